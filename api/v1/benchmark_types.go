@@ -6,6 +6,7 @@
 package v1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -21,35 +22,250 @@ type BenchmarkOperatorMeta struct {
 type BenchmarkSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
-	Operator      BenchmarkOperatorMeta `json:"benchmarkOperator"`
-	Spec          string                `json:"benchmarkSpec"`
-	IterationSpec IterationSpec         `json:"iterationSpec,omitempty"`
-	Repetition    int                   `json:"repetition,omitempty"`
-	JobInterval   int                   `json:"interval,omitempty"`
-	ParserKey     string                `json:"parserKey,omitempty"`
-	BuildConfigs  []ConfigSpec          `json:"trackBuildConfigs,omitempty"`
-	Sidecar       bool                  `json:"sidecar,omitempty"`
+	Operator        BenchmarkOperatorMeta  `json:"benchmarkOperator"`
+	Spec            string                 `json:"benchmarkSpec,omitempty"`
+	SpecFrom        *BenchmarkSpecSource   `json:"benchmarkSpecFrom,omitempty"`
+	IterationSpec   IterationSpec          `json:"iterationSpec,omitempty"`
+	Repetition      int                    `json:"repetition,omitempty"`
+	JobInterval     int                    `json:"interval,omitempty"`
+	ParserKey       string                 `json:"parserKey,omitempty"`
+	BuildConfigs    []ConfigSpec           `json:"trackBuildConfigs,omitempty"`
+	Sidecar         bool                   `json:"sidecar,omitempty"`
+	ExclusiveNodes  *ExclusiveNodesSpec    `json:"exclusiveNodes,omitempty"`
+	Placement       *PlacementSpec         `json:"placement,omitempty"`
+	BuildRetention  *BuildRetentionSpec    `json:"buildRetention,omitempty"`
+	Regression      *RegressionSpec        `json:"regression,omitempty"`
+	BaselineRef     *BaselineReference     `json:"baselineRef,omitempty"`
+	Assertions      []AssertionSpec        `json:"assertions,omitempty"`
+	DerivedMetrics  []DerivedMetricSpec    `json:"derivedMetrics,omitempty"`
+	Resources       []ScenarioResourceSpec `json:"resources,omitempty"`
+	Hooks           *HooksSpec             `json:"hooks,omitempty"`
+	SystemUnderTest *SystemUnderTestSpec   `json:"systemUnderTest,omitempty"`
+}
+
+// Resources deployed once per configuration and shared by all iterations and repetitions of the configuration
+type SystemUnderTestSpec struct {
+	// resources rendered with configuration values, named [benchmark]-sut-[configuration hash]-[role]
+	// and referred as {{ .systemUnderTest.[role].name }} (keep: not deleted when switching configuration)
+	Resources []ScenarioResourceSpec `json:"resources"`
+}
+
+// Jobs run before and after each benchmark job (scenario) or the whole benchmark
+type HooksSpec struct {
+	// batch/v1 JobSpec template with the same values as benchmarkSpec and .hook, run before each benchmark job
+	PreRun string `json:"preRun,omitempty"`
+	// batch/v1 JobSpec template run after each benchmark job is completed
+	PostRun string `json:"postRun,omitempty"`
+	// batch/v1 JobSpec template run before the first benchmark job
+	BenchmarkPreRun string `json:"benchmarkPreRun,omitempty"`
+	// batch/v1 JobSpec template run after all benchmark jobs are completed
+	BenchmarkPostRun string `json:"benchmarkPostRun,omitempty"`
+	// timeout of each hook (default: 600)
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+}
+
+// Resource deployed for each scenario along with the benchmark job (e.g., server of client/server benchmark)
+type ScenarioResourceSpec struct {
+	// role of the resource, named [job name]-[role] and referred as {{ .resources.[role].name }}
+	Role string `json:"role"`
+	// manifest template of namespaced resource with the same values as benchmarkSpec and .role
+	Template string `json:"template"`
+	// readiness gate before creating the benchmark job
+	Readiness *ReadinessSpec `json:"readiness,omitempty"`
+	// keep the resource after the benchmark job is completed
+	Keep bool `json:"keep,omitempty"`
+}
+
+// Readiness of scenario resource (default by kind: readyReplicas of Deployment/StatefulSet/ReplicaSet, numberReady of DaemonSet,
+// Ready condition of Pod, succeeded Job, otherwise ready once created)
+type ReadinessSpec struct {
+	// condition type to be True, e.g., Available
+	Condition string `json:"condition,omitempty"`
+	// location of status value, e.g., .status.phase
+	Path string `json:"path,omitempty"`
+	// expected value at path (default: non-empty value other than 0 and false)
+	Value          string `json:"value,omitempty"`
+	TimeoutSeconds int32  `json:"timeoutSeconds,omitempty"`
+}
+
+// Source of benchmarkSpec template, one of configMapKeyRef, secretKeyRef, url, or oci
+type BenchmarkSpecSource struct {
+	// key of ConfigMap in benchmark namespace
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+	// key of Secret in benchmark namespace
+	SecretKeyRef *corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+	// HTTP(S) URL of raw template
+	URL string `json:"url,omitempty"`
+	// OCI artifact pushed as a single file
+	OCI *OCIArtifactSource `json:"oci,omitempty"`
+	// interval to check a new revision (default: 300)
+	PollIntervalSeconds int32 `json:"pollIntervalSeconds,omitempty"`
+}
+
+type OCIArtifactSource struct {
+	// artifact reference, e.g., quay.io/org/templates:v1 or quay.io/org/templates@sha256:...
+	Image string `json:"image"`
+	// title annotation (org.opencontainers.image.title) of the layer (default: first layer)
+	File string `json:"file,omitempty"`
+	// secret of registry credentials (dockerconfigjson or username/password) in benchmark namespace
+	Secret string `json:"secret,omitempty"`
+	// use http instead of https
+	Insecure bool `json:"insecure,omitempty"`
+}
+
+// Metric computed from parsed values of each result item
+type DerivedMetricSpec struct {
+	Name string `json:"name"`
+	// CEL expression with the same variables as assertions and ref, e.g., tpmC / double(labels.threads)
+	Expression string `json:"expression"`
+	// iteration values of the reference scenario exposed as ref, e.g., threads: "1"
+	Reference map[string]string `json:"reference,omitempty"`
+}
+
+// Assertion evaluated on each result item
+type AssertionSpec struct {
+	Name string `json:"name"`
+	// CEL expression over parsed values and iteration labels, e.g., p99_ms < 50 && tpmC > 1000
+	Expression string `json:"expression"`
+}
+
+// BenchmarkBaseline to evaluate the results against
+type BaselineReference struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// Comparison of each build to the previous or baseline build
+type RegressionSpec struct {
+	// build to compare with (default: previous build with the same scenario)
+	Baseline string `json:"baseline,omitempty"`
+	// minimum relative change of mean to be reported, e.g., 0.05 (default)
+	Tolerance string `json:"tolerance,omitempty"`
+	// significance level of Welch's t-test, e.g., 0.05 (default)
+	SignificanceLevel string `json:"significanceLevel,omitempty"`
+	// bisect versions between baseline and regressed build
+	Bisect *BisectSpec `json:"bisect,omitempty"`
+}
+
+// Versions to bisect when a regression is detected
+type BisectSpec struct {
+	// ordered list of build names or images (default: seen tags of the image repository if its semver is set)
+	Candidates []string `json:"candidates,omitempty"`
+}
+
+// Retention of tracked builds
+type BuildRetentionSpec struct {
+	// keep last N builds (1: only latest, 0: all)
+	KeepLast int `json:"keepLast,omitempty"`
+	// builds always kept
+	Pinned []string `json:"pinned,omitempty"`
+	// schedule only the latest tracked build (and bisection builds); earlier builds keep their results but are not resumed
+	NewBuildsOnly bool `json:"newBuildsOnly,omitempty"`
+}
+
+// Placement of repetitions
+type PlacementSpec struct {
+	// spread: pin each repetition to a different eligible node
+	Policy string `json:"policy"`
+	// eligible nodes (default: exclusiveNodes or nodeSelection selector, otherwise all schedulable nodes)
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+	// dotted location of affinity in benchmarkSpec (default: .template.spec.affinity)
+	AffinityLocation string `json:"affinityLocation,omitempty"`
+}
+
+// Exclusive access to the benchmarked nodes
+type ExclusiveNodesSpec struct {
+	// nodes to reserve (default: nodeSelection selector)
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+	// dotted location of tolerations list in benchmarkSpec (default: .template.spec.tolerations)
+	TolerationLocation   string `json:"tolerationLocation,omitempty"`
+	Cordon               bool   `json:"cordon,omitempty"`
+	LeaseDurationSeconds int32  `json:"leaseDurationSeconds,omitempty"`
 }
 
 // BuildConfig Definition
 type ConfigSpec struct {
-	Name      string `json:"name"`
+	Name string `json:"name"`
+	// BuildConfig (default), PipelineRun (by pipeline name), TaskRun (by task name), or Workflow (by workflow template name)
+	// kind of watched resource if apiVersion is set
 	Kind      string `json:"kind,omitempty"`
 	Namespace string `json:"namespace,omitempty"`
+	// generic build source (tracked by selector instead of name)
+	APIVersion    string                `json:"apiVersion,omitempty"`
+	Selector      *metav1.LabelSelector `json:"selector,omitempty"`
+	FieldSelector string                `json:"fieldSelector,omitempty"`
+	// location of value that signals success (e.g., .status.phase)
+	SuccessPath string `json:"successPath,omitempty"`
+	// default: True
+	SuccessValue string `json:"successValue,omitempty"`
+	// location of produced artifact (e.g., image reference, version)
+	ArtifactPath string `json:"artifactPath,omitempty"`
+	DigestPath   string `json:"digestPath,omitempty"`
+	// image repository polled by registry v2 API (kind: ImageRepository), e.g., quay.io/org/app
+	Image string `json:"image,omitempty"`
+	// regular expression of tags to track
+	TagFilter string `json:"tagFilter,omitempty"`
+	// semantic version constraint of tags to track, e.g., >=1.2.0
+	Semver string `json:"semver,omitempty"`
+	// secret of registry credentials (dockerconfigjson or username/password) in namespace (default: benchmark namespace)
+	Secret              string `json:"secret,omitempty"`
+	PollIntervalSeconds int32  `json:"pollIntervalSeconds,omitempty"`
+	// track new digest of the same tag
+	TrackDigest bool `json:"trackDigest,omitempty"`
+	// use http instead of https
+	Insecure bool `json:"insecure,omitempty"`
+}
+
+// Image tags seen by polling image repository
+type SeenImageTags struct {
+	Config string   `json:"config"`
+	Tags   []string `json:"tags"`
+}
+
+// Completed build tracked by trackBuildConfigs
+type TrackedBuild struct {
+	// build value listed in builds
+	Name   string `json:"name"`
+	Kind   string `json:"kind"`
+	Config string `json:"config"`
+	Image  string `json:"image,omitempty"`
+	Digest string `json:"digest,omitempty"`
 }
 
 type IterationSpec struct {
-	Iteration     []IterationItem    `json:"iterations,omitempty"`
-	NodeSelection *NodeSelectionSpec `json:"nodeSelection,omitempty"`
-	Configuration []IterationItem    `json:"configurations,omitempty"`
-	Sequential    bool               `json:"sequential,omitempty"`
-	Minimize      bool               `json:"minimize,omitempty"`
+	Iteration     []IterationItem     `json:"iterations,omitempty"`
+	NodeSelection *NodeSelectionSpec  `json:"nodeSelection,omitempty"`
+	Configuration []IterationItem     `json:"configurations,omitempty"`
+	Sequential    bool                `json:"sequential,omitempty"`
+	Minimize      bool                `json:"minimize,omitempty"`
+	Order         *ExecutionOrderSpec `json:"order,omitempty"`
+}
+
+// Order of jobs in waiting list
+type ExecutionOrderSpec struct {
+	// sequential (default), shuffle, or interleave
+	Strategy string `json:"strategy,omitempty"`
+	// seed of shuffle (default: generated and recorded in status)
+	Seed *int64 `json:"seed,omitempty"`
+	// build (default) or configuration
+	InterleaveBy string `json:"interleaveBy,omitempty"`
 }
 
 type NodeSelectionSpec struct {
 	Location       string                `json:"location"`
 	TunedValues    []string              `json:"values"`
 	TargetSelector *metav1.LabelSelector `json:"selector,omitempty"`
+	Export         *ProfileExportSpec    `json:"export,omitempty"`
+}
+
+// Export of finalized auto-tuned profile
+type ProfileExportSpec struct {
+	// Tuned or ConfigMap (default: Tuned if node tuning operator exists)
+	Kind      string            `json:"kind,omitempty"`
+	Name      string            `json:"name,omitempty"`
+	Namespace string            `json:"namespace,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Disabled  bool              `json:"disabled,omitempty"`
 }
 
 // Iteration Definition
@@ -57,16 +273,48 @@ type IterationItem struct {
 	Name     string   `json:"name"`
 	Location string   `json:"location"`
 	Values   []string `json:"values,omitempty"`
+	// configuration applied to helm deployment of BenchmarkOperator: version (chart version) or values path, e.g., .image.tag
+	Helm string `json:"helm,omitempty"`
+	// CEL expression of the other items computing the value instead of values, e.g., threads * 2
+	Expression string `json:"expression,omitempty"`
+	// JSON Patch operation for JSON Pointer or JSONPath location: add (default), replace, or remove
+	Op string `json:"op,omitempty"`
+	// type of value set at JSON Pointer or JSONPath location: string, int, float, bool, or yaml (default: type of the current value)
+	// string also keeps the value as a string in expressions of derived items
+	Type string `json:"type,omitempty"`
+	// add creates missing parents at JSON Pointer or JSONPath location (default: parent must exist as in JSON Patch)
+	CreateParents bool `json:"createParents,omitempty"`
 }
 
 type BenchmarkResultItem struct {
-	Repetition       string `json:"run"`
-	JobName          string `json:"job"`
-	PodName          string `json:"pod"`
-	PerformanceKey   string `json:"performanceKey"`
-	PerformanceValue string `json:"performanceValue"`
-	Result           string `json:"parseResult"`
-	PushedTime       string `json:"pushedTime"`
+	Repetition       string           `json:"run"`
+	JobName          string           `json:"job"`
+	PodName          string           `json:"pod"`
+	NodeName         string           `json:"node,omitempty"`
+	NodeInfo         *NodeFingerprint `json:"nodeInfo,omitempty"`
+	PerformanceKey   string           `json:"performanceKey"`
+	PerformanceValue string           `json:"performanceValue"`
+	Result           string           `json:"parseResult"`
+	// names of assertions that are false or cannot be evaluated
+	FailedAssertions []string `json:"failedAssertions,omitempty"`
+	// values of derivedMetrics
+	DerivedValues map[string]string `json:"derivedValues,omitempty"`
+	PushedTime    string            `json:"pushedTime"`
+}
+
+// Node metadata of the node that runs the job, read when the result is parsed
+type NodeFingerprint struct {
+	KernelVersion           string            `json:"kernelVersion,omitempty"`
+	OSImage                 string            `json:"osImage,omitempty"`
+	Architecture            string            `json:"architecture,omitempty"`
+	ContainerRuntimeVersion string            `json:"containerRuntimeVersion,omitempty"`
+	KubeletVersion          string            `json:"kubeletVersion,omitempty"`
+	InstanceType            string            `json:"instanceType,omitempty"`
+	Zone                    string            `json:"zone,omitempty"`
+	Region                  string            `json:"region,omitempty"`
+	CPUModel                string            `json:"cpuModel,omitempty"`
+	Labels                  map[string]string `json:"labels,omitempty"`
+	Allocatable             map[string]string `json:"allocatable,omitempty"`
 }
 
 // BemchmarkIterationHash
@@ -85,6 +333,71 @@ type BenchmarkResult struct {
 	ConfigurationID  string                `json:"configID"`
 	ConfigurationMap map[string]string     `json:"configurations"`
 	Items            []BenchmarkResultItem `json:"repetitions"`
+	Summary          *ResultSummary        `json:"summary,omitempty"`
+}
+
+// Statistics of performance values over repetitions
+type ResultSummary struct {
+	Count  int    `json:"count"`
+	Mean   string `json:"mean"`
+	StdDev string `json:"stdDev"`
+	// number of distinct nodes that run the repetitions
+	NodeCount int `json:"nodeCount,omitempty"`
+	// variance components of one-way random-effects ANOVA by node, set only if repetitions outnumber nodes
+	BetweenNodeVariance string `json:"betweenNodeVariance,omitempty"`
+	WithinNodeVariance  string `json:"withinNodeVariance,omitempty"`
+}
+
+// Comparison of a build to its baseline on the same scenario
+type RegressionResult struct {
+	BuildID         string `json:"build"`
+	BaselineID      string `json:"baseline"`
+	IterationID     string `json:"scenarioID"`
+	ConfigurationID string `json:"configID"`
+	PerformanceKey  string `json:"performanceKey"`
+	BaselineMean    string `json:"baselineMean"`
+	Mean            string `json:"mean"`
+	// relative change of mean to baseline mean
+	Change string `json:"change"`
+	PValue string `json:"pValue"`
+	// Regressed, Improved, or Unchanged
+	Verdict string `json:"verdict"`
+}
+
+// Comparison of a result to the reference value of BenchmarkBaseline
+type BaselineComparison struct {
+	BuildID         string `json:"build"`
+	IterationID     string `json:"scenarioID"`
+	ConfigurationID string `json:"configID"`
+	PerformanceKey  string `json:"performanceKey"`
+	BaselineValue   string `json:"baselineValue"`
+	Mean            string `json:"mean"`
+	// relative change of mean to baseline value
+	Change    string `json:"change"`
+	Tolerance string `json:"tolerance"`
+	Passed    bool   `json:"passed"`
+	// no result of the evaluated build matches the baseline value (counted as failed)
+	Missing bool `json:"missing,omitempty"`
+}
+
+// Binary search of the first bad version on the regressed scenario
+type BisectionStatus struct {
+	// regressed build and its baseline
+	Build            string            `json:"build"`
+	Baseline         string            `json:"baseline"`
+	Good             string            `json:"good"`
+	Bad              string            `json:"bad"`
+	IterationID      string            `json:"scenarioID"`
+	IterationMap     map[string]string `json:"scenarios"`
+	ConfigurationID  string            `json:"configID"`
+	ConfigurationMap map[string]string `json:"configurations"`
+	// untested versions between good and bad
+	Remaining []string `json:"remaining,omitempty"`
+	// version under test
+	Testing  string `json:"testing,omitempty"`
+	FirstBad string `json:"firstBad,omitempty"`
+	// Running or Completed
+	Phase string `json:"phase"`
 }
 
 type BenchmarkBestResult struct {
@@ -95,15 +408,57 @@ type BenchmarkBestResult struct {
 	PerformanceValue string            `json:"performanceValue"`
 }
 
+type ExportedProfile struct {
+	Kind             string `json:"kind"`
+	Name             string `json:"name"`
+	Namespace        string `json:"namespace"`
+	JobName          string `json:"job"`
+	BuildID          string `json:"build"`
+	IterationID      string `json:"scenarioID"`
+	SamplingCount    int    `json:"samples"`
+	PerformanceKey   string `json:"performanceKey"`
+	PerformanceValue string `json:"performanceValue"`
+}
+
 // BenchmarkStatus defines the observed state of Benchmark
 type BenchmarkStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
-	Hash          []IterationHash       `json:"hash,omitempty"`
-	Results       []BenchmarkResult     `json:"results,omitempty"`
-	BestResults   []BenchmarkBestResult `json:"bestResults,omitempty"`
-	TrackedBuilds []string              `json:"builds,omitempty"`
-	JobCompleted  string                `json:"jobCompleted,omitempty"`
+	Hash             []IterationHash       `json:"hash,omitempty"`
+	Results          []BenchmarkResult     `json:"results,omitempty"`
+	BestResults      []BenchmarkBestResult `json:"bestResults,omitempty"`
+	TrackedBuilds    []string              `json:"builds,omitempty"`
+	BuildDetails     []TrackedBuild        `json:"buildDetails,omitempty"`
+	ImageTags        []SeenImageTags       `json:"imageTags,omitempty"`
+	JobCompleted     string                `json:"jobCompleted,omitempty"`
+	ExportedProfiles []ExportedProfile     `json:"exportedProfiles,omitempty"`
+	ReservedNodes    []string              `json:"reservedNodes,omitempty"`
+	OrderSeed        *int64                `json:"orderSeed,omitempty"`
+	Regressions      []RegressionResult    `json:"regressions,omitempty"`
+	Bisections       []BisectionStatus     `json:"bisections,omitempty"`
+	BaselineResults  []BaselineComparison  `json:"baselineResults,omitempty"`
+	Conditions       []metav1.Condition    `json:"conditions,omitempty"`
+	SpecRevision     string                `json:"specRevision,omitempty"`
+	ResultHistory    []RevisionResults     `json:"resultHistory,omitempty"`
+	FailedJobs       []FailedJob           `json:"failedJobs,omitempty"`
+}
+
+// Benchmark job (scenario) failed by its hook, its preparation step (e.g., resources), or the job resource (empty hook)
+type FailedJob struct {
+	JobName         string `json:"job,omitempty"`
+	BuildID         string `json:"build,omitempty"`
+	IterationID     string `json:"scenarioID,omitempty"`
+	ConfigurationID string `json:"configID,omitempty"`
+	Repetition      string `json:"repetition,omitempty"`
+	Hook            string `json:"hook"`
+	Message         string `json:"message,omitempty"`
+}
+
+// Results of previous revision of benchmarkSpec
+type RevisionResults struct {
+	Revision    string                `json:"revision"`
+	Results     []BenchmarkResult     `json:"results,omitempty"`
+	BestResults []BenchmarkBestResult `json:"bestResults,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -0,0 +1,69 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Benchmark results to capture reference values from
+type BaselineSource struct {
+	Benchmark string `json:"benchmark"`
+	Namespace string `json:"namespace,omitempty"`
+	// build of results (default: latest build with results)
+	Build string `json:"build,omitempty"`
+}
+
+// Reference value of a scenario
+type BaselineValue struct {
+	IterationID     string `json:"scenarioID"`
+	ConfigurationID string `json:"configID,omitempty"`
+	PerformanceKey  string `json:"performanceKey,omitempty"`
+	Value           string `json:"value"`
+	// relative tolerance of this value (default: spec.tolerance)
+	Tolerance string `json:"tolerance,omitempty"`
+}
+
+// BenchmarkBaselineSpec defines the desired state of BenchmarkBaseline
+type BenchmarkBaselineSpec struct {
+	Source *BaselineSource `json:"source,omitempty"`
+	// manual reference values, override values captured from source
+	Values []BaselineValue `json:"values,omitempty"`
+	// relative tolerance, e.g., 0.05 (default)
+	Tolerance string `json:"tolerance,omitempty"`
+}
+
+// BenchmarkBaselineStatus defines the observed state of BenchmarkBaseline
+type BenchmarkBaselineStatus struct {
+	// build captured from source
+	SourceBuild string          `json:"sourceBuild,omitempty"`
+	Values      []BaselineValue `json:"values,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// BenchmarkBaseline is the Schema for the benchmarkbaselines API
+type BenchmarkBaseline struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   BenchmarkBaselineSpec   `json:"spec,omitempty"`
+	Status BenchmarkBaselineStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// BenchmarkBaselineList contains a list of BenchmarkBaseline
+type BenchmarkBaselineList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []BenchmarkBaseline `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&BenchmarkBaseline{}, &BenchmarkBaselineList{})
+}
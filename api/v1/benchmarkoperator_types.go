@@ -20,6 +20,8 @@ type HelmSpec struct {
 	Username   string `json:"user,omitempty"`
 	Password   string `json:"password,omitempty"`
 	ValuesYaml string `json:"valuesYaml,omitempty"`
+	// seconds to roll out a helm iteration since it is requested by the benchmark (default: 600)
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
 }
 
 type YAMLSpec struct {
@@ -33,22 +35,61 @@ type DeploymentSpec struct {
 	Helm      HelmSpec `json:"helm,omitempty"`
 }
 
+// Status value of the job resource at the path
+type StatusMatchSpec struct {
+	// JSON Pointer or JSONPath to the value, e.g., /status/state
+	Path   string   `json:"path"`
+	Values []string `json:"values"`
+}
+
+// Adaptor of job resource declared without code
+type GenericAdaptorSpec struct {
+	// condition types in .status.conditions with status True when the job is completed, e.g., Succeeded
+	CompleteConditions []string `json:"completeConditions,omitempty"`
+	// status value when the job is completed
+	CompleteStatus *StatusMatchSpec `json:"completeStatus,omitempty"`
+	// condition types in .status.conditions with status True when the job is failed, e.g., Failed
+	FailedConditions []string `json:"failedConditions,omitempty"`
+	// status value when the job is failed
+	FailedStatus *StatusMatchSpec `json:"failedStatus,omitempty"`
+	// label selector of the job pods rendered over the job object (default: job-name={{ .metadata.name }})
+	PodSelector string `json:"podSelector,omitempty"`
+	// name prefix of the pods with the result log rendered over the job object (default: all job pods)
+	ResultPod string `json:"resultPod,omitempty"`
+	// container with the result log (required if the pods have multiple containers)
+	ResultContainer string `json:"resultContainer,omitempty"`
+}
+
 // BenchmarkOperatorSpec defines the desired state of BenchmarkOperator
 type BenchmarkOperatorSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 
-	APIVersion string         `json:"apiVersion"`
-	Kind       string         `json:"kind"`
-	Adaptor    string         `json:"adaptor,omitempty"`
-	CRD        YAMLSpec       `json:"crd,omitempty"`
-	DeploySpec DeploymentSpec `json:"deploySpec"`
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Adaptor    string `json:"adaptor,omitempty"`
+	// used instead of adaptor if set
+	GenericAdaptor *GenericAdaptorSpec `json:"genericAdaptor,omitempty"`
+	CRD            YAMLSpec            `json:"crd,omitempty"`
+	DeploySpec     DeploymentSpec      `json:"deploySpec"`
+}
+
+// Chart version and values paths overriding helm deployment by iterating benchmark
+type HelmIteration struct {
+	Benchmark string            `json:"benchmark,omitempty"`
+	Version   string            `json:"version,omitempty"`
+	Values    map[string]string `json:"values,omitempty"`
 }
 
 // BenchmarkOperatorStatus defines the observed state of BenchmarkOperator
 type BenchmarkOperatorStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
+
+	// helm iteration last installed, rolled out unless helmIterationError is set
+	HelmIteration *HelmIteration `json:"helmIteration,omitempty"`
+	// error of installing helmIteration
+	HelmIterationError string `json:"helmIterationError,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -0,0 +1,146 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+
+package controllers
+
+///////////////////////////////////////////////////////////////////////////
+//
+// assertion.go
+//
+// GetAssertionVariables
+// - expose parsed values of result item as CEL variables
+//   each key (non-identifier characters replaced by _), values (raw keys),
+//   labels (iterations and configurations), and performanceValue
+// EvaluateAssertions
+// - evaluate CEL expressions of assertions, return names of failed ones
+//   (expressions that are not true or cannot be evaluated)
+// - programs are compiled once per expression and variable names and reused
+//
+////////////////////////////////////////////////////////////////////////////
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker/decls"
+	exprpb "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+)
+
+const (
+	ASSERTION_VALUES_VAR            = "values"
+	ASSERTION_LABELS_VAR            = "labels"
+	ASSERTION_PERFORMANCE_VALUE_VAR = "performanceValue"
+	MAX_CACHED_PROGRAMS             = 1000
+)
+
+var nonIdentifierRegex *regexp.Regexp = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// GetVariableName converts parsed key to CEL identifier
+func GetVariableName(key string) string {
+	name := nonIdentifierRegex.ReplaceAllString(key, "_")
+	if len(name) > 0 && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+// GetAssertionVariables returns variables of the result item
+func GetAssertionVariables(item cpev1.BenchmarkResultItem, iterationMap map[string]string, configurationMap map[string]string) map[string]interface{} {
+	values := make(map[string]interface{})
+	if item.Result != "" {
+		json.Unmarshal([]byte(item.Result), &values)
+	}
+	labels := make(map[string]interface{})
+	for key, value := range iterationMap {
+		labels[key] = value
+	}
+	for key, value := range configurationMap {
+		labels[key] = value
+	}
+	variables := make(map[string]interface{})
+	for key, value := range values {
+		variables[GetVariableName(key)] = value
+	}
+	variables[ASSERTION_VALUES_VAR] = values
+	variables[ASSERTION_LABELS_VAR] = labels
+	performanceValue, _ := strconv.ParseFloat(item.PerformanceValue, 64)
+	variables[ASSERTION_PERFORMANCE_VALUE_VAR] = performanceValue
+	return variables
+}
+
+var programCache map[string]cel.Program = make(map[string]cel.Program)
+var programCacheMutex sync.Mutex
+
+// getProgram returns the compiled program of the expression with the variable names declared
+func getProgram(expression string, variables map[string]interface{}) (cel.Program, error) {
+	var names []string
+	for name := range variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	cacheKey := expression + "\n" + strings.Join(names, ",")
+	programCacheMutex.Lock()
+	defer programCacheMutex.Unlock()
+	if program, ok := programCache[cacheKey]; ok {
+		return program, nil
+	}
+	var declarations []*exprpb.Decl
+	for _, name := range names {
+		declarations = append(declarations, decls.NewVar(name, decls.Dyn))
+	}
+	env, err := cel.NewEnv(cel.Declarations(declarations...), cel.CrossTypeNumericComparisons(true))
+	if err != nil {
+		return nil, err
+	}
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+	if len(programCache) >= MAX_CACHED_PROGRAMS {
+		programCache = make(map[string]cel.Program)
+	}
+	programCache[cacheKey] = program
+	return program, nil
+}
+
+func evaluateExpression(expression string, variables map[string]interface{}) (interface{}, error) {
+	program, err := getProgram(expression, variables)
+	if err != nil {
+		return nil, err
+	}
+	out, _, err := program.Eval(variables)
+	if err != nil {
+		return nil, err
+	}
+	return out.Value(), nil
+}
+
+// EvaluateAssertions returns names of failed assertions and the reasons
+func EvaluateAssertions(assertions []cpev1.AssertionSpec, variables map[string]interface{}) ([]string, []string) {
+	var failed []string
+	var messages []string
+	for _, assertion := range assertions {
+		value, err := evaluateExpression(assertion.Expression, variables)
+		if err != nil {
+			failed = append(failed, assertion.Name)
+			messages = append(messages, fmt.Sprintf("%s: %v", assertion.Name, err))
+		} else if passed, ok := value.(bool); !ok || !passed {
+			failed = append(failed, assertion.Name)
+			messages = append(messages, fmt.Sprintf("%s: %s is %v", assertion.Name, assertion.Expression, value))
+		}
+	}
+	return failed, messages
+}
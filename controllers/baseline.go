@@ -0,0 +1,235 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+
+package controllers
+
+///////////////////////////////////////////////////////////////////////////
+//
+// baseline.go
+//
+// GetBaselineValues
+// - capture mean of each scenario of the source benchmark build as reference values
+// EvaluateBaseline
+// - compare mean of each scenario of the latest build to the reference value
+//   within relative tolerance (direction by iterationSpec.minimize)
+//   a baseline value without matching result is a missing (failed) comparison
+// SetBaselineConditions
+// - set Passed/Failed conditions once all jobs of the latest build have results
+//   (not passed if any result is missing or any job of the build failed)
+// GetBaselineRefKeys
+// - index benchmarks by referred baseline to re-evaluate them on baseline change
+//
+////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	CONDITION_PASSED           = "Passed"
+	CONDITION_FAILED           = "Failed"
+	REASON_MISSING_RESULTS     = "MissingResults"
+	DEFAULT_BASELINE_TOLERANCE = 0.05
+	// field index of benchmarks by namespace/name of the referred baseline
+	BASELINE_REF_INDEX = "spec.baselineRef"
+)
+
+// GetBaselineRefKeys returns namespace/name of the referred baseline (values of BASELINE_REF_INDEX)
+func GetBaselineRefKeys(benchmark *cpev1.Benchmark) []string {
+	baselineRef := benchmark.Spec.BaselineRef
+	if baselineRef == nil {
+		return []string{}
+	}
+	namespace := baselineRef.Namespace
+	if namespace == "" {
+		namespace = benchmark.Namespace
+	}
+	return []string{types.NamespacedName{Name: baselineRef.Name, Namespace: namespace}.String()}
+}
+
+// GetEvaluatedBuild returns the latest iterated build that is not a bisection candidate
+func GetEvaluatedBuild(benchmark *cpev1.Benchmark) string {
+	_, _, builds, _ := GetIteratedValues(benchmark)
+	for index := len(builds) - 1; index >= 0; index-- {
+		if !isBisectionBuild(benchmark, builds[index]) {
+			return builds[index]
+		}
+	}
+	return INIT_BUILD_NAME
+}
+
+// GetBaselineValues returns mean of each result of the build (default: latest build with results) as reference values
+func GetBaselineValues(benchmark *cpev1.Benchmark, build string) ([]cpev1.BaselineValue, string) {
+	if build == "" {
+		build = INIT_BUILD_NAME
+		buildsWithResult := make(map[string]bool)
+		for _, result := range benchmark.Status.Results {
+			buildsWithResult[result.BuildID] = true
+		}
+		for index := len(benchmark.Status.TrackedBuilds) - 1; index >= 0; index-- {
+			trackedBuild := benchmark.Status.TrackedBuilds[index]
+			if buildsWithResult[trackedBuild] && !isBisectionBuild(benchmark, trackedBuild) {
+				build = trackedBuild
+				break
+			}
+		}
+	}
+	var values []cpev1.BaselineValue
+	for _, result := range benchmark.Status.Results {
+		performanceValues := getPerformanceValues(result)
+		if result.BuildID != build || len(performanceValues) == 0 {
+			continue
+		}
+		values = append(values, cpev1.BaselineValue{
+			IterationID:     result.IterationID,
+			ConfigurationID: result.ConfigurationID,
+			PerformanceKey:  result.Items[0].PerformanceKey,
+			Value:           fmt.Sprintf("%f", getMean(performanceValues)),
+		})
+	}
+	return values, build
+}
+
+// MergeBaselineValues returns captured values overridden by manual values of the same scenario
+func MergeBaselineValues(captured []cpev1.BaselineValue, manual []cpev1.BaselineValue) []cpev1.BaselineValue {
+	manualKeys := make(map[string]bool)
+	for _, value := range manual {
+		manualKeys[value.IterationID+"/"+value.ConfigurationID] = true
+	}
+	var values []cpev1.BaselineValue
+	for _, value := range captured {
+		if !manualKeys[value.IterationID+"/"+value.ConfigurationID] {
+			values = append(values, value)
+		}
+	}
+	return append(values, manual...)
+}
+
+// EvaluateBaseline compares results of the evaluated build to the baseline values,
+// return comparisons and whether all jobs of the build have results
+func EvaluateBaseline(benchmark *cpev1.Benchmark, baseline *cpev1.BenchmarkBaseline) ([]cpev1.BaselineComparison, bool) {
+	build := GetEvaluatedBuild(benchmark)
+	defaultTolerance := getFloatOrDefault(baseline.Spec.Tolerance, DEFAULT_BASELINE_TOLERANCE)
+	var comparisons []cpev1.BaselineComparison
+	for _, baselineValue := range baseline.Status.Values {
+		tolerance := getFloatOrDefault(baselineValue.Tolerance, defaultTolerance)
+		missingComparison := cpev1.BaselineComparison{
+			BuildID:         build,
+			IterationID:     baselineValue.IterationID,
+			ConfigurationID: baselineValue.ConfigurationID,
+			PerformanceKey:  baselineValue.PerformanceKey,
+			BaselineValue:   baselineValue.Value,
+			Tolerance:       fmt.Sprintf("%f", tolerance),
+			Missing:         true,
+		}
+		referenceValue, err := strconv.ParseFloat(baselineValue.Value, 64)
+		if err != nil {
+			comparisons = append(comparisons, missingComparison)
+			continue
+		}
+		matched := false
+		for _, result := range benchmark.Status.Results {
+			if result.BuildID != build || result.IterationID != baselineValue.IterationID || result.ConfigurationID != baselineValue.ConfigurationID {
+				continue
+			}
+			values := getPerformanceValues(result)
+			if len(values) == 0 {
+				continue
+			}
+			if baselineValue.PerformanceKey != "" && result.Items[0].PerformanceKey != baselineValue.PerformanceKey {
+				continue
+			}
+			mean := getMean(values)
+			change := 0.0
+			if referenceValue != 0 {
+				change = (mean - referenceValue) / math.Abs(referenceValue)
+			}
+			passed := change >= -tolerance
+			if benchmark.Spec.IterationSpec.Minimize {
+				passed = change <= tolerance
+			}
+			matched = true
+			comparisons = append(comparisons, cpev1.BaselineComparison{
+				BuildID:         build,
+				IterationID:     result.IterationID,
+				ConfigurationID: result.ConfigurationID,
+				PerformanceKey:  result.Items[0].PerformanceKey,
+				BaselineValue:   baselineValue.Value,
+				Mean:            fmt.Sprintf("%f", mean),
+				Change:          fmt.Sprintf("%f", change),
+				Tolerance:       fmt.Sprintf("%f", tolerance),
+				Passed:          passed,
+			})
+		}
+		if !matched {
+			comparisons = append(comparisons, missingComparison)
+		}
+	}
+	return comparisons, IsBuildCompleted(benchmark, build)
+}
+
+// countFailedJobs returns number of failed jobs of the build (no result is expected from them)
+func countFailedJobs(benchmark *cpev1.Benchmark, build string) int {
+	count := 0
+	for _, failedJob := range benchmark.Status.FailedJobs {
+		if failedJob.BuildID == build {
+			count++
+		}
+	}
+	return count
+}
+
+// SetBaselineConditions sets Passed and Failed conditions from the comparisons,
+// missing results (no comparison, missing comparison, or failed job of the build) fail the gate
+func SetBaselineConditions(benchmark *cpev1.Benchmark, baselineName string, comparisons []cpev1.BaselineComparison, completed bool) {
+	build := GetEvaluatedBuild(benchmark)
+	status, reason := metav1.ConditionUnknown, "Evaluating"
+	message := fmt.Sprintf("waiting for results of build %s", build)
+	if completed {
+		failed, missing := 0, 0
+		for _, comparison := range comparisons {
+			if comparison.Missing {
+				missing++
+			} else if !comparison.Passed {
+				failed++
+			}
+		}
+		failedJobs := countFailedJobs(benchmark, build)
+		message = fmt.Sprintf("%d/%d scenarios failed against %s", failed+missing, len(comparisons), baselineName)
+		if len(comparisons) == 0 || missing > 0 || failedJobs > 0 {
+			status, reason = metav1.ConditionFalse, REASON_MISSING_RESULTS
+			message = fmt.Sprintf("%s (%d without results, %d failed jobs of build %s)", message, missing, failedJobs, build)
+		} else if failed == 0 {
+			status, reason = metav1.ConditionTrue, "WithinTolerance"
+		} else {
+			status, reason = metav1.ConditionFalse, "OutOfTolerance"
+		}
+	}
+	failedStatus := status
+	if status == metav1.ConditionTrue {
+		failedStatus = metav1.ConditionFalse
+	} else if status == metav1.ConditionFalse {
+		failedStatus = metav1.ConditionTrue
+	}
+	meta.SetStatusCondition(&benchmark.Status.Conditions, metav1.Condition{
+		Type:    CONDITION_PASSED,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	})
+	meta.SetStatusCondition(&benchmark.Status.Conditions, metav1.Condition{
+		Type:    CONDITION_FAILED,
+		Status:  failedStatus,
+		Reason:  reason,
+		Message: message,
+	})
+}
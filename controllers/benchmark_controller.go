@@ -14,6 +14,8 @@
 //   2. create job tracker if not exists for the target job resource
 //   3. deploy only the first undeployed-yet manifests
 //      put the rest in waiting to the job tracker
+// - Watch BenchmarkBaseline
+//   enqueue benchmarks referring the changed baseline (by BASELINE_REF_INDEX)
 //
 ////////////////////////////////////////////////////////////////////////////
 
@@ -22,11 +24,13 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"reflect"
 
 	"time"
 
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/discovery"
@@ -34,6 +38,9 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	cpev1 "github.com/IBM/cpe-operator/api/v1"
 )
@@ -41,17 +48,29 @@ import (
 // BenchmarkReconciler reconciles a Benchmark object
 type BenchmarkReconciler struct {
 	client.Client
-	Log    logr.Logger
-	Scheme *runtime.Scheme
-	DC     *discovery.DiscoveryClient
-	DYN    dynamic.Interface
-	JTM    *JobTrackManager
+	// uncached reader for objects referred by benchmarkSpecFrom
+	APIReader client.Reader
+	Log       logr.Logger
+	Scheme    *runtime.Scheme
+	DC        *discovery.DiscoveryClient
+	DYN       dynamic.Interface
+	JTM       *JobTrackManager
 	*TunedHandler
+	BuildWatcher *BuildWatcher
 }
 
 //+kubebuilder:rbac:groups=cpe.cogadvisor.io,resources=benchmarks,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=cpe.cogadvisor.io,resources=benchmarks/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=cpe.cogadvisor.io,resources=benchmarks/finalizers,verbs=update
+//+kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;watch;create;update;delete
+//+kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=tekton.dev,resources=pipelineruns;taskruns,verbs=get;list;watch
+//+kubebuilder:rbac:groups=argoproj.io,resources=workflows,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups=cpe.cogadvisor.io,resources=benchmarkbaselines,verbs=get;list;watch
+//+kubebuilder:rbac:groups=ray.io,resources=rayclusters,verbs=get;list;watch;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -64,6 +83,7 @@ type BenchmarkReconciler struct {
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.7.2/pkg/reconcile
 
 const ReconcileTime = 30 * time.Minute
+const ReservationRetryTime = 1 * time.Minute
 
 const benchmarkFinalizer = "finalizers.benchmark.cpe.cogadvisor.io"
 
@@ -111,6 +131,9 @@ func (r *BenchmarkReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 			return ctrl.Result{}, nil
 		}
 	} else {
+		if r.BuildWatcher != nil {
+			r.BuildWatcher.WatchBuildConfigs(instance)
+		}
 		r.Log.Info(fmt.Sprintf("Creating #%s ", instance.ObjectMeta.Name))
 		operatorName := instance.Spec.Operator.Name
 		operatorNS := instance.Spec.Operator.Namespace
@@ -123,27 +146,124 @@ func (r *BenchmarkReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 			r.Log.Info(fmt.Sprintf("Cannot get #%v ", err))
 			return ctrl.Result{}, nil
 		}
-		var adaptor OperatorAdaptor
-		if _, adaptorExists := OperatorAdaptorMap[operator.Spec.Adaptor]; adaptorExists {
-			adaptor = OperatorAdaptorMap[operator.Spec.Adaptor]
-		} else {
-			adaptor = OperatorAdaptorMap["default"]
-		}
+		adaptor := GetOperatorAdaptor(operator)
 		r.Log.Info(fmt.Sprintf("Operator #%s ", operator.ObjectMeta.Name))
 
-		CreateFromOperator(r.JTM, r.Client, r.DC, r.DYN, instance, operator, r.Log, adaptor, r.TunedHandler)
+		// template referred by benchmarkSpecFrom is kept only in memory
+		spec, revision, err := ResolveBenchmarkSpec(r.APIReader, instance)
+		if err != nil {
+			r.Log.Info(fmt.Sprintf("Cannot get benchmarkSpec #%v ", err))
+			if instance.Spec.SpecFrom == nil {
+				// nothing to run until the benchmark is updated
+				return ctrl.Result{}, nil
+			}
+			return ctrl.Result{RequeueAfter: GetSpecPollInterval(instance)}, nil
+		}
+		instance.Spec.Spec = spec
+		r.startSpecRevision(instance, operator, revision)
+
+		err = CreateFromOperator(r.JTM, r.Client, r.DC, r.DYN, instance, operator, r.Log, adaptor, r.TunedHandler)
+		if err != nil && instance.Spec.ExclusiveNodes != nil && len(instance.Status.ReservedNodes) == 0 {
+			// nodes are reserved by others, retry later
+			return ctrl.Result{RequeueAfter: ReservationRetryTime}, nil
+		}
+		if instance.Spec.BaselineRef != nil {
+			r.evaluateBaseline(instance)
+		}
+		if instance.Spec.SpecFrom != nil {
+			// check a new revision
+			return ctrl.Result{RequeueAfter: GetSpecPollInterval(instance)}, nil
+		}
 	}
 
 	return ctrl.Result{}, nil
 }
 
+// startSpecRevision deletes jobs of the previous benchmarkSpec revision and starts a new result set
+func (r *BenchmarkReconciler) startSpecRevision(instance *cpev1.Benchmark, operator *cpev1.BenchmarkOperator, revision string) {
+	previousHash, changed := StartSpecRevision(instance, revision)
+	if !changed {
+		return
+	}
+	r.Log.Info(fmt.Sprintf("Start revision %s of %s", revision, instance.GetName()))
+	gvk := GetSimpleJobGVK(operator)
+	r.JTM.DeleteTracker(gvk, instance.GetName())
+	if dr := getResourceInterface(r.DC, r.DYN, &gvk, instance.Namespace); dr != nil {
+		for _, hashItem := range previousHash {
+			dr.Delete(context.TODO(), getJobNameFromHash(instance.GetName(), hashItem.Hash), metav1.DeleteOptions{})
+		}
+	}
+	for _, hashItem := range previousHash {
+		DeleteScenarioResources(r.DC, r.DYN, instance, hashItem)
+	}
+	err := r.Client.Status().Update(context.Background(), instance)
+	if err != nil {
+		r.Log.Info(fmt.Sprintf("Cannot update revision #%v ", err))
+	}
+}
+
+// evaluateBaseline updates comparisons to the referred baseline and Passed/Failed conditions
+func (r *BenchmarkReconciler) evaluateBaseline(instance *cpev1.Benchmark) {
+	baselineRef := instance.Spec.BaselineRef
+	namespace := baselineRef.Namespace
+	if namespace == "" {
+		namespace = instance.Namespace
+	}
+	baseline := &cpev1.BenchmarkBaseline{}
+	err := r.Client.Get(context.Background(), types.NamespacedName{Name: baselineRef.Name, Namespace: namespace}, baseline)
+	if err != nil {
+		r.Log.Info(fmt.Sprintf("Cannot get baseline #%v ", err))
+		return
+	}
+	comparisons, completed := EvaluateBaseline(instance, baseline)
+	conditions := append([]metav1.Condition{}, instance.Status.Conditions...)
+	SetBaselineConditions(instance, baseline.Name, comparisons, completed)
+	if reflect.DeepEqual(comparisons, instance.Status.BaselineResults) && reflect.DeepEqual(conditions, instance.Status.Conditions) {
+		return
+	}
+	instance.Status.BaselineResults = comparisons
+	err = r.Client.Status().Update(context.Background(), instance)
+	if err != nil {
+		r.Log.Info(fmt.Sprintf("Cannot update baseline results #%v ", err))
+	}
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *BenchmarkReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// BuildWatcher lists benchmarks referring the GVK of a generic build
+	err := mgr.GetFieldIndexer().IndexField(context.Background(), &cpev1.Benchmark{}, BUILD_CONFIG_GVK_INDEX, func(obj client.Object) []string {
+		return GetBuildConfigGVKs(obj.(*cpev1.Benchmark))
+	})
+	if err != nil {
+		return err
+	}
+	err = mgr.GetFieldIndexer().IndexField(context.Background(), &cpev1.Benchmark{}, BASELINE_REF_INDEX, func(obj client.Object) []string {
+		return GetBaselineRefKeys(obj.(*cpev1.Benchmark))
+	})
+	if err != nil {
+		return err
+	}
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&cpev1.Benchmark{}).
+		Watches(&source.Kind{Type: &cpev1.BenchmarkBaseline{}}, handler.EnqueueRequestsFromMapFunc(r.mapBaselineToBenchmarks)).
 		Complete(r)
 }
 
+// mapBaselineToBenchmarks enqueues benchmarks referring the baseline to re-evaluate them
+func (r *BenchmarkReconciler) mapBaselineToBenchmarks(obj client.Object) []reconcile.Request {
+	benchmarkList := &cpev1.BenchmarkList{}
+	key := types.NamespacedName{Name: obj.GetName(), Namespace: obj.GetNamespace()}.String()
+	if err := r.Client.List(context.Background(), benchmarkList, client.MatchingFields{BASELINE_REF_INDEX: key}); err != nil {
+		r.Log.Info(fmt.Sprintf("Cannot list benchmarks of baseline %s: %v", key, err))
+		return []reconcile.Request{}
+	}
+	requests := []reconcile.Request{}
+	for _, benchmark := range benchmarkList.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: benchmark.Name, Namespace: benchmark.Namespace}})
+	}
+	return requests
+}
+
 func (r *BenchmarkReconciler) finalizeBenchmark(reqLogger logr.Logger, instance *cpev1.Benchmark) error {
 	ctx := context.Background()
 
@@ -172,6 +292,11 @@ func (r *BenchmarkReconciler) finalizeBenchmark(reqLogger logr.Logger, instance
 		reqLogger.Info(fmt.Sprintf("Cannot delete #%v ", err))
 	}
 
+	// release reserved nodes
+	if instance.Spec.ExclusiveNodes != nil && r.JTM.Reserver != nil {
+		r.JTM.Reserver.Release(instance)
+	}
+
 	reqLogger.Info(fmt.Sprintf("Finalized %s", instance.ObjectMeta.Name))
 	return nil
 }
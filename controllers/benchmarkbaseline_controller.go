@@ -0,0 +1,92 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+
+///////////////////////////////////////////////////////////////////////////
+//
+// benchmarkbaseline_controller.go
+//
+// - Reconcile Loop
+//   capture reference values from results of the source benchmark
+//   merge with manual values and put to status
+//   (source is captured again every ReconcileTime)
+//
+////////////////////////////////////////////////////////////////////////////
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+)
+
+// BenchmarkBaselineReconciler reconciles a BenchmarkBaseline object
+type BenchmarkBaselineReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=cpe.cogadvisor.io,resources=benchmarkbaselines,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=cpe.cogadvisor.io,resources=benchmarkbaselines/status,verbs=get;update;patch
+
+func (r *BenchmarkBaselineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.Log.Info(fmt.Sprintf("BenchmarkBaseline Request #%v ", req.NamespacedName))
+
+	instance := &cpev1.BenchmarkBaseline{}
+	err := r.Client.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		r.Log.Info(fmt.Sprintf("Cannot get #%v ", err))
+		return ctrl.Result{RequeueAfter: ReconcileTime}, nil
+	}
+
+	var captured []cpev1.BaselineValue
+	sourceBuild := ""
+	if source := instance.Spec.Source; source != nil {
+		namespace := source.Namespace
+		if namespace == "" {
+			namespace = instance.Namespace
+		}
+		benchmark := &cpev1.Benchmark{}
+		err = r.Client.Get(ctx, types.NamespacedName{Name: source.Benchmark, Namespace: namespace}, benchmark)
+		if err != nil {
+			r.Log.Info(fmt.Sprintf("Cannot get source benchmark #%v ", err))
+		} else {
+			captured, sourceBuild = GetBaselineValues(benchmark, source.Build)
+		}
+	}
+	values := MergeBaselineValues(captured, instance.Spec.Values)
+	if sourceBuild != instance.Status.SourceBuild || !reflect.DeepEqual(values, instance.Status.Values) {
+		instance.Status.SourceBuild = sourceBuild
+		instance.Status.Values = values
+		err = r.Client.Status().Update(ctx, instance)
+		if err != nil {
+			r.Log.Info(fmt.Sprintf("Cannot update #%v ", err))
+		}
+	}
+	if instance.Spec.Source != nil {
+		return ctrl.Result{RequeueAfter: ReconcileTime}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *BenchmarkBaselineReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&cpev1.BenchmarkBaseline{}).
+		Complete(r)
+}
@@ -11,6 +11,9 @@
 //   create benchmark operator from yaml files or helm chart
 //   create RBAC resource for the defined job resource for allow this controller
 //   to create the target job
+//   upgrade helm chart with version and values requested by iterating benchmark
+//   and record it to status once rolled out (see helm_iteration.go)
+//   (rollout is waited in background not to block the reconciler)
 //
 ////////////////////////////////////////////////////////////////////////////
 
@@ -21,11 +24,14 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
 	"helm.sh/helm/v3/pkg/repo"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
@@ -58,6 +64,9 @@ type BenchmarkOperatorReconciler struct {
 	DC         *discovery.DiscoveryClient
 	DYN        dynamic.Interface
 	HelmClient helmclient.Client
+
+	helmMutex      sync.Mutex
+	helmInstalling map[string]bool
 }
 
 const operatorFinalizer = "finalizers.benchmarkoperators.cpe.cogadvisor.io"
@@ -210,10 +219,28 @@ func (r *BenchmarkOperatorReconciler) Reconcile(ctx context.Context, req ctrl.Re
 		r.Client.Create(ctx, binding)
 		r.Log.Info(fmt.Sprintf("Create cluster role binding %s ", bindName))
 
+		helmIteration, iterationErr := GetRequestedHelmIteration(instance)
+		if iterationErr != nil {
+			r.Log.Info(fmt.Sprintf("Invalid helm iteration #%v ", iterationErr))
+		}
+
 		if !reflect.DeepEqual(cpev1.HelmSpec{}, instance.Spec.DeploySpec.Helm) {
-			err = r.installHelm(ctx, instance)
+			if r.isHelmInstalling(req.NamespacedName.String()) {
+				// reconcile again with the latest request once the rollout is done
+				return ctrl.Result{RequeueAfter: HELM_ITERATION_POLL_INTERVAL}, nil
+			}
+			if helmIteration != nil {
+				if !reflect.DeepEqual(instance.Status.HelmIteration, helmIteration) {
+					r.installHelmIteration(req.NamespacedName, instance, helmIteration)
+				}
+				return ctrl.Result{}, nil
+			}
+			err = r.installHelm(ctx, instance, helmIteration)
 		} else if !reflect.DeepEqual(cpev1.YAMLSpec{}, instance.Spec.DeploySpec.YAML) {
 			err = r.installYAML(instance)
+			if helmIteration != nil {
+				err = fmt.Errorf("no helm deployment to iterate")
+			}
 		} else {
 			r.Log.Info("No deployment specification")
 		}
@@ -221,11 +248,68 @@ func (r *BenchmarkOperatorReconciler) Reconcile(ctx context.Context, req ctrl.Re
 		if err != nil {
 			r.Log.Info(fmt.Sprintf("Deployment err #%v ", err))
 		}
+		r.updateHelmIterationStatus(ctx, instance, helmIteration, err)
 	}
 	return ctrl.Result{}, nil
 }
 
-func (r *BenchmarkOperatorReconciler) installHelm(ctx context.Context, instance *cpev1.BenchmarkOperator) error {
+func (r *BenchmarkOperatorReconciler) isHelmInstalling(key string) bool {
+	r.helmMutex.Lock()
+	defer r.helmMutex.Unlock()
+	return r.helmInstalling[key]
+}
+
+func (r *BenchmarkOperatorReconciler) setHelmInstalling(key string, installing bool) {
+	r.helmMutex.Lock()
+	defer r.helmMutex.Unlock()
+	if r.helmInstalling == nil {
+		r.helmInstalling = make(map[string]bool)
+	}
+	if installing {
+		r.helmInstalling[key] = true
+	} else {
+		delete(r.helmInstalling, key)
+	}
+}
+
+// installHelmIteration upgrades the chart with the helm iteration and waits for rollout in background,
+// the status is updated once it is done
+func (r *BenchmarkOperatorReconciler) installHelmIteration(namespacedName types.NamespacedName, instance *cpev1.BenchmarkOperator, helmIteration *cpev1.HelmIteration) {
+	key := namespacedName.String()
+	r.setHelmInstalling(key, true)
+	go func() {
+		ctx := context.Background()
+		err := r.installHelm(ctx, instance, helmIteration)
+		if err != nil {
+			r.Log.Info(fmt.Sprintf("Deployment err #%v ", err))
+		}
+		r.setHelmInstalling(key, false)
+		latest := &cpev1.BenchmarkOperator{}
+		if getErr := r.Client.Get(ctx, namespacedName, latest); getErr != nil {
+			r.Log.Info(fmt.Sprintf("Cannot get %s after helm iteration #%v ", key, getErr))
+			return
+		}
+		r.updateHelmIterationStatus(ctx, latest, helmIteration, err)
+	}()
+}
+
+// updateHelmIterationStatus records the installed helm iteration and its error
+func (r *BenchmarkOperatorReconciler) updateHelmIterationStatus(ctx context.Context, instance *cpev1.BenchmarkOperator, helmIteration *cpev1.HelmIteration, err error) {
+	iterationErr := ""
+	if helmIteration != nil && err != nil {
+		iterationErr = err.Error()
+	}
+	if reflect.DeepEqual(instance.Status.HelmIteration, helmIteration) && instance.Status.HelmIterationError == iterationErr {
+		return
+	}
+	instance.Status.HelmIteration = helmIteration
+	instance.Status.HelmIterationError = iterationErr
+	if err := r.Client.Status().Update(ctx, instance); err != nil {
+		r.Log.Info(fmt.Sprintf("Cannot update helm iteration #%v ", err))
+	}
+}
+
+func (r *BenchmarkOperatorReconciler) installHelm(ctx context.Context, instance *cpev1.BenchmarkOperator, helmIteration *cpev1.HelmIteration) error {
 	r.Log.Info(fmt.Sprintf("Install helm %s/%s ", instance.Spec.DeploySpec.Helm.RepoName, instance.Spec.DeploySpec.Helm.Entity))
 	chartRepo := repo.Entry{}
 	if instance.Spec.DeploySpec.Helm.Username == "" {
@@ -258,6 +342,25 @@ func (r *BenchmarkOperatorReconciler) installHelm(ctx context.Context, instance
 		chartSpec.ValuesYaml = instance.Spec.DeploySpec.Helm.ValuesYaml
 	}
 
+	if helmIteration != nil {
+		valuesYaml, err := GetHelmValuesYaml(instance.Spec.DeploySpec.Helm.ValuesYaml, helmIteration.Values)
+		if err != nil {
+			return err
+		}
+		chartSpec.ValuesYaml = valuesYaml
+		chartSpec.Version = helmIteration.Version
+		// benchmark job waits for rollout until the timeout since the request
+		timeout := GetHelmIterationTimeout(instance.Spec.DeploySpec.Helm)
+		if requestTime := GetHelmIterationRequestTime(instance); !requestTime.IsZero() {
+			timeout -= time.Since(requestTime)
+		}
+		if timeout <= 0 {
+			return fmt.Errorf("helm iteration is not installed within the timeout")
+		}
+		chartSpec.Wait = true
+		chartSpec.Timeout = timeout
+	}
+
 	err := r.HelmClient.AddOrUpdateChartRepo(chartRepo)
 	if err != nil {
 		r.Log.Info(fmt.Sprintf("Add or Update Chart Repo err #%v ", err))
@@ -0,0 +1,231 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+
+package controllers
+
+///////////////////////////////////////////////////////////////////////////
+//
+// bisection.go
+//
+// UpdateBisections
+// - start bisection for each new Regressed verdict if regression.bisect is set
+//   candidates are versions between baseline and regressed build
+//   in regression.bisect.candidates or seen tags of the image repository
+//   (image tags are bisected only if ordered by semver of the config)
+// - add the middle candidate to builds (kind Bisection) to run the regressed scenario only
+// - once the candidate has all repetitions, compare it to the baseline
+//   and narrow down to the half containing the first bad version
+// IsScenarioPlanned
+// - bisection build runs only the scenario of its bisection
+//
+////////////////////////////////////////////////////////////////////////////
+
+import (
+	"strings"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+)
+
+const (
+	BISECTION_KIND      = "Bisection"
+	BISECTION_RUNNING   = "Running"
+	BISECTION_COMPLETED = "Completed"
+)
+
+func getBuildDetail(benchmark *cpev1.Benchmark, build string) (cpev1.TrackedBuild, bool) {
+	for _, trackedBuild := range benchmark.Status.BuildDetails {
+		if trackedBuild.Name == build {
+			return trackedBuild, true
+		}
+	}
+	return cpev1.TrackedBuild{}, false
+}
+
+func isBisectionBuild(benchmark *cpev1.Benchmark, build string) bool {
+	trackedBuild, found := getBuildDetail(benchmark, build)
+	return found && trackedBuild.Kind == BISECTION_KIND
+}
+
+// hasOrderedVersions returns false if candidates would be seen image tags not sorted by semantic version
+// (registry lists tags in lexical order, e.g., v10 before v9)
+func hasOrderedVersions(benchmark *cpev1.Benchmark, bad string) bool {
+	if len(benchmark.Spec.Regression.Bisect.Candidates) > 0 {
+		return true
+	}
+	badBuild, _ := getBuildDetail(benchmark, bad)
+	if badBuild.Kind != IMAGE_REPOSITORY_KIND {
+		return true
+	}
+	for _, configSpec := range benchmark.Spec.BuildConfigs {
+		if configSpec.Name == badBuild.Config {
+			return configSpec.Semver != ""
+		}
+	}
+	return false
+}
+
+func indexOfVersion(versions []string, benchmark *cpev1.Benchmark, build string) int {
+	trackedBuild, _ := getBuildDetail(benchmark, build)
+	for index, version := range versions {
+		if version == build || (trackedBuild.Image != "" && version == trackedBuild.Image) {
+			return index
+		}
+	}
+	return -1
+}
+
+// GetBisectionCandidates returns ordered versions strictly between good and bad builds
+func GetBisectionCandidates(benchmark *cpev1.Benchmark, good string, bad string) []string {
+	var versions []string
+	if bisectSpec := benchmark.Spec.Regression.Bisect; len(bisectSpec.Candidates) > 0 {
+		versions = bisectSpec.Candidates
+	} else {
+		// seen tags of the image repository that produced the regressed build
+		badBuild, _ := getBuildDetail(benchmark, bad)
+		goodBuild, _ := getBuildDetail(benchmark, good)
+		if badBuild.Kind != IMAGE_REPOSITORY_KIND || goodBuild.Config != badBuild.Config || !hasOrderedVersions(benchmark, bad) {
+			return nil
+		}
+		seenTags, _ := getSeenImageTags(benchmark, badBuild.Config)
+		image := getImageWithoutTag(badBuild.Image)
+		for _, key := range seenTags {
+			tagImage := image + ":" + strings.Split(key, "@")[0]
+			if len(versions) == 0 || versions[len(versions)-1] != tagImage {
+				versions = append(versions, tagImage)
+			}
+		}
+	}
+	goodIndex := indexOfVersion(versions, benchmark, good)
+	badIndex := indexOfVersion(versions, benchmark, bad)
+	if goodIndex < 0 || badIndex < 0 || goodIndex >= badIndex {
+		return nil
+	}
+	return append([]string{}, versions[goodIndex+1:badIndex]...)
+}
+
+// IsScenarioPlanned returns false if the build is skipped by newBuildsOnly or a bisection candidate of another scenario
+func IsScenarioPlanned(benchmark *cpev1.Benchmark, build string, iterationLabel map[string]string) bool {
+	if IsSkippedBuild(benchmark, build) {
+		return false
+	}
+	if !isBisectionBuild(benchmark, build) {
+		return true
+	}
+	for _, bisection := range benchmark.Status.Bisections {
+		if bisection.Testing != build {
+			continue
+		}
+		matched := true
+		for key, value := range iterationLabel {
+			if bisection.IterationMap[key] != value && bisection.ConfigurationMap[key] != value {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func getCompletedResult(benchmark *cpev1.Benchmark, build string, iterationID string, configurationID string) (cpev1.BenchmarkResult, bool) {
+	_, _, _, maxRepetition := GetIteratedValues(benchmark)
+	for _, result := range benchmark.Status.Results {
+		if result.BuildID == build && result.IterationID == iterationID && result.ConfigurationID == configurationID {
+			return result, len(getPerformanceValues(result)) >= maxRepetition
+		}
+	}
+	return cpev1.BenchmarkResult{}, false
+}
+
+// nextBisectionStep tests the middle of remaining versions or completes the bisection
+func nextBisectionStep(benchmark *cpev1.Benchmark, bisection *cpev1.BisectionStatus) {
+	if len(bisection.Remaining) == 0 {
+		bisection.Testing = ""
+		bisection.FirstBad = bisection.Bad
+		bisection.Phase = BISECTION_COMPLETED
+		return
+	}
+	bisection.Testing = bisection.Remaining[len(bisection.Remaining)/2]
+	if !isBuildTracked(benchmark, bisection.Testing) {
+		benchmark.Status.TrackedBuilds = append(benchmark.Status.TrackedBuilds, bisection.Testing)
+		benchmark.Status.BuildDetails = append(benchmark.Status.BuildDetails, cpev1.TrackedBuild{
+			Name:  bisection.Testing,
+			Kind:  BISECTION_KIND,
+			Image: bisection.Testing,
+		})
+	}
+}
+
+// UpdateBisections starts bisections of new regressions and moves running bisections forward,
+// return bisections completed by this update
+func UpdateBisections(benchmark *cpev1.Benchmark, newVerdicts []cpev1.RegressionResult) []cpev1.BisectionStatus {
+	if benchmark.Spec.Regression == nil || benchmark.Spec.Regression.Bisect == nil {
+		return nil
+	}
+	var completedBisections []cpev1.BisectionStatus
+	for _, regression := range newVerdicts {
+		if regression.Verdict != VERDICT_REGRESSED || isBisectionBuild(benchmark, regression.BuildID) || isBisectionBuild(benchmark, regression.BaselineID) {
+			continue
+		}
+		if !hasOrderedVersions(benchmark, regression.BuildID) {
+			continue
+		}
+		exists := false
+		for _, bisection := range benchmark.Status.Bisections {
+			if bisection.Build == regression.BuildID && bisection.Baseline == regression.BaselineID && bisection.IterationID == regression.IterationID && bisection.ConfigurationID == regression.ConfigurationID {
+				exists = true
+				break
+			}
+		}
+		result, completed := getCompletedResult(benchmark, regression.BuildID, regression.IterationID, regression.ConfigurationID)
+		if exists || !completed {
+			continue
+		}
+		bisection := cpev1.BisectionStatus{
+			Build:            regression.BuildID,
+			Baseline:         regression.BaselineID,
+			Good:             regression.BaselineID,
+			Bad:              regression.BuildID,
+			IterationID:      regression.IterationID,
+			IterationMap:     result.IterationMap,
+			ConfigurationID:  regression.ConfigurationID,
+			ConfigurationMap: result.ConfigurationMap,
+			Remaining:        GetBisectionCandidates(benchmark, regression.BaselineID, regression.BuildID),
+			Phase:            BISECTION_RUNNING,
+		}
+		nextBisectionStep(benchmark, &bisection)
+		if bisection.Phase == BISECTION_COMPLETED {
+			// no version in between
+			completedBisections = append(completedBisections, bisection)
+		}
+		benchmark.Status.Bisections = append(benchmark.Status.Bisections, bisection)
+	}
+
+	for index := range benchmark.Status.Bisections {
+		bisection := &benchmark.Status.Bisections[index]
+		baselineResult, baselineCompleted := getCompletedResult(benchmark, bisection.Baseline, bisection.IterationID, bisection.ConfigurationID)
+		for bisection.Phase == BISECTION_RUNNING && baselineCompleted {
+			result, completed := getCompletedResult(benchmark, bisection.Testing, bisection.IterationID, bisection.ConfigurationID)
+			if !completed {
+				break
+			}
+			middle := len(bisection.Remaining) / 2
+			if CompareResult(benchmark, result, baselineResult).Verdict == VERDICT_REGRESSED {
+				bisection.Bad = bisection.Testing
+				bisection.Remaining = bisection.Remaining[:middle]
+			} else {
+				bisection.Good = bisection.Testing
+				bisection.Remaining = bisection.Remaining[middle+1:]
+			}
+			nextBisectionStep(benchmark, bisection)
+			if bisection.Phase == BISECTION_COMPLETED {
+				completedBisections = append(completedBisections, *bisection)
+			}
+		}
+	}
+	return completedBisections
+}
@@ -0,0 +1,141 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+
+package controllers
+
+///////////////////////////////////////////////////////////////////////////
+//
+// build_retention.go
+//
+// GetRetainedBuilds
+// - return tracked builds kept by buildRetention (last N and pinned builds)
+// PruneBuildStatus
+// - remove pruned builds from builds, buildDetails, and hash status
+//   (results and bestResults are kept as history)
+// GetBuildJobNames
+// - return names of planned jobs of the build
+// IsBuildCompleted
+// - check whether every job of the build has its result
+// IsNewBuild
+// - check whether the build is scheduled with newBuildsOnly (latest or bisection build)
+// IsSkippedBuild
+// - check whether the build is not scheduled by newBuildsOnly (not counted for completion)
+//
+////////////////////////////////////////////////////////////////////////////
+
+import (
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+)
+
+// GetRetainedBuilds returns tracked builds in arrival order after applying retention
+func GetRetainedBuilds(benchmark *cpev1.Benchmark) []string {
+	builds := benchmark.Status.TrackedBuilds
+	retention := benchmark.Spec.BuildRetention
+	if retention == nil || (retention.KeepLast <= 0 && len(retention.Pinned) == 0) {
+		return builds
+	}
+	pinned := make(map[string]bool)
+	for _, build := range retention.Pinned {
+		pinned[build] = true
+	}
+	// bisection builds are always kept and not counted
+	var countedBuilds []string
+	for _, build := range builds {
+		if !isBisectionBuild(benchmark, build) {
+			countedBuilds = append(countedBuilds, build)
+		}
+	}
+	keepFrom := 0
+	if retention.KeepLast > 0 && len(countedBuilds) > retention.KeepLast {
+		keepFrom = len(countedBuilds) - retention.KeepLast
+	}
+	kept := make(map[string]bool)
+	for _, build := range countedBuilds[keepFrom:] {
+		kept[build] = true
+	}
+	var retainedBuilds []string
+	for _, build := range builds {
+		if kept[build] || pinned[build] || isBisectionBuild(benchmark, build) {
+			retainedBuilds = append(retainedBuilds, build)
+		}
+	}
+	return retainedBuilds
+}
+
+// PruneBuildStatus removes builds that are not retained from the status, return true if pruned
+func PruneBuildStatus(benchmark *cpev1.Benchmark) bool {
+	retainedBuilds := GetRetainedBuilds(benchmark)
+	if len(retainedBuilds) == len(benchmark.Status.TrackedBuilds) {
+		return false
+	}
+	retained := make(map[string]bool)
+	for _, build := range retainedBuilds {
+		retained[build] = true
+	}
+	var buildDetails []cpev1.TrackedBuild
+	for _, trackedBuild := range benchmark.Status.BuildDetails {
+		if retained[trackedBuild.Name] {
+			buildDetails = append(buildDetails, trackedBuild)
+		}
+	}
+	// hash of init build is also pruned as it is no longer iterated once a build is tracked
+	var hash []cpev1.IterationHash
+	for _, hashItem := range benchmark.Status.Hash {
+		if retained[hashItem.Build] {
+			hash = append(hash, hashItem)
+		}
+	}
+	benchmark.Status.TrackedBuilds = retainedBuilds
+	benchmark.Status.BuildDetails = buildDetails
+	benchmark.Status.Hash = hash
+	return true
+}
+
+// GetBuildJobNames returns names of the iterated jobs of the build that are planned
+func GetBuildJobNames(benchmark *cpev1.Benchmark, build string) []string {
+	firstLabel, iterationLabels, _, maxRepetition := GetIteratedValues(benchmark)
+	labels := append([]map[string]string{firstLabel}, iterationLabels...)
+	var jobNames []string
+	for repetition := 0; repetition < maxRepetition; repetition++ {
+		for _, iterationLabel := range labels {
+			if !IsScenarioPlanned(benchmark, build, iterationLabel) {
+				continue
+			}
+			jobNames = append(jobNames, getJobName(benchmark, iterationLabel, build, repetition))
+		}
+	}
+	return jobNames
+}
+
+// IsBuildCompleted returns true if every iterated job of the build has its result
+func IsBuildCompleted(benchmark *cpev1.Benchmark, build string) bool {
+	for _, jobName := range GetBuildJobNames(benchmark, build) {
+		if !CheckIfJobDone(benchmark, jobName) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsNewBuild returns true if the build is the latest tracked build or a bisection build
+// (builds tracked before the latest one are kept in status but not scheduled with newBuildsOnly)
+func IsNewBuild(benchmark *cpev1.Benchmark, build string) bool {
+	var latestBuild string
+	for _, trackedBuild := range benchmark.Status.TrackedBuilds {
+		if !isBisectionBuild(benchmark, trackedBuild) {
+			latestBuild = trackedBuild
+		}
+	}
+	if latestBuild == "" {
+		return true
+	}
+	return build == latestBuild || isBisectionBuild(benchmark, build)
+}
+
+// IsSkippedBuild returns true if newBuildsOnly is set and the build is not new (see IsNewBuild)
+func IsSkippedBuild(benchmark *cpev1.Benchmark, build string) bool {
+	retention := benchmark.Spec.BuildRetention
+	return retention != nil && retention.NewBuildsOnly && !IsNewBuild(benchmark, build)
+}
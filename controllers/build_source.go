@@ -0,0 +1,367 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+
+package controllers
+
+///////////////////////////////////////////////////////////////////////////
+//
+// build_source.go
+//
+// BuildSource
+// - resource to watch for completed builds
+//   Build (builds.v1.build.openshift.io) tracked by BuildConfig
+//   PipelineRun, TaskRun (tekton.dev) tracked by pipeline, task name
+//   Workflow (argoproj.io) tracked by workflow template name
+// - IsCompleted: check whether the build is succeeded
+// - GetConfig: return kind, name, namespace to match with trackBuildConfigs
+// - GetImage: return produced image reference and digest
+// GetGenericBuildSource
+// - source defined by apiVersion, kind and status paths in trackBuildConfigs
+// MatchBuildConfig
+// - return build entry if the completed build is tracked by the config
+//
+////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+const (
+	BUILD_CONFIG_KIND     = "BuildConfig"
+	PIPELINE_RUN_KIND     = "PipelineRun"
+	TASK_RUN_KIND         = "TaskRun"
+	WORKFLOW_KIND         = "Workflow"
+	PIPELINE_RUN_RESOURCE = "pipelineruns.v1beta1.tekton.dev"
+	TASK_RUN_RESOURCE     = "taskruns.v1beta1.tekton.dev"
+	WORKFLOW_RESOURCE     = "workflows.v1alpha1.argoproj.io"
+	TEKTON_PIPELINE_LABEL = "tekton.dev/pipeline"
+	TEKTON_TASK_LABEL     = "tekton.dev/task"
+	ARGO_TEMPLATE_LABEL   = "workflows.argoproj.io/workflow-template"
+	DEFAULT_SUCCESS_VALUE = "True"
+)
+
+// result names of produced image (compared in upper case with '-' replaced by '_')
+var imageResultNames []string = []string{"IMAGE_URL", "IMAGE"}
+var digestResultNames []string = []string{"IMAGE_DIGEST", "DIGEST"}
+
+type BuildSource struct {
+	Resource    string
+	Kind        string
+	IsCompleted func(build *unstructured.Unstructured) bool
+	GetConfig   func(build *unstructured.Unstructured) (kind string, name string, namespace string)
+	GetImage    func(build *unstructured.Unstructured) (image string, digest string)
+}
+
+var BuildSources []BuildSource = []BuildSource{
+	{
+		Resource: BUILD_RESOURCE,
+		Kind:     "Build",
+		IsCompleted: func(build *unstructured.Unstructured) bool {
+			phase, _, _ := unstructured.NestedString(build.Object, "status", "phase")
+			return phase == "Complete"
+		},
+		GetConfig: func(build *unstructured.Unstructured) (string, string, string) {
+			kind, _, _ := unstructured.NestedString(build.Object, "status", "config", "kind")
+			name, _, _ := unstructured.NestedString(build.Object, "status", "config", "name")
+			namespace, _, _ := unstructured.NestedString(build.Object, "status", "config", "namespace")
+			return kind, name, namespace
+		},
+		GetImage: func(build *unstructured.Unstructured) (string, string) {
+			image, _, _ := unstructured.NestedString(build.Object, "status", "outputDockerImageReference")
+			digest, _, _ := unstructured.NestedString(build.Object, "status", "output", "to", "imageDigest")
+			return image, digest
+		},
+	},
+	{
+		Resource:    PIPELINE_RUN_RESOURCE,
+		Kind:        PIPELINE_RUN_KIND,
+		IsCompleted: isTektonSucceeded,
+		GetConfig: func(build *unstructured.Unstructured) (string, string, string) {
+			name := build.GetLabels()[TEKTON_PIPELINE_LABEL]
+			if name == "" {
+				name, _, _ = unstructured.NestedString(build.Object, "spec", "pipelineRef", "name")
+			}
+			return PIPELINE_RUN_KIND, name, build.GetNamespace()
+		},
+		GetImage: func(build *unstructured.Unstructured) (string, string) {
+			return getTektonImage(build, "pipelineResults")
+		},
+	},
+	{
+		Resource:    TASK_RUN_RESOURCE,
+		Kind:        TASK_RUN_KIND,
+		IsCompleted: isTektonSucceeded,
+		GetConfig: func(build *unstructured.Unstructured) (string, string, string) {
+			name := build.GetLabels()[TEKTON_TASK_LABEL]
+			if name == "" {
+				name, _, _ = unstructured.NestedString(build.Object, "spec", "taskRef", "name")
+			}
+			return TASK_RUN_KIND, name, build.GetNamespace()
+		},
+		GetImage: func(build *unstructured.Unstructured) (string, string) {
+			return getTektonImage(build, "taskResults")
+		},
+	},
+	{
+		Resource: WORKFLOW_RESOURCE,
+		Kind:     WORKFLOW_KIND,
+		IsCompleted: func(build *unstructured.Unstructured) bool {
+			phase, _, _ := unstructured.NestedString(build.Object, "status", "phase")
+			return phase == "Succeeded"
+		},
+		GetConfig: func(build *unstructured.Unstructured) (string, string, string) {
+			name := build.GetLabels()[ARGO_TEMPLATE_LABEL]
+			if name == "" {
+				name, _, _ = unstructured.NestedString(build.Object, "spec", "workflowTemplateRef", "name")
+			}
+			return WORKFLOW_KIND, name, build.GetNamespace()
+		},
+		GetImage: getArgoImage,
+	},
+}
+
+// GetBuildSource returns source of the watched build object
+func GetBuildSource(build *unstructured.Unstructured) *BuildSource {
+	for index, source := range BuildSources {
+		gvr, _ := schema.ParseResourceArg(source.Resource)
+		if source.Kind == build.GetKind() && gvr.Group == build.GroupVersionKind().Group {
+			return &BuildSources[index]
+		}
+	}
+	return nil
+}
+
+func isTektonSucceeded(build *unstructured.Unstructured) bool {
+	conditions, _, _ := unstructured.NestedSlice(build.Object, "status", "conditions")
+	for _, condition := range conditions {
+		conditionMap, ok := condition.(map[string]interface{})
+		if ok && conditionMap["type"] == "Succeeded" {
+			return conditionMap["status"] == "True"
+		}
+	}
+	return false
+}
+
+func getResultValue(results []interface{}, names []string) string {
+	for _, name := range names {
+		for _, result := range results {
+			resultMap, ok := result.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			resultName, _ := resultMap["name"].(string)
+			if strings.ReplaceAll(strings.ToUpper(resultName), "-", "_") != name {
+				continue
+			}
+			if value, ok := resultMap["value"].(string); ok {
+				return strings.TrimSpace(value)
+			}
+		}
+	}
+	return ""
+}
+
+// tekton v1beta1 lists results in pipelineResults/taskResults, v1 in results
+func getTektonImage(build *unstructured.Unstructured, resultKey string) (string, string) {
+	results, _, _ := unstructured.NestedSlice(build.Object, "status", resultKey)
+	v1Results, _, _ := unstructured.NestedSlice(build.Object, "status", "results")
+	results = append(results, v1Results...)
+	return getResultValue(results, imageResultNames), getResultValue(results, digestResultNames)
+}
+
+// argo lists output parameters in each node of the workflow
+func getArgoImage(build *unstructured.Unstructured) (string, string) {
+	nodes, _, _ := unstructured.NestedMap(build.Object, "status", "nodes")
+	var nodeIDs []string
+	for nodeID := range nodes {
+		nodeIDs = append(nodeIDs, nodeID)
+	}
+	sort.Strings(nodeIDs)
+	var parameters []interface{}
+	for _, nodeID := range nodeIDs {
+		node, ok := nodes[nodeID].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		nodeParameters, _, _ := unstructured.NestedSlice(node, "outputs", "parameters")
+		parameters = append(parameters, nodeParameters...)
+	}
+	return getResultValue(parameters, imageResultNames), getResultValue(parameters, digestResultNames)
+}
+
+// GetTrackedBuild returns build entry to put in benchmark status
+func GetTrackedBuild(source *BuildSource, build *unstructured.Unstructured) cpev1.TrackedBuild {
+	kind, name, _ := source.GetConfig(build)
+	image, digest := source.GetImage(build)
+	return cpev1.TrackedBuild{
+		Name:   build.GetNamespace() + "-" + build.GetName(),
+		Kind:   kind,
+		Config: name,
+		Image:  image,
+		Digest: digest,
+	}
+}
+
+// getLocationValue returns value at the dotted location ([index] and [key=value] supported for list)
+func getLocationValue(object map[string]interface{}, location string) (interface{}, bool) {
+	if location == "" {
+		return nil, false
+	}
+	var current interface{} = object
+	for _, token := range itrHandler.getToken(strings.TrimPrefix(location, ".")) {
+		currentMap, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		switch itrHandler.getKeyType(token) {
+		case VALUE:
+			if current, ok = currentMap[token]; !ok {
+				return nil, false
+			}
+		case LIST:
+			keyName, indexStr := itrHandler.splitBracket(token)
+			list, _ := currentMap[keyName].([]interface{})
+			index, err := strconv.Atoi(indexStr)
+			if err != nil || index < 0 || index >= len(list) {
+				return nil, false
+			}
+			current = list[index]
+		case MAP:
+			keyName, indexStr := itrHandler.splitBracket(token)
+			subMapIndex := strings.SplitN(indexStr, "=", 2)
+			list, _ := currentMap[keyName].([]interface{})
+			found := false
+			for _, item := range list {
+				itemMap, ok := item.(map[string]interface{})
+				if ok && fmt.Sprintf("%v", itemMap[subMapIndex[0]]) == subMapIndex[1] {
+					current = item
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, false
+			}
+		}
+	}
+	return current, true
+}
+
+func getLocationString(object map[string]interface{}, location string) string {
+	value, ok := getLocationValue(object, location)
+	if !ok || value == nil {
+		return ""
+	}
+	return strings.TrimSpace(fmt.Sprintf("%v", value))
+}
+
+// GetGenericBuildSource returns build source defined by the config
+func GetGenericBuildSource(configSpec cpev1.ConfigSpec) *BuildSource {
+	successValue := configSpec.SuccessValue
+	if successValue == "" {
+		successValue = DEFAULT_SUCCESS_VALUE
+	}
+	return &BuildSource{
+		Kind: configSpec.Kind,
+		IsCompleted: func(build *unstructured.Unstructured) bool {
+			return getLocationString(build.Object, configSpec.SuccessPath) == successValue
+		},
+		GetConfig: func(build *unstructured.Unstructured) (string, string, string) {
+			return configSpec.Kind, configSpec.Name, build.GetNamespace()
+		},
+		GetImage: func(build *unstructured.Unstructured) (string, string) {
+			return getLocationString(build.Object, configSpec.ArtifactPath), getLocationString(build.Object, configSpec.DigestPath)
+		},
+	}
+}
+
+// GetConfigGVK returns GVK of generic build source
+func GetConfigGVK(configSpec cpev1.ConfigSpec) schema.GroupVersionKind {
+	return schema.FromAPIVersionAndKind(configSpec.APIVersion, configSpec.Kind)
+}
+
+func matchFieldSelector(build *unstructured.Unstructured, fieldSelector string) bool {
+	if fieldSelector == "" {
+		return true
+	}
+	selector, err := fields.ParseSelector(fieldSelector)
+	if err != nil {
+		return false
+	}
+	for _, requirement := range selector.Requirements() {
+		value := getLocationString(build.Object, requirement.Field)
+		switch requirement.Operator {
+		case selection.NotEquals:
+			if value == requirement.Value {
+				return false
+			}
+		default:
+			if value != requirement.Value {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// MatchGenericBuildConfig checks whether the build is a resource selected by the generic config
+func MatchGenericBuildConfig(configSpec cpev1.ConfigSpec, build *unstructured.Unstructured) bool {
+	if GetConfigGVK(configSpec) != build.GroupVersionKind() {
+		return false
+	}
+	if configSpec.Namespace != "" && configSpec.Namespace != build.GetNamespace() {
+		return false
+	}
+	if configSpec.Selector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(configSpec.Selector)
+		if err != nil || !selector.Matches(labels.Set(build.GetLabels())) {
+			return false
+		}
+	}
+	return matchFieldSelector(build, configSpec.FieldSelector)
+}
+
+// MatchBuildConfig returns build entry if the completed build is tracked by the config
+func MatchBuildConfig(configSpec cpev1.ConfigSpec, build *unstructured.Unstructured) (cpev1.TrackedBuild, bool) {
+	if configSpec.APIVersion != "" {
+		source := GetGenericBuildSource(configSpec)
+		if !MatchGenericBuildConfig(configSpec, build) || !source.IsCompleted(build) {
+			return cpev1.TrackedBuild{}, false
+		}
+		return GetTrackedBuild(source, build), true
+	}
+
+	source := GetBuildSource(build)
+	if source == nil {
+		return cpev1.TrackedBuild{}, false
+	}
+	buildKind, buildName, buildNamespace := source.GetConfig(build)
+
+	configKind := configSpec.Kind
+	if configKind == "" {
+		configKind = BUILD_CONFIG_KIND
+	}
+	configNamespace := configSpec.Namespace
+	if configNamespace == "" {
+		configNamespace = "default"
+	}
+	if configKind == buildKind &&
+		configSpec.Name == buildName &&
+		configNamespace == buildNamespace {
+		return GetTrackedBuild(source, build), true
+	}
+	return cpev1.TrackedBuild{}, false
+}
@@ -7,10 +7,15 @@
 //
 // build_watcher.go
 //
-// - Watch Build (builds.v1.build.openshift.io)
+// - Watch Build (builds.v1.build.openshift.io), Tekton PipelineRun/TaskRun,
+//   and Argo Workflow if the resource is available (see build_source.go)
 // - When Build is completed,
 //   put build's namespace-name to the status list of referring benchmarks
-//   (further handled by benchmark controller)
+//   with produced image reference (further handled by benchmark controller)
+// - WatchBuildConfigs: watch generic build source of each GVK
+//   defined in trackBuildConfigs (called by benchmark controller),
+//   referring benchmarks are listed by BUILD_CONFIG_GVK_INDEX
+// - RunImagePoller: poll image repositories (see image_watcher.go)
 //
 ////////////////////////////////////////////////////////////////////////////
 
@@ -19,10 +24,12 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
 	v1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -30,70 +37,93 @@ import (
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	cpev1 "github.com/IBM/cpe-operator/api/v1"
 )
 
-const NO_BUILD_RESOURCE_ERROR = "the server could not find the requested resource"
 const BUILD_RESOURCE = "builds.v1.build.openshift.io"
 
+// field index of benchmarks by GVK of generic build configs (registered by benchmark controller)
+const BUILD_CONFIG_GVK_INDEX = "spec.trackBuildConfigs.gvk"
+
 type BuildWatcher struct {
 	client.Client
+	*kubernetes.Clientset
 	Log        logr.Logger
 	Scheme     *runtime.Scheme
 	DC         *discovery.DiscoveryClient
 	DYN        dynamic.Interface
 	BuildQueue chan *unstructured.Unstructured
 	Quit       chan struct{}
-}
 
-func (r *BuildWatcher) getInformer(DYN dynamic.Interface) (cache.SharedIndexInformer, dynamicinformer.DynamicSharedInformerFactory, error) {
+	watchedGVKs map[schema.GroupVersionKind]bool
+	lastPolled  map[string]time.Time
+	mutex       sync.Mutex
+}
 
-	gvr, _ := schema.ParseResourceArg(BUILD_RESOURCE)
-	_, err := r.DYN.Resource(*gvr).Namespace("default").List(context.TODO(), metav1.ListOptions{})
+// getInformer returns informer of the resource, nil if the resource is not served (checked by discovery)
+func (r *BuildWatcher) getInformer(factory dynamicinformer.DynamicSharedInformerFactory, resource string) (cache.SharedIndexInformer, error) {
+	gvr, _ := schema.ParseResourceArg(resource)
+	if gvr == nil {
+		return nil, fmt.Errorf("invalid resource %s", resource)
+	}
+	resourceList, err := r.DC.ServerResourcesForGroupVersion(gvr.GroupVersion().String())
 	if err != nil {
-		return nil, nil, err
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
 	}
-
-	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(DYN, 0, v1.NamespaceAll, nil)
-	informer := factory.ForResource(*gvr)
-
-	s := informer.Informer()
-	return s, factory, nil
+	for _, apiResource := range resourceList.APIResources {
+		if apiResource.Name == gvr.Resource {
+			return factory.ForResource(*gvr).Informer(), nil
+		}
+	}
+	return nil, nil
 }
 
 func (r *BuildWatcher) InitInformer() error {
 
-	s, factory, err := r.getInformer(r.DYN)
+	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(r.DYN, 0, v1.NamespaceAll, nil)
+	var err error
+	watchCount := 0
+	for index := range BuildSources {
+		source := &BuildSources[index]
+		var s cache.SharedIndexInformer
+		s, err = r.getInformer(factory, source.Resource)
 
-	if err != nil || s == nil {
-		if err.Error() == NO_BUILD_RESOURCE_ERROR {
-			r.Log.Info(fmt.Sprintf("No Build Resource"))
-			return err
+		if err != nil {
+			r.Log.Info(fmt.Sprintf("BuildListError (%s): %v", source.Resource, err))
+			continue
 		}
-		r.Log.Info(fmt.Sprintf("BuildListError: %v", err))
-		return err
+		if s == nil {
+			r.Log.Info(fmt.Sprintf("No Build Resource: %s", source.Resource))
+			continue
+		}
+
+		handlers := cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(oldInstance, instance interface{}) {
+				build := instance.(*unstructured.Unstructured)
+				oldBuild := oldInstance.(*unstructured.Unstructured)
+
+				// status change to completed --> add to process queue
+				if !source.IsCompleted(oldBuild) && source.IsCompleted(build) {
+					r.BuildQueue <- build
+				}
+			},
+		}
+
+		s.AddEventHandler(handlers)
+		watchCount += 1
 	}
 
-	handlers := cache.ResourceEventHandlerFuncs{
-		UpdateFunc: func(oldInstance, instance interface{}) {
-			build := instance.(*unstructured.Unstructured)
-			oldBuild := oldInstance.(*unstructured.Unstructured)
-			buildObject := build.Object
-			oldObject := oldBuild.Object
-			buildStatus := buildObject["status"].(map[string]interface{})
-			oldStatus := oldObject["status"].(map[string]interface{})
-
-			// status change to "Complete" --> add to process queue
-			if oldStatus["phase"] != "Complete" && buildStatus["phase"] == "Complete" {
-				r.BuildQueue <- build
-			}
-		},
+	if watchCount == 0 {
+		return err
 	}
 
-	s.AddEventHandler(handlers)
 	factory.Start(r.Quit)
 	r.Log.Info(fmt.Sprintf("Successfully Init BuildWatcher"))
 	return nil
@@ -105,58 +135,42 @@ func (r *BuildWatcher) Run() {
 
 func (r *BuildWatcher) ProcessBuildQueue() {
 	build := <-r.BuildQueue
-	buildObject := build.Object
-
-	buildStatus := buildObject["status"].(map[string]interface{})
-	buildConfig := buildStatus["config"].(map[string]interface{})
-
-	buildKind := buildConfig["kind"].(string)
-	buildName := buildConfig["name"].(string)
-	buildNamespace := buildConfig["namespace"].(string)
-
-	buildMeta := buildObject["metadata"].(map[string]interface{})
-	buildValue := buildMeta["namespace"].(string) + "-" + buildMeta["name"].(string)
 
-	_ = r.Log.WithValues("build", buildName)
+	_ = r.Log.WithValues("build", build.GetName())
 
-	r.Log.Info(fmt.Sprintf("Build #%s, %s, %s", buildKind, buildName, buildNamespace))
+	r.Log.Info(fmt.Sprintf("Build #%s, %s, %s", build.GetKind(), build.GetName(), build.GetNamespace()))
 
 	benchmarkList := &cpev1.BenchmarkList{}
 	r.Client.List(context.Background(), benchmarkList)
 
 	update_benchmarks := []cpev1.Benchmark{}
+	trackedBuilds := []cpev1.TrackedBuild{}
 
 	// find corresponding benchmarks
 	for _, benchmark := range benchmarkList.Items {
 		configSpecs := benchmark.Spec.BuildConfigs
 		for _, configSpec := range configSpecs {
-			configKind := configSpec.Kind
+			r.Log.Info(fmt.Sprintf("Track #%s, %s, %s", configSpec.Kind, configSpec.Name, configSpec.Namespace))
 
-			if configKind == "" {
-				configKind = "BuildConfig"
-			}
-
-			configNamespace := configSpec.Namespace
-			if configNamespace == "" {
-				configNamespace = "default"
-			}
-
-			r.Log.Info(fmt.Sprintf("Track #%s, %s, %s", configKind, configSpec.Name, configNamespace))
-
-			if configKind == buildKind &&
-				configSpec.Name == buildName &&
-				configNamespace == buildNamespace {
+			if trackedBuild, matched := MatchBuildConfig(configSpec, build); matched {
 				update_benchmarks = append(update_benchmarks, benchmark)
+				trackedBuilds = append(trackedBuilds, trackedBuild)
+				break
 			}
 		}
 	}
 
 	// update build list of the corresponding benchmarks
 	// this update will be further watched by benchmark_controller
-	for _, update_benchmark := range update_benchmarks {
+	for index, update_benchmark := range update_benchmarks {
+		trackedBuild := trackedBuilds[index]
+		if isBuildTracked(&update_benchmark, trackedBuild.Name) {
+			continue
+		}
 		builds := update_benchmark.Status.TrackedBuilds
-		builds = append(builds, buildValue)
+		builds = append(builds, trackedBuild.Name)
 		update_benchmark.Status.TrackedBuilds = builds
+		update_benchmark.Status.BuildDetails = append(update_benchmark.Status.BuildDetails, trackedBuild)
 
 		err := r.Client.Status().Update(context.Background(), &update_benchmark)
 
@@ -165,3 +179,84 @@ func (r *BuildWatcher) ProcessBuildQueue() {
 		}
 	}
 }
+
+func isBuildTracked(benchmark *cpev1.Benchmark, buildValue string) bool {
+	for _, build := range benchmark.Status.TrackedBuilds {
+		if build == buildValue {
+			return true
+		}
+	}
+	return false
+}
+
+// GetBuildConfigGVKs returns GVKs of generic build configs of the benchmark (values of BUILD_CONFIG_GVK_INDEX)
+func GetBuildConfigGVKs(benchmark *cpev1.Benchmark) []string {
+	var gvks []string
+	for _, configSpec := range benchmark.Spec.BuildConfigs {
+		if configSpec.APIVersion == "" {
+			continue
+		}
+		gvks = append(gvks, GetConfigGVK(configSpec).String())
+	}
+	return gvks
+}
+
+// isGenericBuildCompleted checks whether the build is newly completed for any generic config of the GVK
+func (r *BuildWatcher) isGenericBuildCompleted(gvk schema.GroupVersionKind, oldBuild *unstructured.Unstructured, build *unstructured.Unstructured) bool {
+	benchmarkList := &cpev1.BenchmarkList{}
+	if err := r.Client.List(context.Background(), benchmarkList, client.MatchingFields{BUILD_CONFIG_GVK_INDEX: gvk.String()}); err != nil {
+		r.Log.Info(fmt.Sprintf("Cannot list benchmarks of %v: %v", gvk, err))
+		return false
+	}
+	for _, benchmark := range benchmarkList.Items {
+		for _, configSpec := range benchmark.Spec.BuildConfigs {
+			if configSpec.APIVersion == "" || GetConfigGVK(configSpec) != gvk || !MatchGenericBuildConfig(configSpec, build) {
+				continue
+			}
+			source := GetGenericBuildSource(configSpec)
+			if !source.IsCompleted(oldBuild) && source.IsCompleted(build) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WatchBuildConfigs starts an informer for each new GVK of generic build sources in the benchmark
+func (r *BuildWatcher) WatchBuildConfigs(benchmark *cpev1.Benchmark) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.watchedGVKs == nil {
+		r.watchedGVKs = make(map[schema.GroupVersionKind]bool)
+	}
+	for _, configSpec := range benchmark.Spec.BuildConfigs {
+		if configSpec.APIVersion == "" {
+			continue
+		}
+		gvk := GetConfigGVK(configSpec)
+		if r.watchedGVKs[gvk] {
+			continue
+		}
+		if !isGVKAvailable(r.DC, gvk) {
+			r.Log.Info(fmt.Sprintf("No Build Resource: %v", gvk))
+			continue
+		}
+
+		s, factory := GetInformerFromGVK(r.DC, r.DYN, gvk)
+		handlers := cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(oldInstance, instance interface{}) {
+				build := instance.(*unstructured.Unstructured)
+				oldBuild := oldInstance.(*unstructured.Unstructured)
+
+				// status change to completed --> add to process queue
+				if r.isGenericBuildCompleted(gvk, oldBuild, build) {
+					r.BuildQueue <- build
+				}
+			},
+		}
+		s.AddEventHandler(handlers)
+		factory.Start(r.Quit)
+		r.watchedGVKs[gvk] = true
+		r.Log.Info(fmt.Sprintf("Watch Build Resource: %v", gvk))
+	}
+}
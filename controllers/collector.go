@@ -9,8 +9,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 
 	cpev1 "github.com/IBM/cpe-operator/api/v1"
@@ -27,6 +29,21 @@ var (
 	cpe_result_metric_lables = []string{
 		"benchmark", "build", "config", "scenario", "job", "pod", "key", "attrbs",
 	}
+	// optional labels from node fingerprint, enabled by CPE_RESULT_NODE_LABELS=true
+	cpe_result_node_labels = []string{
+		"node", "instance_type", "zone", "cpu_model", "kernel", "kubelet", "container_runtime",
+	}
+	withNodeLabels bool = os.Getenv("CPE_RESULT_NODE_LABELS") == "true"
+
+	cpe_regression_metric_name   = "cpe_regression"
+	cpe_regression_metric_labels = []string{
+		"benchmark", "build", "baseline", "config", "scenario", "key", "verdict",
+	}
+
+	cpe_assertion_metric_name   = "cpe_assertion_failure"
+	cpe_assertion_metric_labels = []string{
+		"benchmark", "build", "config", "scenario", "job", "assertion",
+	}
 )
 
 type ValueWithLabels struct {
@@ -40,8 +57,11 @@ type ValuesWithLabels struct {
 
 type ResultCollector struct {
 	client.Client
-	Log           logr.Logger
-	resultVectors *prometheus.GaugeVec
+	Log               logr.Logger
+	resultVectors     *prometheus.GaugeVec
+	regressionVectors *prometheus.GaugeVec
+	assertionVectors  *prometheus.GaugeVec
+	withNodeLabels    bool
 }
 
 func (c *ResultCollector) relabelKey(key string) string {
@@ -73,13 +93,26 @@ func (c *ResultCollector) labelMapToStr(labelMap map[string]string) string {
 }
 
 func NewCollector(client client.Client, logger logr.Logger) {
+	labelNames := cpe_result_metric_lables
+	if withNodeLabels {
+		labelNames = append(labelNames, cpe_result_node_labels...)
+	}
 	collector := &ResultCollector{
 		Client: client,
 		Log:    logger,
 		resultVectors: prometheus.NewGaugeVec(prometheus.GaugeOpts{
 			Name: cpe_result_metric_name,
 			Help: "CPE Results with parsed key and index if applicable",
-		}, cpe_result_metric_lables),
+		}, labelNames),
+		regressionVectors: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: cpe_regression_metric_name,
+			Help: "CPE relative change of mean performance value from baseline build with verdict",
+		}, cpe_regression_metric_labels),
+		assertionVectors: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: cpe_assertion_metric_name,
+			Help: "CPE failed assertion of result (1 if failed)",
+		}, cpe_assertion_metric_labels),
+		withNodeLabels: withNodeLabels,
 	}
 	// register prometheus
 	metrics.Registry.MustRegister(collector)
@@ -88,6 +121,8 @@ func NewCollector(client client.Client, logger logr.Logger) {
 // Describe implements the prometheus.Collector interface
 func (c *ResultCollector) Describe(ch chan<- *prometheus.Desc) {
 	c.resultVectors.Describe(ch)
+	c.regressionVectors.Describe(ch)
+	c.assertionVectors.Describe(ch)
 }
 
 func (c *ResultCollector) getStat(vals []float64) (minVal, maxVal, avgVal float64) {
@@ -110,28 +145,41 @@ func (c *ResultCollector) getStat(vals []float64) (minVal, maxVal, avgVal float6
 	return
 }
 
-func (c *ResultCollector) getCommonLabels(benchmarkName, build, configID, scenarioID, jobName, podName string) prometheus.Labels {
+func (c *ResultCollector) getCommonLabels(benchmarkName, build, configID, scenarioID string, item cpev1.BenchmarkResultItem) prometheus.Labels {
 	labels := make(prometheus.Labels)
 	labels["benchmark"] = benchmarkName
 	labels["build"] = build
 	labels["config"] = configID
 	labels["scenario"] = scenarioID
-	labels["job"] = jobName
-	labels["pod"] = podName
+	labels["job"] = item.JobName
+	labels["pod"] = item.PodName
+	if c.withNodeLabels {
+		labels["node"] = item.NodeName
+		nodeInfo := item.NodeInfo
+		if nodeInfo == nil {
+			nodeInfo = &cpev1.NodeFingerprint{}
+		}
+		labels["instance_type"] = nodeInfo.InstanceType
+		labels["zone"] = nodeInfo.Zone
+		labels["cpu_model"] = nodeInfo.CPUModel
+		labels["kernel"] = nodeInfo.KernelVersion
+		labels["kubelet"] = nodeInfo.KubeletVersion
+		labels["container_runtime"] = nodeInfo.ContainerRuntimeVersion
+	}
 	return labels
 }
 
-func (c *ResultCollector) updateGaugeVec(benchmarkName, build, configID, scenarioID, jobName, podName string, values map[string]interface{}) {
+func (c *ResultCollector) updateGaugeVec(benchmarkName, build, configID, scenarioID string, item cpev1.BenchmarkResultItem, values map[string]interface{}) {
 	for key, vals := range values {
 		relabeledKey := c.relabelKey(key)
 		if reflect.TypeOf(vals).Kind() == reflect.Float64 {
-			labels := c.getCommonLabels(benchmarkName, build, configID, scenarioID, jobName, podName)
+			labels := c.getCommonLabels(benchmarkName, build, configID, scenarioID, item)
 			labels["key"] = relabeledKey
 			labels["attrbs"] = ""
 			c.resultVectors.With(labels).Set(vals.(float64))
 		} else if valueWithLabelsArr, ok := vals.([]ValueWithLabels); ok {
 			for _, valueWithLabels := range valueWithLabelsArr {
-				labels := c.getCommonLabels(benchmarkName, build, configID, scenarioID, jobName, podName)
+				labels := c.getCommonLabels(benchmarkName, build, configID, scenarioID, item)
 				labels["key"] = relabeledKey
 				labels["attrbs"] = c.labelMapToStr(valueWithLabels.Labels)
 				c.resultVectors.With(labels).Set(valueWithLabels.Value)
@@ -139,9 +187,9 @@ func (c *ResultCollector) updateGaugeVec(benchmarkName, build, configID, scenari
 		} else if valuesWithLabelsArr, ok := vals.([]ValuesWithLabels); ok {
 			for _, valuesWithLabels := range valuesWithLabelsArr {
 				minVal, maxVal, avgVal := c.getStat(valuesWithLabels.Values)
-				minLabels := c.getCommonLabels(benchmarkName, build, configID, scenarioID, jobName, podName)
-				maxLables := c.getCommonLabels(benchmarkName, build, configID, scenarioID, jobName, podName)
-				avgLables := c.getCommonLabels(benchmarkName, build, configID, scenarioID, jobName, podName)
+				minLabels := c.getCommonLabels(benchmarkName, build, configID, scenarioID, item)
+				maxLables := c.getCommonLabels(benchmarkName, build, configID, scenarioID, item)
+				avgLables := c.getCommonLabels(benchmarkName, build, configID, scenarioID, item)
 				minLabels["key"] = relabeledKey
 				maxLables["key"] = relabeledKey
 				avgLables["key"] = relabeledKey
@@ -155,7 +203,7 @@ func (c *ResultCollector) updateGaugeVec(benchmarkName, build, configID, scenari
 		} else if reflect.TypeOf(vals).Kind() == reflect.Slice {
 			for index, val := range vals.([]interface{}) {
 				if reflect.TypeOf(val).Kind() == reflect.Float64 {
-					labels := c.getCommonLabels(benchmarkName, build, configID, scenarioID, jobName, podName)
+					labels := c.getCommonLabels(benchmarkName, build, configID, scenarioID, item)
 					labels["key"] = relabeledKey
 					labels["attrbs"] = fmt.Sprintf("%d", index)
 					c.resultVectors.With(labels).Set(val.(float64))
@@ -175,6 +223,8 @@ func (c *ResultCollector) Collect(ch chan<- prometheus.Metric) {
 		Namespace: metav1.NamespaceAll,
 	})
 	c.resultVectors.Reset()
+	c.regressionVectors.Reset()
+	c.assertionVectors.Reset()
 	for _, benchmark := range benchmarks.Items {
 		benchmarkName := benchmark.Name
 		for _, result := range benchmark.Status.Results {
@@ -182,17 +232,48 @@ func (c *ResultCollector) Collect(ch chan<- prometheus.Metric) {
 			configID := result.ConfigurationID
 			scenarioID := result.IterationID
 			for _, item := range result.Items {
+				for _, assertion := range item.FailedAssertions {
+					c.assertionVectors.With(prometheus.Labels{
+						"benchmark": benchmarkName,
+						"build":     build,
+						"config":    configID,
+						"scenario":  scenarioID,
+						"job":       item.JobName,
+						"assertion": assertion,
+					}).Set(1)
+				}
 				values := make(map[string]interface{})
 				err := json.Unmarshal([]byte(item.Result), &values)
 				if err != nil {
 					c.Log.Info(fmt.Sprintf("Cannot parse values of %s from respone: %s: %v", benchmarkName, item.Result, err))
 					continue
 				}
-				jobName := item.JobName
-				podName := item.PodName
-				c.updateGaugeVec(benchmarkName, build, configID, scenarioID, jobName, podName, values)
+				// derived metrics are exported as parsed keys
+				for name, derivedValue := range item.DerivedValues {
+					if value, err := strconv.ParseFloat(derivedValue, 64); err == nil {
+						values[name] = value
+					}
+				}
+				c.updateGaugeVec(benchmarkName, build, configID, scenarioID, item, values)
+			}
+		}
+		for _, regression := range benchmark.Status.Regressions {
+			change, err := strconv.ParseFloat(regression.Change, 64)
+			if err != nil {
+				continue
 			}
+			c.regressionVectors.With(prometheus.Labels{
+				"benchmark": benchmarkName,
+				"build":     regression.BuildID,
+				"baseline":  regression.BaselineID,
+				"config":    regression.ConfigurationID,
+				"scenario":  regression.IterationID,
+				"key":       c.relabelKey(regression.PerformanceKey),
+				"verdict":   regression.Verdict,
+			}).Set(change)
 		}
 	}
 	c.resultVectors.Collect(ch)
+	c.regressionVectors.Collect(ch)
+	c.assertionVectors.Collect(ch)
 }
@@ -6,8 +6,8 @@
 package controllers
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"hash/fnv"
 	"io/ioutil"
@@ -15,7 +15,6 @@ import (
 	"regexp"
 	"sort"
 	"strings"
-	"text/template"
 
 	cpev1 "github.com/IBM/cpe-operator/api/v1"
 	"github.com/go-logr/logr"
@@ -78,6 +77,12 @@ func getResourceInterface(dc *discovery.DiscoveryClient, dyn dynamic.Interface,
 	return dr
 }
 
+func isGVKAvailable(dc *discovery.DiscoveryClient, gvk schema.GroupVersionKind) bool {
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(dc))
+	_, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	return err == nil
+}
+
 func getInfoFromURL(dc *discovery.DiscoveryClient, dyn dynamic.Interface, yamlURL string) (*unstructured.Unstructured, dynamic.ResourceInterface, error) {
 	// 1. get yaml body from url
 	resp, err := http.Get(yamlURL)
@@ -168,8 +173,12 @@ func getSubfixFromIterationLabel(iterationLabel map[string]string) string {
 // 	return fmt.Sprintf("%s%s-bc-%s-rp-%d", benchmark.ObjectMeta.Name, getSubfixFromIterationLabel(iterationLabel), build, repetition)
 // }
 
-func getJobHash(iterationLabel map[string]string, build string, repetition int) string {
+// getJobHash returns hash of the job, revision of benchmarkSpecFrom is included if set
+func getJobHash(benchmark *cpev1.Benchmark, iterationLabel map[string]string, build string, repetition int) string {
 	fullKey := fmt.Sprintf("%s-bc-%s-rp-%d", getSubfixFromIterationLabel(iterationLabel), build, repetition)
+	if revision := benchmark.Status.SpecRevision; revision != "" {
+		fullKey += "-rv-" + revision
+	}
 	h := fnv.New32a()
 	h.Write([]byte(fullKey))
 	return fmt.Sprintf("%d", h.Sum32())
@@ -180,7 +189,7 @@ func getJobNameFromHash(benchmarkName string, jobHash string) string {
 }
 
 func getJobName(benchmark *cpev1.Benchmark, iterationLabel map[string]string, build string, repetition int) string {
-	jobHash := getJobHash(iterationLabel, build, repetition)
+	jobHash := getJobHash(benchmark, iterationLabel, build, repetition)
 	return getJobNameFromHash(benchmark.GetName(), jobHash)
 }
 
@@ -274,9 +283,9 @@ func GetSimpleJobGVK(benchmarkOperator *cpev1.BenchmarkOperator) schema.GroupVer
 
 func GetIteratedValues(benchmark *cpev1.Benchmark) (firstLabel map[string]string, iterationLabels []map[string]string, builds []string, maxRepetition int) {
 	// iterations
-	iterations := GetCombinedIterations(benchmark)
-	if len(iterations) > 0 {
-		iterationLabels = itrHandler.GetAllCombination(iterations)
+	// combinations with derived values
+	iterationLabels, _ = GetIterationLabels(GetCombinedIterations(benchmark))
+	if len(iterationLabels) > 0 {
 		firstLabel, iterationLabels = iterationLabels[0], iterationLabels[1:]
 	} else {
 		firstLabel = make(map[string]string)
@@ -284,9 +293,9 @@ func GetIteratedValues(benchmark *cpev1.Benchmark) (firstLabel map[string]string
 	}
 
 	// builds
-	buildSize := len(benchmark.Status.TrackedBuilds)
-	if buildSize > 0 {
-		builds = benchmark.Status.TrackedBuilds
+	retainedBuilds := GetRetainedBuilds(benchmark)
+	if len(retainedBuilds) > 0 {
+		builds = retainedBuilds
 	} else {
 		builds = []string{INIT_BUILD_NAME}
 	}
@@ -336,6 +345,27 @@ func GetExpandLabel(iterationLabel map[string]string) map[string]interface{} {
 	return expandLabel
 }
 
+// GetBuildTemplateValue returns build metadata to be referred as {{ .build.* }} in benchmarkSpec
+func GetBuildTemplateValue(benchmark *cpev1.Benchmark, build string) map[string]interface{} {
+	buildValue := map[string]interface{}{
+		"name":   build,
+		"kind":   "",
+		"config": "",
+		"image":  "",
+		"digest": "",
+	}
+	for _, trackedBuild := range benchmark.Status.BuildDetails {
+		if trackedBuild.Name == build {
+			buildValue["kind"] = trackedBuild.Kind
+			buildValue["config"] = trackedBuild.Config
+			buildValue["image"] = trackedBuild.Image
+			buildValue["digest"] = trackedBuild.Digest
+			break
+		}
+	}
+	return buildValue
+}
+
 func GetBenchmarkWithIteration(client client.Client, ns string, benchmark *cpev1.Benchmark, benchmarkObj map[string]interface{}, iterationLabel map[string]string, build string, repetition int) (*unstructured.Unstructured, error) {
 
 	labels := map[string]interface{}{BENCHMARK_LABEL: benchmark.ObjectMeta.Name}
@@ -347,7 +377,7 @@ func GetBenchmarkWithIteration(client client.Client, ns string, benchmark *cpev1
 	labels[REPETITION_KEY] = repInString
 
 	// get hash
-	jobHash := getJobHash(iterationLabel, build, repetition)
+	jobHash := getJobHash(benchmark, iterationLabel, build, repetition)
 	jobName := getJobNameFromHash(benchmark.GetName(), jobHash)
 	patchBenchmarkStatus(client, benchmark, jobHash, iterationLabel, build, repInString)
 
@@ -356,17 +386,11 @@ func GetBenchmarkWithIteration(client client.Client, ns string, benchmark *cpev1
 	benchmarkObj["metadata"] = map[string]interface{}{"name": jobName, "namespace": ns, "labels": labels}
 
 	// generate job spec
-	tmpl, err := template.New("").Parse(benchmark.Spec.Spec)
-	if err != nil {
-		return nil, err
-	}
-	var buffer bytes.Buffer
-	expandLabel := GetExpandLabel(iterationLabel)
-	err = tmpl.Execute(&buffer, expandLabel)
+	templateContext := GetTemplateContext(benchmark, iterationLabel, build, repetition, jobName)
+	executedSpec, err := ExecuteBenchmarkTemplate(benchmark.Spec.Spec, templateContext)
 	if err != nil {
 		return nil, err
 	}
-	executedSpec := buffer.String()
 
 	var decUnstructured = yaml.NewDecodingSerializer(unstructured.UnstructuredJSONScheme)
 	obj := &unstructured.Unstructured{}
@@ -374,6 +398,19 @@ func GetBenchmarkWithIteration(client client.Client, ns string, benchmark *cpev1
 
 	specObject := obj.Object
 
+	// set values at item locations
+	items := append([]cpev1.IterationItem{}, benchmark.Spec.IterationSpec.Iteration...)
+	for _, item := range append(items, benchmark.Spec.IterationSpec.Configuration...) {
+		value, ok := iterationLabel[item.Name]
+		if !ok || item.Location == "" {
+			continue
+		}
+		specObject, err = itrHandler.SetValue(specObject, item, value)
+		if err != nil {
+			return nil, fmt.Errorf("cannot set %s: %v", item.Name, err)
+		}
+	}
+
 	if _, ok := iterationLabel[NODESELECT_ITR_NAME]; ok {
 		if iterationLabel[NODESELECT_ITR_NAME] != NODESELECT_ITR_DEFAULT {
 			nodeSelectionItr := NodeSelectionSpecToIteration(benchmark.Spec.IterationSpec.NodeSelection)
@@ -390,6 +427,22 @@ func GetBenchmarkWithIteration(client client.Client, ns string, benchmark *cpev1
 		}
 	}
 
+	// tolerate reserved nodes
+	if benchmark.Spec.ExclusiveNodes != nil {
+		specObject = AddTolerations(specObject, benchmark)
+	}
+
+	// pin repetition to node
+	if benchmark.Spec.Placement != nil && benchmark.Spec.Placement.Policy == PLACEMENT_SPREAD {
+		nodes, err := listPlacementNodes(client, benchmark)
+		if err != nil {
+			return nil, err
+		}
+		if len(nodes) > 0 {
+			specObject = AddNodeAffinity(specObject, benchmark, GetSpreadNode(nodes, repetition))
+		}
+	}
+
 	benchmarkObj["spec"] = specObject
 	extBenchmark := &unstructured.Unstructured{
 		Object: benchmarkObj,
@@ -397,7 +450,11 @@ func GetBenchmarkWithIteration(client client.Client, ns string, benchmark *cpev1
 	return extBenchmark, nil
 }
 
+// CheckIfJobDone returns true if the job has its result or its hook failed
 func CheckIfJobDone(benchmark *cpev1.Benchmark, jobName string) bool {
+	if IsJobFailed(benchmark, jobName) {
+		return true
+	}
 	for _, result := range benchmark.Status.Results {
 		for _, item := range result.Items {
 			if item.JobName == jobName {
@@ -408,7 +465,42 @@ func CheckIfJobDone(benchmark *cpev1.Benchmark, jobName string) bool {
 	return false
 }
 
-func CreateIfNotExists(dr dynamic.ResourceInterface, benchmark *cpev1.Benchmark, unstructuredInstance *unstructured.Unstructured, adaptor OperatorAdaptor, tunedHandler *TunedHandler, nodeTunedOptimizer *BaysesOptimizer) (error, bool) {
+// ErrJobNotReady is returned by CreateIfNotExists while the job is waiting for its preparation
+var ErrJobNotReady = errors.New("job is waiting for its preparation")
+
+// IsJobNotReady returns true if the job is not created yet because its preparation is not ready
+func IsJobNotReady(err error) bool {
+	return errors.Is(err, ErrJobNotReady)
+}
+
+// PrepareJob deploys what the job depends on without waiting, return true if all of them are ready
+// (operator, system under test of the configuration and resources of the scenario must be ready before the benchmark job)
+func PrepareJob(dc *discovery.DiscoveryClient, dyn dynamic.Interface, benchmark *cpev1.Benchmark, jobName string) (bool, error) {
+	// benchmark jobs are not created if benchmarkPreRun failed
+	if finished, err := CheckHook(dyn, benchmark, HOOK_BENCHMARK_PRE_RUN, ""); err != nil {
+		var hookErr *HookError
+		if errors.As(err, &hookErr) {
+			return false, &HookError{Hook: HOOK_BENCHMARK_PRE_RUN, JobName: jobName, Err: hookErr.Err}
+		}
+		return false, err
+	} else if !finished {
+		return false, nil
+	}
+	if ready, err := DeployHelmIteration(dyn, benchmark, jobName); err != nil || !ready {
+		return false, err
+	}
+	if ready, err := DeploySystemUnderTest(dc, dyn, benchmark, jobName); err != nil || !ready {
+		return false, err
+	}
+	if ready, err := DeployScenarioResources(dc, dyn, benchmark, jobName); err != nil || !ready {
+		return false, err
+	}
+	return CheckHook(dyn, benchmark, HOOK_PRE_RUN, jobName)
+}
+
+// CreateIfNotExists creates the job once its preparation is ready
+// (ErrJobNotReady until then or while the preparation gets an API error other than HookError)
+func CreateIfNotExists(dc *discovery.DiscoveryClient, dyn dynamic.Interface, dr dynamic.ResourceInterface, benchmark *cpev1.Benchmark, unstructuredInstance *unstructured.Unstructured, adaptor OperatorAdaptor, tunedHandler *TunedHandler, nodeTunedOptimizer *BaysesOptimizer) (error, bool) {
 	if CheckIfJobDone(benchmark, unstructuredInstance.GetName()) {
 		return nil, false
 	}
@@ -424,31 +516,48 @@ func CreateIfNotExists(dr dynamic.ResourceInterface, benchmark *cpev1.Benchmark,
 		// found
 		completed = adaptor.CheckComplete(existJob.Object)
 	}
+	if completed && nodeSelectionSpec != nil {
+		tunedValue = getTunedValue(nodeSelectionSpec, unstructuredInstance)
+		autoTuned = tunedValue == RESERVED_AUTOTUNED_PROFILE_NAME && tunedHandler != nil
+	}
 
-	if completed {
-		if nodeSelectionSpec != nil {
-			tunedValue = getTunedValue(nodeSelectionSpec, unstructuredInstance)
-			if tunedValue == RESERVED_AUTOTUNED_PROFILE_NAME && tunedHandler != nil {
-				sampledProfileMaps, ok := <-nodeTunedOptimizer.SampleQueue
-				if !ok {
-					if nodeTunedOptimizer.FinalizedApplied {
-						return fmt.Errorf("no more sample"), true
-					} else {
-						sampledProfileMaps = nodeTunedOptimizer.FinalizedTunedProfile
-						nodeTunedOptimizer.SetFinalizedApplied()
-					}
-				}
-				tunedHandler.DeleteAutoTunedProfile()
-				err = tunedHandler.CreateAutoTunedProfile(sampledProfileMaps)
-				if err != nil {
-					return err, true
-				}
+	if err != nil || autoTuned { // create if not exists or autotuned
+		ready, prepareErr := PrepareJob(dc, dyn, benchmark, unstructuredInstance.GetName())
+		var hookErr *HookError
+		if prepareErr != nil && !errors.As(prepareErr, &hookErr) {
+			// transient API error, the preparation is retried
+			return fmt.Errorf("%w: %v", ErrJobNotReady, prepareErr), false
+		}
+		if prepareErr != nil {
+			// delete half-created resources of the failed scenario
+			if hashItem, found := getHashItem(benchmark, unstructuredInstance.GetName()); found {
+				DeleteScenarioResources(dc, dyn, benchmark, hashItem)
+			}
+			return prepareErr, false
+		}
+		if !ready {
+			return ErrJobNotReady, false
+		}
+	}
 
-				// deleted previous tuned job
-				dr.Delete(context.TODO(), unstructuredInstance.GetName(), metav1.DeleteOptions{})
-				autoTuned = true
+	if autoTuned {
+		sampledProfileMaps, ok := <-nodeTunedOptimizer.SampleQueue
+		if !ok {
+			if nodeTunedOptimizer.FinalizedApplied {
+				return fmt.Errorf("no more sample"), true
+			} else {
+				sampledProfileMaps = nodeTunedOptimizer.FinalizedTunedProfile
+				nodeTunedOptimizer.SetFinalizedApplied()
 			}
 		}
+		tunedHandler.DeleteAutoTunedProfile()
+		err = tunedHandler.CreateAutoTunedProfile(sampledProfileMaps)
+		if err != nil {
+			return err, true
+		}
+
+		// deleted previous tuned job
+		dr.Delete(context.TODO(), unstructuredInstance.GetName(), metav1.DeleteOptions{})
 	}
 
 	if err != nil || autoTuned { // create if not exists or autotuned deleted
@@ -484,12 +593,12 @@ func JobListChanged(benchmark *cpev1.Benchmark) bool {
 		}
 		for _, build := range builds {
 			noHash = true
-			jobHash := getJobHash(firstLabel, build, repetition)
+			jobHash := getJobHash(benchmark, firstLabel, build, repetition)
 			if !checkHashExist(benchmark, jobHash) {
 				return true
 			}
 			for _, iterationLabel := range iterationLabels {
-				jobHash = getJobHash(iterationLabel, build, repetition)
+				jobHash = getJobHash(benchmark, iterationLabel, build, repetition)
 				if !checkHashExist(benchmark, jobHash) {
 					return true
 				}
@@ -515,12 +624,78 @@ func NewBenchmarkObject(benchmarkOperator *cpev1.BenchmarkOperator) map[string]i
 
 func CreateFromOperator(jtm *JobTrackManager, client client.Client, dc *discovery.DiscoveryClient, dyn dynamic.Interface, benchmark *cpev1.Benchmark, benchmarkOperator *cpev1.BenchmarkOperator, reqLogger logr.Logger, adaptor OperatorAdaptor, tunedHandler *TunedHandler) error {
 	gvk := GetSimpleJobGVK(benchmarkOperator)
+	exists := jtm.IsExist(gvk, benchmark.GetName())
+
+	// remove builds out of retention before planning jobs
+	if PruneBuildStatus(benchmark) {
+		reqLogger.Info(fmt.Sprintf("Prune builds of %s: %v", benchmark.GetName(), benchmark.Status.TrackedBuilds))
+		err := client.Status().Update(context.Background(), benchmark)
+		if err != nil {
+			reqLogger.Info(fmt.Sprintf("Cannot update pruned builds #%v ", err))
+		}
+		jtm.PruneWaitingJob(gvk, benchmark.GetName(), benchmark.Status.TrackedBuilds)
+	}
+
+	if err := ValidateExecutionOrder(benchmark); err != nil {
+		reqLogger.Info(fmt.Sprintf("Invalid order of %s: %v", benchmark.GetName(), err))
+		if jtm.Recorder != nil {
+			jtm.Recorder.Event(benchmark, v1.EventTypeWarning, "InvalidOrder", err.Error())
+		}
+		return err
+	}
+	if err := ValidatePlacement(benchmark); err != nil {
+		reqLogger.Info(fmt.Sprintf("Invalid placement of %s: %v", benchmark.GetName(), err))
+		if jtm.Recorder != nil {
+			jtm.Recorder.Event(benchmark, v1.EventTypeWarning, "InvalidPlacement", err.Error())
+		}
+		return err
+	}
+	if err := ValidateExclusiveNodes(benchmark); err != nil {
+		reqLogger.Info(fmt.Sprintf("Invalid exclusiveNodes of %s: %v", benchmark.GetName(), err))
+		if jtm.Recorder != nil {
+			jtm.Recorder.Event(benchmark, v1.EventTypeWarning, "InvalidExclusiveNodes", err.Error())
+		}
+		return err
+	}
+	if IsGroupedByConfiguration(benchmark) && getOrderStrategy(benchmark) == ORDER_SHUFFLE {
+		reqLogger.Info(fmt.Sprintf("Jobs of %s are shuffled within each configuration", benchmark.GetName()))
+	}
 
-	if jtm.IsExist(gvk, benchmark.GetName()) {
+	firstLabel, iterationLabels, builds, maxRepetition := GetIteratedValues(benchmark)
+	plannedBuilds := jtm.GetPlannedBuilds(gvk, benchmark.GetName())
+	planned := make(map[string]bool)
+	for _, build := range plannedBuilds {
+		planned[build] = true
+	}
+	newBuilds := make(map[string]bool)
+	for _, build := range builds {
+		if planned[build] {
+			continue
+		}
+		plannedBuilds = append(plannedBuilds, build)
+		if IsSkippedBuild(benchmark, build) {
+			reqLogger.Info(fmt.Sprintf("Skip previously tracked build %s of %s", build, benchmark.GetName()))
+			continue
+		}
+		newBuilds[build] = true
+	}
+
+	if exists && len(newBuilds) == 0 {
 		reqLogger.Info(fmt.Sprintf("Benchmark %s has already registered", benchmark.GetName()))
+		jtm.SetPlannedBuilds(gvk, benchmark.GetName(), plannedBuilds)
 		return nil
 	}
 
+	// logged once per planning of new jobs
+	for _, err := range ValidateDerivedIterations(benchmark) {
+		reqLogger.Info(fmt.Sprintf("Skip combination: %v", err))
+	}
+	if benchmark.Spec.Placement != nil {
+		if nodes, err := listPlacementNodes(client, benchmark); err == nil && maxRepetition <= len(nodes) {
+			reqLogger.Info(fmt.Sprintf("Node variance of %s is not split: %d repetitions on %d nodes", benchmark.GetName(), maxRepetition, len(nodes)))
+		}
+	}
+
 	dr := getResourceInterface(dc, dyn, &gvk, benchmark.Namespace)
 
 	if dr == nil {
@@ -528,115 +703,135 @@ func CreateFromOperator(jtm *JobTrackManager, client client.Client, dc *discover
 		return nil
 	}
 
-	firstLabel, iterationLabels, builds, maxRepetition := GetIteratedValues(benchmark)
-
-	var waitingJob []*unstructured.Unstructured
-	jobOptMap := make(map[string]*BaysesOptimizer)
-	isNew := false
-	var err error
-	repetition := 0
-	reqLogger.Info(fmt.Sprintf("Max Repetition: %d", maxRepetition))
-	for {
+	// reserve nodes for exclusive access before creating any job
+	if !exists && benchmark.Spec.ExclusiveNodes != nil && jtm.Reserver != nil && !IsBenchmarkCompleted(benchmark) {
+		reservedNodes, err := jtm.Reserver.Reserve(benchmark)
+		if err != nil {
+			reqLogger.Info(fmt.Sprintf("Cannot reserve nodes for %s: %v", benchmark.GetName(), err))
+			return err
+		}
+		benchmark.Status.ReservedNodes = reservedNodes
+		err = client.Status().Update(context.Background(), benchmark)
+		if err != nil {
+			reqLogger.Info(fmt.Sprintf("Cannot update reserved nodes #%v ", err))
+		}
+		// keep the leases for the life of the benchmark (stopped by Release)
+		jtm.Reserver.StartRenewal(benchmark)
+	}
 
-		if repetition >= maxRepetition {
-			break
+	// record shuffle seed to keep the same order when the benchmark is reconciled again
+	seed, seedUpdated := GetOrderSeed(benchmark)
+	if seedUpdated {
+		benchmark.Status.OrderSeed = &seed
+		err := client.Status().Update(context.Background(), benchmark)
+		if err != nil {
+			reqLogger.Info(fmt.Sprintf("Cannot update order seed #%v ", err))
 		}
+	}
 
-		for _, build := range builds {
-			// for firstLabel (iteration0 or nolabel)
-			firstBenchmarkObj := NewBenchmarkObject(benchmarkOperator)
-			extBenchmark, err := GetBenchmarkWithIteration(client, benchmark.Namespace, benchmark, firstBenchmarkObj, firstLabel, build, repetition)
-			if err != nil {
-				reqLogger.Info(fmt.Sprintf("Failed to GetBenchmarkWithIteration: %v)", err))
-			}
-			nodeTunedOptimizer := NewBayesOptimizer(benchmark.Spec.IterationSpec.Minimize)
-			jobName := extBenchmark.GetName()
-			jobOptMap[jobName] = nodeTunedOptimizer
-
-			nodeSelectionSpec := benchmark.Spec.IterationSpec.NodeSelection
-			if nodeSelectionSpec != nil {
-				tunedValue := getTunedValue(nodeSelectionSpec, extBenchmark)
-				if tunedValue == RESERVED_AUTOTUNED_PROFILE_NAME && tunedHandler != nil {
-					// activate auto-tuning
-					go nodeTunedOptimizer.AutoTune()
-				} else {
-					nodeTunedOptimizer.SetFinalizedApplied()
-				}
-				reqLogger.Info(fmt.Sprintf("Set JobOptimizerMap %s - %s, %v)", jobName, tunedValue, extBenchmark))
-			} else {
-				nodeTunedOptimizer.SetFinalizedApplied()
-			}
+	labels := append([]map[string]string{firstLabel}, iterationLabels...)
+	iterationIndex, configurationIndex := getLabelIndexMap(benchmark, labels)
 
-			if err == nil && !isNew {
-				err, isNew = CreateIfNotExists(dr, benchmark, extBenchmark, adaptor, tunedHandler, nodeTunedOptimizer)
-				reqLogger.Info(fmt.Sprintf("Try creating %s (first label)", extBenchmark.GetName()))
-				if err != nil {
-					reqLogger.Info(fmt.Sprintf("Failed to create benchmark %s: %v)", benchmark.Name, err))
-				}
-			} else {
-				_, existErr := dr.Get(context.TODO(), extBenchmark.GetName(), metav1.GetOptions{})
-				if existErr != nil {
-					waitingJob = append(waitingJob, extBenchmark.DeepCopy())
-				}
+	var plannedJobs []plannedJob
+	jobOptMap := make(map[string]*BaysesOptimizer)
+	nodeSelectionSpec := benchmark.Spec.IterationSpec.NodeSelection
+	reqLogger.Info(fmt.Sprintf("Max Repetition: %d", maxRepetition))
+	for repetition := 0; repetition < maxRepetition; repetition++ {
+		for buildIndex, build := range builds {
+			if !newBuilds[build] {
+				continue
 			}
-			// for the rest iteration
-			for _, iterationLabel := range iterationLabels {
+			for labelIndex, iterationLabel := range labels {
+				if !IsScenarioPlanned(benchmark, build, iterationLabel) {
+					continue
+				}
 				benchmarkObj := NewBenchmarkObject(benchmarkOperator)
-				waitExtBenchmark, err := GetBenchmarkWithIteration(client, benchmark.Namespace, benchmark, benchmarkObj, iterationLabel, build, repetition)
+				extBenchmark, err := GetBenchmarkWithIteration(client, benchmark.Namespace, benchmark, benchmarkObj, iterationLabel, build, repetition)
 				if err != nil {
 					reqLogger.Info(fmt.Sprintf("Failed to GetBenchmarkWithIteration: %v)", err))
 					continue
 				}
 				nodeTunedOptimizer := NewBayesOptimizer(benchmark.Spec.IterationSpec.Minimize)
-				jobName = waitExtBenchmark.GetName()
+				jobName := extBenchmark.GetName()
 				jobOptMap[jobName] = nodeTunedOptimizer
 
 				if nodeSelectionSpec != nil {
-					tunedValue := getTunedValue(nodeSelectionSpec, waitExtBenchmark)
-
-					if tunedValue == RESERVED_AUTOTUNED_PROFILE_NAME {
+					tunedValue := getTunedValue(nodeSelectionSpec, extBenchmark)
+					if tunedValue == RESERVED_AUTOTUNED_PROFILE_NAME && tunedHandler != nil {
 						// activate auto-tuning
 						go nodeTunedOptimizer.AutoTune()
 					} else {
 						nodeTunedOptimizer.SetFinalizedApplied()
 					}
-					reqLogger.Info(fmt.Sprintf("Set JobOptimizerMap %s - %s, %v)", jobName, tunedValue, waitExtBenchmark))
+					reqLogger.Info(fmt.Sprintf("Set JobOptimizerMap %s - %s, %v)", jobName, tunedValue, extBenchmark))
 				} else {
 					nodeTunedOptimizer.SetFinalizedApplied()
 				}
 
-				if benchmark.Spec.IterationSpec.Sequential || benchmark.Spec.IterationSpec.NodeSelection != nil { // must be sequential if node selection is set
-					// to create at least one new job
-					if err == nil && !isNew {
-						err, isNew = CreateIfNotExists(dr, benchmark, waitExtBenchmark, adaptor, tunedHandler, nodeTunedOptimizer)
-						reqLogger.Info(fmt.Sprintf("Try creating %s", waitExtBenchmark.GetName()))
-						if err != nil {
-							reqLogger.Info(fmt.Sprintf("Failed to create benchmark %s: %v)", benchmark.Name, err))
-						}
-					} else {
-						_, existErr := dr.Get(context.TODO(), waitExtBenchmark.GetName(), metav1.GetOptions{})
-						if existErr != nil {
-							waitingJob = append(waitingJob, waitExtBenchmark.DeepCopy())
-						}
-					}
-				} else {
-					err, _ = CreateIfNotExists(dr, benchmark, waitExtBenchmark, adaptor, tunedHandler, nodeTunedOptimizer)
-					if err != nil {
-						reqLogger.Info(fmt.Sprintf("Failed to create benchmark %s: %v)", benchmark.Name, err))
-					}
-				}
+				// first label (iteration0 or nolabel) always waits for the previous one
+				// the rest iteration must be sequential if node selection, system under test, or helm iteration is set
+				sequential := labelIndex == 0 || benchmark.Spec.IterationSpec.Sequential || nodeSelectionSpec != nil || IsGroupedByConfiguration(benchmark)
+				plannedJobs = append(plannedJobs, plannedJob{
+					job:        extBenchmark,
+					optimizer:  nodeTunedOptimizer,
+					sequential: sequential,
+					key: JobOrderKey{
+						Repetition:    repetition,
+						Build:         buildIndex,
+						Iteration:     iterationIndex[labelIndex],
+						Configuration: configurationIndex[labelIndex],
+					},
+					configuration: GetSystemUnderTestHash(benchmark, iterationLabel),
+				})
 			}
 		}
-		repetition = repetition + 1
-
 	}
 
-	if err != nil {
-		reqLogger.Info(fmt.Sprintf("Cannot create #%v: %s", err, benchmark.GetName()))
-		return err
+	var waitingJob []*unstructured.Unstructured
+	// jobs waiting for their preparation are created by the tracker once ready
+	var pendingJobs []PendingJob
+	// jobs of newly tracked builds wait for the ones already in the waiting list
+	isNew := exists && jtm.HasWaitingJob(gvk, benchmark.GetName())
+	for _, planned := range orderPlannedJobs(benchmark, plannedJobs, seed) {
+		if !planned.sequential {
+			err, _ := CreateIfNotExists(dc, dyn, dr, benchmark, planned.job, adaptor, tunedHandler, planned.optimizer)
+			if IsJobNotReady(err) {
+				pendingJobs = append(pendingJobs, PendingJob{Job: planned.job, DR: dr})
+			} else if err != nil {
+				reqLogger.Info(fmt.Sprintf("Failed to create benchmark %s: %v)", benchmark.Name, err))
+				RecordHookFailure(client, jtm.Recorder, benchmark, err)
+			}
+			continue
+		}
+		// to create at least one new job
+		if !isNew {
+			var err error
+			err, isNew = CreateIfNotExists(dc, dyn, dr, benchmark, planned.job, adaptor, tunedHandler, planned.optimizer)
+			reqLogger.Info(fmt.Sprintf("Try creating %s", planned.job.GetName()))
+			if IsJobNotReady(err) {
+				isNew = true
+				pendingJobs = append(pendingJobs, PendingJob{Job: planned.job, DR: dr, Sequential: true})
+			} else if err != nil {
+				reqLogger.Info(fmt.Sprintf("Failed to create benchmark %s: %v)", benchmark.Name, err))
+				RecordHookFailure(client, jtm.Recorder, benchmark, err)
+			}
+		} else {
+			_, existErr := dr.Get(context.TODO(), planned.job.GetName(), metav1.GetOptions{})
+			if existErr != nil {
+				waitingJob = append(waitingJob, planned.job.DeepCopy())
+			}
+		}
 	}
 
-	jtm.NewTracker(gvk, benchmark.GetName(), waitingJob, dr, adaptor, jobOptMap)
+	if exists {
+		jtm.AddWaitingJob(gvk, benchmark.GetName(), waitingJob, dr, jobOptMap)
+	} else {
+		jtm.NewTracker(gvk, benchmark.GetName(), waitingJob, dr, adaptor, jobOptMap)
+	}
+	jtm.AddPendingJob(gvk, benchmark.GetName(), pendingJobs)
+	// finalize the benchmark if no job is created (e.g., all failed at their preparation)
+	jtm.RequestProgress(gvk, benchmark)
+	jtm.SetPlannedBuilds(gvk, benchmark.GetName(), plannedBuilds)
 
 	return nil
 }
@@ -0,0 +1,121 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+
+package controllers
+
+///////////////////////////////////////////////////////////////////////////
+//
+// derived_iteration.go
+//
+// GetIterationLabels
+// - set values of iteration items with expression (CEL) to each combination of the other items
+//   variables: other item names (non-identifier characters replaced by _),
+//   integer and float values are converted to numbers unless the item type is string
+//   derived items are evaluated in the defined order and can refer to the previous ones
+// - combinations that cannot be evaluated are not planned (see ValidateDerivedIterations)
+//
+////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"strconv"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+)
+
+// IsDerivedIteration returns true if the value of the item is computed from the other items
+func IsDerivedIteration(item cpev1.IterationItem) bool {
+	return item.Expression != ""
+}
+
+// GetIndependentIterations returns items with listed values
+func GetIndependentIterations(items []cpev1.IterationItem) []cpev1.IterationItem {
+	var independentItems []cpev1.IterationItem
+	for _, item := range items {
+		if !IsDerivedIteration(item) {
+			independentItems = append(independentItems, item)
+		}
+	}
+	return independentItems
+}
+
+func getIterationVariable(value string, valueType string) interface{} {
+	if valueType == VALUE_TYPE_STRING {
+		return value
+	}
+	if intValue, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return intValue
+	}
+	if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+		return floatValue
+	}
+	return value
+}
+
+func formatDerivedValue(value interface{}) string {
+	switch typedValue := value.(type) {
+	case string:
+		return typedValue
+	case int64:
+		return strconv.FormatInt(typedValue, 10)
+	case uint64:
+		return strconv.FormatUint(typedValue, 10)
+	case float64:
+		return strconv.FormatFloat(typedValue, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// DeriveIterationValues sets values of derived items to the label
+func DeriveIterationValues(items []cpev1.IterationItem, label map[string]string) error {
+	valueTypes := make(map[string]string)
+	for _, item := range items {
+		valueTypes[item.Name] = item.Type
+	}
+	variables := make(map[string]interface{})
+	for key, value := range label {
+		variables[GetVariableName(key)] = getIterationVariable(value, valueTypes[key])
+	}
+	for _, item := range items {
+		if !IsDerivedIteration(item) {
+			continue
+		}
+		value, err := evaluateExpression(item.Expression, variables)
+		if err != nil {
+			return fmt.Errorf("%s of %v: %v", item.Name, label, err)
+		}
+		derivedValue := formatDerivedValue(value)
+		if derivedValue == "" {
+			return fmt.Errorf("%s of %v: empty value", item.Name, label)
+		}
+		label[item.Name] = derivedValue
+		variables[GetVariableName(item.Name)] = getIterationVariable(derivedValue, item.Type)
+	}
+	return nil
+}
+
+// GetIterationLabels returns combinations of the items with derived values and errors of the combinations that cannot be evaluated
+func GetIterationLabels(items []cpev1.IterationItem) ([]map[string]string, []error) {
+	labels := itrHandler.GetAllCombination(GetIndependentIterations(items))
+	if len(labels) == 0 {
+		labels = []map[string]string{{}}
+	}
+	var derivedLabels []map[string]string
+	var errs []error
+	for _, label := range labels {
+		if err := DeriveIterationValues(items, label); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		derivedLabels = append(derivedLabels, label)
+	}
+	return derivedLabels, errs
+}
+
+// ValidateDerivedIterations returns errors of the combinations that are not planned
+func ValidateDerivedIterations(benchmark *cpev1.Benchmark) []error {
+	_, errs := GetIterationLabels(GetCombinedIterations(benchmark))
+	return errs
+}
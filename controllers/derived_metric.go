@@ -0,0 +1,139 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+
+package controllers
+
+///////////////////////////////////////////////////////////////////////////
+//
+// derived_metric.go
+//
+// UpdateDerivedValues
+// - evaluate CEL expressions of derivedMetrics on each result item
+//   with the variables of assertions (see assertion.go)
+// - if reference is set, variables of the reference scenario are exposed as ref
+//   (same build and labels except the reference ones, same repetition if exists)
+// - recompute all items since the reference result may arrive later
+// - a metric named as a parsed key of the item is rejected
+//
+////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+)
+
+const DERIVED_REFERENCE_VAR = "ref"
+
+func getResultLabels(result cpev1.BenchmarkResult) map[string]string {
+	labels := make(map[string]string)
+	for key, value := range result.IterationMap {
+		labels[key] = value
+	}
+	for key, value := range result.ConfigurationMap {
+		labels[key] = value
+	}
+	return labels
+}
+
+// isReferenceResult returns true if the labels of candidate equal to labels except the reference values
+func isReferenceResult(reference map[string]string, labels map[string]string, candidateLabels map[string]string) bool {
+	for key, value := range reference {
+		if candidateLabels[key] != value {
+			return false
+		}
+	}
+	for key, value := range labels {
+		if _, isReference := reference[key]; !isReference && candidateLabels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+func getReferenceItem(benchmark *cpev1.Benchmark, result cpev1.BenchmarkResult, reference map[string]string, repetition string) (cpev1.BenchmarkResultItem, cpev1.BenchmarkResult, bool) {
+	labels := getResultLabels(result)
+	for _, candidate := range benchmark.Status.Results {
+		if candidate.BuildID != result.BuildID || len(candidate.Items) == 0 || !isReferenceResult(reference, labels, getResultLabels(candidate)) {
+			continue
+		}
+		for _, item := range candidate.Items {
+			if item.Repetition == repetition {
+				return item, candidate, true
+			}
+		}
+		return candidate.Items[0], candidate, true
+	}
+	return cpev1.BenchmarkResultItem{}, cpev1.BenchmarkResult{}, false
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch number := value.(type) {
+	case float64:
+		return number, true
+	case int64:
+		return float64(number), true
+	case uint64:
+		return float64(number), true
+	}
+	return 0, false
+}
+
+// GetDerivedValues returns values of derived metrics of the item
+func GetDerivedValues(benchmark *cpev1.Benchmark, result cpev1.BenchmarkResult, item cpev1.BenchmarkResultItem) (map[string]string, []error) {
+	var errs []error
+	derivedValues := make(map[string]string)
+	variables := GetAssertionVariables(item, result.IterationMap, result.ConfigurationMap)
+	parsedValues, _ := variables[ASSERTION_VALUES_VAR].(map[string]interface{})
+	for _, metric := range benchmark.Spec.DerivedMetrics {
+		if _, collided := parsedValues[metric.Name]; collided {
+			errs = append(errs, fmt.Errorf("%s: collides with parsed key", metric.Name))
+			continue
+		}
+		metricVariables := variables
+		if len(metric.Reference) > 0 {
+			referenceItem, referenceResult, found := getReferenceItem(benchmark, result, metric.Reference, item.Repetition)
+			if !found {
+				continue
+			}
+			metricVariables = make(map[string]interface{})
+			for key, value := range variables {
+				metricVariables[key] = value
+			}
+			metricVariables[DERIVED_REFERENCE_VAR] = GetAssertionVariables(referenceItem, referenceResult.IterationMap, referenceResult.ConfigurationMap)
+		}
+		value, err := evaluateExpression(metric.Expression, metricVariables)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", metric.Name, err))
+			continue
+		}
+		floatValue, ok := toFloat(value)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %v is not a number", metric.Name, value))
+			continue
+		}
+		derivedValues[metric.Name] = fmt.Sprintf("%f", floatValue)
+	}
+	if len(derivedValues) == 0 {
+		return nil, errs
+	}
+	return derivedValues, errs
+}
+
+// UpdateDerivedValues computes derived values of all result items
+func UpdateDerivedValues(benchmark *cpev1.Benchmark) []error {
+	if len(benchmark.Spec.DerivedMetrics) == 0 {
+		return nil
+	}
+	var errs []error
+	for resultIndex, result := range benchmark.Status.Results {
+		for itemIndex, item := range result.Items {
+			derivedValues, itemErrs := GetDerivedValues(benchmark, result, item)
+			benchmark.Status.Results[resultIndex].Items[itemIndex].DerivedValues = derivedValues
+			errs = append(errs, itemErrs...)
+		}
+	}
+	return errs
+}
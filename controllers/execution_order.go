@@ -0,0 +1,221 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+
+package controllers
+
+///////////////////////////////////////////////////////////////////////////
+//
+// execution_order.go
+//
+// GetOrderSeed
+// - return seed of shuffle order (spec > recorded status > generated)
+// GetExecutionOrder
+// - return order of jobs by strategy
+//   sequential: repetition > build > configuration > iteration (default)
+//   shuffle: random permutation by seed
+//   interleave: alternate builds (or configurations) within each repetition
+// GroupByConfiguration
+// - keep order within each configuration (by first appearance)
+//   so that system under test and helm iteration are deployed once per configuration
+// ValidateExecutionOrder
+// - reject unknown strategy or interleaveBy
+// - reject interleaving configurations when jobs are grouped by configuration
+//
+////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	ORDER_SEQUENTIAL         = "sequential"
+	ORDER_SHUFFLE            = "shuffle"
+	ORDER_INTERLEAVE         = "interleave"
+	INTERLEAVE_BUILD         = "build"
+	INTERLEAVE_CONFIGURATION = "configuration"
+)
+
+// position of job in the nested loop
+type JobOrderKey struct {
+	Repetition    int
+	Build         int
+	Iteration     int
+	Configuration int
+}
+
+type plannedJob struct {
+	job           *unstructured.Unstructured
+	optimizer     *BaysesOptimizer
+	sequential    bool
+	key           JobOrderKey
+	configuration string
+}
+
+func getOrderStrategy(benchmark *cpev1.Benchmark) string {
+	orderSpec := benchmark.Spec.IterationSpec.Order
+	if orderSpec == nil || orderSpec.Strategy == "" {
+		return ORDER_SEQUENTIAL
+	}
+	return orderSpec.Strategy
+}
+
+// GetOrderSeed returns seed of shuffle order and whether it has to be recorded to status
+func GetOrderSeed(benchmark *cpev1.Benchmark) (int64, bool) {
+	if getOrderStrategy(benchmark) != ORDER_SHUFFLE {
+		return 0, false
+	}
+	orderSpec := benchmark.Spec.IterationSpec.Order
+	recorded := benchmark.Status.OrderSeed
+	if orderSpec.Seed != nil {
+		return *orderSpec.Seed, recorded == nil || *recorded != *orderSpec.Seed
+	}
+	if recorded != nil {
+		return *recorded, false
+	}
+	return time.Now().UnixNano(), true
+}
+
+// getLabelIndexMap returns index of iteration part and configuration part of each label by first appearance
+func getLabelIndexMap(benchmark *cpev1.Benchmark, labels []map[string]string) (iterationIndex []int, configurationIndex []int) {
+	iterationNames := make(map[string]bool)
+	for _, item := range benchmark.Spec.IterationSpec.Iteration {
+		iterationNames[item.Name] = true
+	}
+	iterationIDs := make(map[string]int)
+	configurationIDs := make(map[string]int)
+	for _, label := range labels {
+		var iterationParts, configurationParts []string
+		for _, key := range getOrderedKey(label) {
+			part := key + "=" + label[key]
+			if iterationNames[key] {
+				iterationParts = append(iterationParts, part)
+			} else {
+				configurationParts = append(configurationParts, part)
+			}
+		}
+		iterationID := strings.Join(iterationParts, ";")
+		if _, exists := iterationIDs[iterationID]; !exists {
+			iterationIDs[iterationID] = len(iterationIDs)
+		}
+		configurationID := strings.Join(configurationParts, ";")
+		if _, exists := configurationIDs[configurationID]; !exists {
+			configurationIDs[configurationID] = len(configurationIDs)
+		}
+		iterationIndex = append(iterationIndex, iterationIDs[iterationID])
+		configurationIndex = append(configurationIndex, configurationIDs[configurationID])
+	}
+	return iterationIndex, configurationIndex
+}
+
+func compareKeys(a []int, b []int) bool {
+	for index := range a {
+		if a[index] != b[index] {
+			return a[index] < b[index]
+		}
+	}
+	return false
+}
+
+// GetExecutionOrder returns indexes of keys in execution order
+func GetExecutionOrder(keys []JobOrderKey, orderSpec *cpev1.ExecutionOrderSpec, seed int64) []int {
+	order := make([]int, len(keys))
+	for index := range order {
+		order[index] = index
+	}
+	if orderSpec == nil {
+		return order
+	}
+	switch orderSpec.Strategy {
+	case ORDER_SHUFFLE:
+		random := rand.New(rand.NewSource(seed))
+		random.Shuffle(len(order), func(i, j int) {
+			order[i], order[j] = order[j], order[i]
+		})
+	case ORDER_INTERLEAVE:
+		sortKey := func(key JobOrderKey) []int {
+			if orderSpec.InterleaveBy == INTERLEAVE_CONFIGURATION {
+				return []int{key.Repetition, key.Build, key.Iteration, key.Configuration}
+			}
+			return []int{key.Repetition, key.Configuration, key.Iteration, key.Build}
+		}
+		sort.SliceStable(order, func(i, j int) bool {
+			return compareKeys(sortKey(keys[order[i]]), sortKey(keys[order[j]]))
+		})
+	}
+	return order
+}
+
+func orderPlannedJobs(benchmark *cpev1.Benchmark, plannedJobs []plannedJob, seed int64) []plannedJob {
+	keys := make([]JobOrderKey, len(plannedJobs))
+	for index, planned := range plannedJobs {
+		keys[index] = planned.key
+	}
+	order := GetExecutionOrder(keys, benchmark.Spec.IterationSpec.Order, seed)
+	if IsGroupedByConfiguration(benchmark) {
+		groups := make([]string, len(plannedJobs))
+		for index, planned := range plannedJobs {
+			groups[index] = planned.configuration
+		}
+		order = GroupByConfiguration(order, groups)
+	}
+	var orderedJobs []plannedJob
+	for _, index := range order {
+		orderedJobs = append(orderedJobs, plannedJobs[index])
+	}
+	return orderedJobs
+}
+
+// IsGroupedByConfiguration returns true if jobs must run sequentially grouped by configuration
+func IsGroupedByConfiguration(benchmark *cpev1.Benchmark) bool {
+	return benchmark.Spec.SystemUnderTest != nil || HasHelmIteration(benchmark)
+}
+
+// GroupByConfiguration returns the order grouped by configuration
+func GroupByConfiguration(order []int, groups []string) []int {
+	groupIndex := make(map[string]int)
+	for _, index := range order {
+		if _, exists := groupIndex[groups[index]]; !exists {
+			groupIndex[groups[index]] = len(groupIndex)
+		}
+	}
+	groupedOrder := append([]int{}, order...)
+	sort.SliceStable(groupedOrder, func(i, j int) bool {
+		return groupIndex[groups[groupedOrder[i]]] < groupIndex[groups[groupedOrder[j]]]
+	})
+	return groupedOrder
+}
+
+// ValidateExecutionOrder returns error if the order strategy is unknown or conflicts with grouping by configuration
+// (shuffle is applied within each configuration)
+func ValidateExecutionOrder(benchmark *cpev1.Benchmark) error {
+	orderSpec := benchmark.Spec.IterationSpec.Order
+	if orderSpec == nil {
+		return nil
+	}
+	switch orderSpec.Strategy {
+	case "", ORDER_SEQUENTIAL, ORDER_SHUFFLE, ORDER_INTERLEAVE:
+	default:
+		return fmt.Errorf("unknown order strategy %q (supported: %s, %s, %s)", orderSpec.Strategy, ORDER_SEQUENTIAL, ORDER_SHUFFLE, ORDER_INTERLEAVE)
+	}
+	switch orderSpec.InterleaveBy {
+	case "", INTERLEAVE_BUILD, INTERLEAVE_CONFIGURATION:
+	default:
+		return fmt.Errorf("unknown interleaveBy %q (supported: %s, %s)", orderSpec.InterleaveBy, INTERLEAVE_BUILD, INTERLEAVE_CONFIGURATION)
+	}
+	if !IsGroupedByConfiguration(benchmark) {
+		return nil
+	}
+	if orderSpec.Strategy == ORDER_INTERLEAVE && orderSpec.InterleaveBy == INTERLEAVE_CONFIGURATION {
+		return fmt.Errorf("cannot interleave configurations as jobs are grouped by configuration for systemUnderTest or helm iteration")
+	}
+	return nil
+}
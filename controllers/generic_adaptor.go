@@ -0,0 +1,159 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+
+package controllers
+
+///////////////////////////////////////////////////////////////////////////
+//
+// generic_adaptor.go
+//
+// GenericAdaptor
+// - OperatorAdaptor declared by genericAdaptor of BenchmarkOperator
+//   CheckComplete/CheckFailed: condition types with status True or status values at the path
+//   (JSON Pointer or JSONPath, see iteration_location.go)
+//   GetPodList: pods matched by podSelector (and resultPod name prefix) rendered over the job object
+//   GetLogContainer: resultContainer
+//
+////////////////////////////////////////////////////////////////////////////
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes"
+)
+
+const DEFAULT_GENERIC_POD_SELECTOR = "job-name={{ .metadata.name }}"
+
+// Generic Operator Adaptor
+type GenericAdaptor struct {
+	*BaseOperatorAdaptor
+	Spec cpev1.GenericAdaptorSpec
+}
+
+func NewGenericAdaptor(spec cpev1.GenericAdaptorSpec) *GenericAdaptor {
+	genericAdaptor := &GenericAdaptor{Spec: spec}
+	abs := &BaseOperatorAdaptor{
+		OperatorAdaptor: genericAdaptor,
+	}
+	genericAdaptor.BaseOperatorAdaptor = abs
+	return genericAdaptor
+}
+
+// isAnyConditionTrue returns true if any of the condition types has status True
+func isAnyConditionTrue(jobObject map[string]interface{}, conditionTypes []string) bool {
+	for _, conditionType := range conditionTypes {
+		if isConditionTrue(&unstructured.Unstructured{Object: jobObject}, conditionType) {
+			return true
+		}
+	}
+	return false
+}
+
+// isStatusMatched returns true if any value at the path is in the values
+func isStatusMatched(jobObject map[string]interface{}, statusMatch *cpev1.StatusMatchSpec) bool {
+	if statusMatch == nil {
+		return false
+	}
+	values, err := GetLocationValues(jobObject, statusMatch.Path)
+	if err != nil {
+		return false
+	}
+	for _, value := range values {
+		for _, expectedValue := range statusMatch.Values {
+			if fmt.Sprintf("%v", value) == expectedValue {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (a *GenericAdaptor) CheckComplete(jobObject map[string]interface{}) bool {
+	return isAnyConditionTrue(jobObject, a.Spec.CompleteConditions) || isStatusMatched(jobObject, a.Spec.CompleteStatus)
+}
+
+func (a *GenericAdaptor) CheckFailed(jobObject map[string]interface{}) bool {
+	return isAnyConditionTrue(jobObject, a.Spec.FailedConditions) || isStatusMatched(jobObject, a.Spec.FailedStatus)
+}
+
+// ExecuteJobTemplate renders the template over the job object
+func ExecuteJobTemplate(jobTemplate string, jobObject map[string]interface{}) (string, error) {
+	tmpl, err := template.New("").Funcs(GetTemplateFuncMap()).Option("missingkey=error").Parse(jobTemplate)
+	if err != nil {
+		return "", err
+	}
+	var buffer bytes.Buffer
+	if err = tmpl.Execute(&buffer, jobObject); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buffer.String()), nil
+}
+
+// GetPodSelector returns label selector of the job pods
+func (a *GenericAdaptor) GetPodSelector(jobObject map[string]interface{}) (string, error) {
+	podSelector := a.Spec.PodSelector
+	if podSelector == "" {
+		podSelector = DEFAULT_GENERIC_POD_SELECTOR
+	}
+	return ExecuteJobTemplate(podSelector, jobObject)
+}
+
+// FilterResultPods returns the pods with the result log
+func (a *GenericAdaptor) FilterResultPods(jobObject map[string]interface{}, podList *corev1.PodList) (*corev1.PodList, error) {
+	if a.Spec.ResultPod == "" {
+		return podList, nil
+	}
+	podPrefix, err := ExecuteJobTemplate(a.Spec.ResultPod, jobObject)
+	if err != nil {
+		return podList, err
+	}
+	var podItems []corev1.Pod
+	for _, pod := range podList.Items {
+		if strings.HasPrefix(pod.GetName(), podPrefix) {
+			podItems = append(podItems, pod)
+		}
+	}
+	sublist := &corev1.PodList{
+		Items:    podItems,
+		TypeMeta: podList.TypeMeta,
+	}
+	return sublist, nil
+}
+
+func (a *GenericAdaptor) GetPodList(jobObject map[string]interface{}, clientset *kubernetes.Clientset) (*corev1.PodList, error) {
+	jobMeta := jobObject["metadata"].(map[string]interface{})
+	jobNamespace := jobMeta["namespace"].(string)
+
+	podSelector, err := a.GetPodSelector(jobObject)
+	if err != nil {
+		return &corev1.PodList{}, err
+	}
+	listOptions := metav1.ListOptions{
+		LabelSelector: podSelector,
+		Limit:         100,
+	}
+
+	podList, err := clientset.CoreV1().Pods(jobNamespace).List(context.TODO(), listOptions)
+	if err != nil {
+		return podList, err
+	}
+	return a.FilterResultPods(jobObject, podList)
+}
+
+func (a *GenericAdaptor) GetLogContainer(pod corev1.Pod) string {
+	return a.Spec.ResultContainer
+}
+
+func (a *GenericAdaptor) CopyJobResource(originalJob *unstructured.Unstructured) *unstructured.Unstructured {
+	return originalJob.DeepCopy()
+}
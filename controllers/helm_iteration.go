@@ -0,0 +1,314 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+
+package controllers
+
+///////////////////////////////////////////////////////////////////////////
+//
+// helm_iteration.go
+//
+// GetHelmIteration
+// - chart version and values of configuration items with helm set
+//   (version: chart version, otherwise dotted values path, e.g., .image.tag)
+// DeployHelmIteration
+// - request the iteration of the job configuration to BenchmarkOperator (annotation)
+//   and check whether BenchmarkOperator reconciler upgraded the chart and the release is rolled out
+//   (jobs are grouped by configuration and run sequentially, see execution_order.go)
+// - wait while the other existing benchmark holds the request
+// - only failed upgrade and rollout timeout fail the job, API errors (e.g., conflict) are retried
+// GetHelmIterationTimeout
+// - timeout of rolling out since the request time (timeoutSeconds of helm spec)
+// ReleaseHelmIteration
+// - remove the request once all jobs are completed (reinstall with the original values)
+// GetHelmValuesYaml
+// - set values paths to valuesYaml of BenchmarkOperator (used by BenchmarkOperator reconciler)
+//
+////////////////////////////////////////////////////////////////////////////
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	HELM_ITERATION_ANNOTATION      = "cpe.cogadvisor.io/helm-iteration"
+	HELM_ITERATION_TIME_ANNOTATION = "cpe.cogadvisor.io/helm-iteration-time"
+	HELM_ITERATION_VERSION         = "version"
+	DEFAULT_HELM_ITERATION_TIMEOUT = 600
+	HELM_ITERATION_POLL_INTERVAL   = 5 * time.Second
+	STEP_HELM_ITERATION            = "helmIteration"
+)
+
+var benchmarkOperatorGVR = cpev1.GroupVersion.WithResource("benchmarkoperators")
+var benchmarkGVR = cpev1.GroupVersion.WithResource("benchmarks")
+
+// HasHelmIteration returns true if any configuration is applied to helm deployment of BenchmarkOperator
+func HasHelmIteration(benchmark *cpev1.Benchmark) bool {
+	for _, item := range benchmark.Spec.IterationSpec.Configuration {
+		if item.Helm != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// GetHelmIteration returns chart version and values of the iteration label, nil if no helm configuration
+func GetHelmIteration(benchmark *cpev1.Benchmark, iterationLabel map[string]string) *cpev1.HelmIteration {
+	if !HasHelmIteration(benchmark) {
+		return nil
+	}
+	iteration := &cpev1.HelmIteration{Benchmark: benchmark.Namespace + "/" + benchmark.Name}
+	for _, item := range benchmark.Spec.IterationSpec.Configuration {
+		value, ok := iterationLabel[item.Name]
+		if item.Helm == "" || !ok {
+			continue
+		}
+		if item.Helm == HELM_ITERATION_VERSION {
+			iteration.Version = value
+			continue
+		}
+		if iteration.Values == nil {
+			iteration.Values = make(map[string]string)
+		}
+		iteration.Values[item.Helm] = value
+	}
+	return iteration
+}
+
+// GetRequestedHelmIteration returns the iteration requested to BenchmarkOperator, nil if not requested
+func GetRequestedHelmIteration(benchmarkOperator *cpev1.BenchmarkOperator) (*cpev1.HelmIteration, error) {
+	requested, ok := benchmarkOperator.GetAnnotations()[HELM_ITERATION_ANNOTATION]
+	if !ok {
+		return nil, nil
+	}
+	iteration := &cpev1.HelmIteration{}
+	if err := json.Unmarshal([]byte(requested), iteration); err != nil {
+		return nil, err
+	}
+	return iteration, nil
+}
+
+// GetHelmIterationRequestTime returns when the current iteration was requested, zero if unknown
+func GetHelmIterationRequestTime(benchmarkOperator *cpev1.BenchmarkOperator) time.Time {
+	requestTime, err := time.Parse(time.RFC3339, benchmarkOperator.GetAnnotations()[HELM_ITERATION_TIME_ANNOTATION])
+	if err != nil {
+		return time.Time{}
+	}
+	return requestTime
+}
+
+// GetHelmIterationTimeout returns timeout of rolling out the iteration since it is requested
+func GetHelmIterationTimeout(helmSpec cpev1.HelmSpec) time.Duration {
+	timeoutSeconds := helmSpec.TimeoutSeconds
+	if timeoutSeconds <= 0 {
+		timeoutSeconds = DEFAULT_HELM_ITERATION_TIMEOUT
+	}
+	return time.Duration(timeoutSeconds) * time.Second
+}
+
+// parseHelmValue keeps type of integer and boolean values
+func parseHelmValue(value string) interface{} {
+	if intValue, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return intValue
+	}
+	if boolValue, err := strconv.ParseBool(value); err == nil && (value == "true" || value == "false") {
+		return boolValue
+	}
+	return value
+}
+
+// GetHelmValuesYaml returns valuesYaml with the values set at their dotted paths
+func GetHelmValuesYaml(valuesYaml string, values map[string]string) (string, error) {
+	if len(values) == 0 {
+		return valuesYaml, nil
+	}
+	valuesMap := make(map[string]interface{})
+	if err := yaml.Unmarshal([]byte(valuesYaml), &valuesMap); err != nil {
+		return "", err
+	}
+	if valuesMap == nil {
+		valuesMap = make(map[string]interface{})
+	}
+	for path, value := range values {
+		keys := strings.Split(strings.TrimPrefix(path, "."), ".")
+		current := valuesMap
+		for _, key := range keys[:len(keys)-1] {
+			next, ok := current[key].(map[string]interface{})
+			if !ok {
+				next = make(map[string]interface{})
+				current[key] = next
+			}
+			current = next
+		}
+		current[keys[len(keys)-1]] = parseHelmValue(value)
+	}
+	yamlBytes, err := yaml.Marshal(valuesMap)
+	if err != nil {
+		return "", err
+	}
+	return string(yamlBytes), nil
+}
+
+func getBenchmarkOperatorInterface(dyn dynamic.Interface, benchmark *cpev1.Benchmark) dynamic.ResourceInterface {
+	namespace := benchmark.Spec.Operator.Namespace
+	if namespace == "" {
+		namespace = "default"
+	}
+	return dyn.Resource(benchmarkOperatorGVR).Namespace(namespace)
+}
+
+// isBenchmarkExist returns true if the benchmark of namespace/name is not deleted
+func isBenchmarkExist(dyn dynamic.Interface, benchmarkKey string) bool {
+	keys := strings.SplitN(benchmarkKey, "/", 2)
+	if len(keys) != 2 {
+		return false
+	}
+	_, err := dyn.Resource(benchmarkGVR).Namespace(keys[0]).Get(context.TODO(), keys[1], metav1.GetOptions{})
+	return !errors.IsNotFound(err)
+}
+
+// setHelmIterationRequest sets (or removes if nil) the request annotation if changed,
+// return false without setting it while the request belongs to the other existing benchmark
+// (retried on conflict with status updates by BenchmarkOperator reconciler)
+func setHelmIterationRequest(dyn dynamic.Interface, dr dynamic.ResourceInterface, benchmark *cpev1.Benchmark, iteration *cpev1.HelmIteration) (bool, error) {
+	requested := false
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var err error
+		requested, err = updateHelmIterationRequest(dyn, dr, benchmark, iteration)
+		return err
+	})
+	return requested, err
+}
+
+func updateHelmIterationRequest(dyn dynamic.Interface, dr dynamic.ResourceInterface, benchmark *cpev1.Benchmark, iteration *cpev1.HelmIteration) (bool, error) {
+	operatorObj, err := dr.Get(context.TODO(), benchmark.Spec.Operator.Name, metav1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+	annotations := operatorObj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	requested, exists := annotations[HELM_ITERATION_ANNOTATION]
+	owner := ""
+	if exists {
+		requestedIteration := &cpev1.HelmIteration{}
+		if err = json.Unmarshal([]byte(requested), requestedIteration); err == nil {
+			owner = requestedIteration.Benchmark
+		}
+	}
+	if owner != "" && owner != benchmark.Namespace+"/"+benchmark.Name && isBenchmarkExist(dyn, owner) {
+		// released once all jobs of the other benchmark are completed
+		return false, nil
+	}
+	if iteration == nil {
+		if !exists {
+			return true, nil
+		}
+		delete(annotations, HELM_ITERATION_ANNOTATION)
+		delete(annotations, HELM_ITERATION_TIME_ANNOTATION)
+	} else {
+		iterationBytes, err := json.Marshal(iteration)
+		if err != nil {
+			return false, err
+		}
+		if exists && requested == string(iterationBytes) {
+			return true, nil
+		}
+		annotations[HELM_ITERATION_ANNOTATION] = string(iterationBytes)
+		annotations[HELM_ITERATION_TIME_ANNOTATION] = time.Now().UTC().Format(time.RFC3339)
+	}
+	operatorObj.SetAnnotations(annotations)
+	_, err = dr.Update(context.TODO(), operatorObj, metav1.UpdateOptions{})
+	return err == nil, err
+}
+
+// IsHelmIterationApplied returns true if the iteration is installed and rolled out, error if failed
+func IsHelmIterationApplied(benchmarkOperator *cpev1.BenchmarkOperator, iteration *cpev1.HelmIteration) (bool, error) {
+	requested, err := GetRequestedHelmIteration(benchmarkOperator)
+	if err != nil || !reflect.DeepEqual(requested, iteration) || !reflect.DeepEqual(benchmarkOperator.Status.HelmIteration, iteration) {
+		return false, err
+	}
+	if benchmarkOperator.Status.HelmIterationError != "" {
+		return false, fmt.Errorf("%s", benchmarkOperator.Status.HelmIterationError)
+	}
+	return true, nil
+}
+
+// DeployHelmIteration requests the configuration of the job to BenchmarkOperator, return true if it is rolled out
+func DeployHelmIteration(dyn dynamic.Interface, benchmark *cpev1.Benchmark, jobName string) (bool, error) {
+	if !HasHelmIteration(benchmark) {
+		return true, nil
+	}
+	stepErr := func(err error) error {
+		return &HookError{Hook: STEP_HELM_ITERATION, JobName: jobName, Err: err}
+	}
+	hashItem, found := getHashItem(benchmark, jobName)
+	if !found {
+		return false, stepErr(fmt.Errorf("no hash"))
+	}
+	iteration := GetHelmIteration(benchmark, hashItem.Iteration)
+	dr := getBenchmarkOperatorInterface(dyn, benchmark)
+	// API errors are returned as is to retry the preparation
+	requested, err := setHelmIterationRequest(dyn, dr, benchmark, iteration)
+	if err != nil {
+		return false, err
+	}
+	if !requested {
+		return false, nil
+	}
+	operatorObj, err := dr.Get(context.TODO(), benchmark.Spec.Operator.Name, metav1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+	benchmarkOperator := &cpev1.BenchmarkOperator{}
+	if err = runtime.DefaultUnstructuredConverter.FromUnstructured(operatorObj.Object, benchmarkOperator); err != nil {
+		return false, stepErr(err)
+	}
+	applied, err := IsHelmIterationApplied(benchmarkOperator, iteration)
+	if err != nil {
+		return false, stepErr(fmt.Errorf("cannot apply helm iteration to %s: %v", benchmark.Spec.Operator.Name, err))
+	}
+	if applied {
+		return true, nil
+	}
+	timeout := GetHelmIterationTimeout(benchmarkOperator.Spec.DeploySpec.Helm)
+	if requestTime := GetHelmIterationRequestTime(benchmarkOperator); !requestTime.IsZero() && time.Since(requestTime) > timeout {
+		return false, stepErr(fmt.Errorf("helm iteration of %s is not rolled out within %v", benchmark.Spec.Operator.Name, timeout))
+	}
+	return false, nil
+}
+
+// ReleaseHelmIteration removes the request of the benchmark from BenchmarkOperator
+func ReleaseHelmIteration(dyn dynamic.Interface, benchmark *cpev1.Benchmark) error {
+	if !HasHelmIteration(benchmark) {
+		return nil
+	}
+	dr := getBenchmarkOperatorInterface(dyn, benchmark)
+	operatorObj, err := dr.Get(context.TODO(), benchmark.Spec.Operator.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	requested := &cpev1.HelmIteration{}
+	if err = json.Unmarshal([]byte(operatorObj.GetAnnotations()[HELM_ITERATION_ANNOTATION]), requested); err != nil || requested.Benchmark != benchmark.Namespace+"/"+benchmark.Name {
+		// requested by the other benchmark
+		return nil
+	}
+	_, err = setHelmIterationRequest(dyn, dr, benchmark, nil)
+	return err
+}
@@ -0,0 +1,285 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+
+package controllers
+
+///////////////////////////////////////////////////////////////////////////
+//
+// hook.go
+//
+// GetHookJob
+// - render batch/v1 Job of the hook with the same values as benchmarkSpec and .hook
+//   (scenario hooks are named [job name]-[hook], benchmark hooks [benchmark]-cpe-[hook])
+// CheckHook
+// - create the hook Job if not exists and check its completion without waiting
+//   (JobTracker re-checks the benchmark on the completion event of the hook Job,
+//    timeout is set to activeDeadlineSeconds of the hook Job)
+//   preRun: before creating each benchmark job (after scenario resources are ready)
+//   postRun: after each benchmark job is completed (before the next job)
+//   benchmarkPreRun: before creating the first benchmark job
+//   benchmarkPostRun: after all benchmark jobs are completed
+// RecordHookFailure
+// - add the benchmark job to failedJobs (regarded as done) and emit HookFailed event
+//   (PreparationFailed if the job failed at its preparation step such as scenario resources)
+//   only render errors, timeouts, and failed hook Jobs are HookError,
+//   other API errors are returned as is and the step is retried
+//
+////////////////////////////////////////////////////////////////////////////
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	HOOK_PRE_RUN            = "preRun"
+	HOOK_POST_RUN           = "postRun"
+	HOOK_BENCHMARK_PRE_RUN  = "benchmarkPreRun"
+	HOOK_BENCHMARK_POST_RUN = "benchmarkPostRun"
+	HOOK_LABEL              = "cpe-benchmark-hook"
+	HOOK_NAME_LABEL         = "cpe-hook"
+	TEMPLATE_HOOK_KEY       = "hook"
+	DEFAULT_HOOK_TIMEOUT    = 600
+)
+
+var hookJobGVR = schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}
+
+// HookError is an error of the hook or the preparation step (e.g., resources) of the benchmark job
+type HookError struct {
+	Hook    string
+	JobName string
+	Err     error
+}
+
+func (e *HookError) Error() string {
+	return fmt.Sprintf("%s hook of %s: %v", e.Hook, e.JobName, e.Err)
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+// isRejected returns true if the API server rejected the rendered object (retrying does not help)
+func isRejected(err error) bool {
+	return k8serrors.IsInvalid(err) || k8serrors.IsBadRequest(err)
+}
+
+func getHookTemplate(benchmark *cpev1.Benchmark, hook string) string {
+	hooks := benchmark.Spec.Hooks
+	if hooks == nil {
+		return ""
+	}
+	switch hook {
+	case HOOK_PRE_RUN:
+		return hooks.PreRun
+	case HOOK_POST_RUN:
+		return hooks.PostRun
+	case HOOK_BENCHMARK_PRE_RUN:
+		return hooks.BenchmarkPreRun
+	case HOOK_BENCHMARK_POST_RUN:
+		return hooks.BenchmarkPostRun
+	}
+	return ""
+}
+
+// GetHookJobName returns name of the hook Job of the benchmark job (benchmark hook if jobName is empty)
+func GetHookJobName(benchmark *cpev1.Benchmark, jobName string, hook string) string {
+	if jobName == "" {
+		name := benchmark.GetName() + "-cpe-" + strings.ToLower(hook)
+		if benchmark.Status.SpecRevision != "" {
+			name += "-" + benchmark.Status.SpecRevision
+		}
+		return name
+	}
+	return jobName + "-" + strings.ToLower(hook)
+}
+
+// GetHookJob returns the hook Job of the benchmark job (benchmark hook if hashItem is nil), nil if the hook is not set
+func GetHookJob(benchmark *cpev1.Benchmark, hook string, hashItem *cpev1.IterationHash) (*unstructured.Unstructured, error) {
+	hookTemplate := getHookTemplate(benchmark, hook)
+	if hookTemplate == "" {
+		return nil, nil
+	}
+	var templateContext map[string]interface{}
+	jobName := ""
+	if hashItem != nil {
+		jobName = getJobNameFromHash(benchmark.GetName(), hashItem.Hash)
+		repetition, _ := strconv.Atoi(hashItem.Repetition)
+		templateContext = GetTemplateContext(benchmark, hashItem.Iteration, hashItem.Build, repetition, jobName)
+	} else {
+		templateContext = GetTemplateContext(benchmark, map[string]string{}, GetEvaluatedBuild(benchmark), 0, "")
+	}
+	templateContext[TEMPLATE_HOOK_KEY] = hook
+	executedSpec, err := ExecuteBenchmarkTemplate(hookTemplate, templateContext)
+	if err != nil {
+		return nil, err
+	}
+	jobSpec := make(map[string]interface{})
+	if err = yaml.Unmarshal([]byte(executedSpec), &jobSpec); err != nil {
+		return nil, err
+	}
+	// the hook Job fails with DeadlineExceeded after the timeout
+	if _, found := jobSpec["activeDeadlineSeconds"]; !found {
+		timeout := int64(DEFAULT_HOOK_TIMEOUT)
+		if benchmark.Spec.Hooks.TimeoutSeconds > 0 {
+			timeout = int64(benchmark.Spec.Hooks.TimeoutSeconds)
+		}
+		jobSpec["activeDeadlineSeconds"] = timeout
+	}
+	hookJob := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "batch/v1",
+		"kind":       "Job",
+		"spec":       jobSpec,
+	}}
+	hookJob.SetName(GetHookJobName(benchmark, jobName, hook))
+	hookJob.SetNamespace(benchmark.Namespace)
+	hookJob.SetLabels(map[string]string{HOOK_LABEL: benchmark.GetName(), HOOK_NAME_LABEL: strings.ToLower(hook)})
+	hookJob.SetOwnerReferences([]metav1.OwnerReference{{
+		APIVersion: cpev1.GroupVersion.String(),
+		Kind:       "Benchmark",
+		Name:       benchmark.GetName(),
+		UID:        benchmark.GetUID(),
+	}})
+	return hookJob, nil
+}
+
+// IsHookJobFinished returns whether the hook Job is finished and succeeded
+func IsHookJobFinished(hookJob *unstructured.Unstructured) (bool, bool) {
+	if succeeded, _, _ := unstructured.NestedInt64(hookJob.Object, "status", "succeeded"); succeeded > 0 {
+		return true, true
+	}
+	return isConditionTrue(hookJob, "Failed"), false
+}
+
+func getFailedReason(hookJob *unstructured.Unstructured) string {
+	conditions, _, _ := unstructured.NestedSlice(hookJob.Object, "status", "conditions")
+	for _, condition := range conditions {
+		if conditionMap, ok := condition.(map[string]interface{}); ok && conditionMap["type"] == "Failed" {
+			if reason, ok := conditionMap["reason"].(string); ok {
+				return reason
+			}
+		}
+	}
+	return ""
+}
+
+// CheckHook creates the hook of the benchmark job (benchmark hook if jobName is empty) if not exists,
+// return true if it is finished (or not set), error if it failed or did not complete within the timeout
+func CheckHook(dyn dynamic.Interface, benchmark *cpev1.Benchmark, hook string, jobName string) (bool, error) {
+	if getHookTemplate(benchmark, hook) == "" {
+		return true, nil
+	}
+	var hashItem *cpev1.IterationHash
+	if jobName != "" {
+		item, found := getHashItem(benchmark, jobName)
+		if !found {
+			return false, &HookError{Hook: hook, JobName: jobName, Err: fmt.Errorf("no hash")}
+		}
+		hashItem = &item
+	}
+	hookErr := func(err error) error {
+		return &HookError{Hook: hook, JobName: jobName, Err: err}
+	}
+	hookJob, err := GetHookJob(benchmark, hook, hashItem)
+	if err != nil {
+		return false, hookErr(err)
+	}
+	dr := dyn.Resource(hookJobGVR).Namespace(benchmark.Namespace)
+	current, err := dr.Get(context.TODO(), hookJob.GetName(), metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		current, err = dr.Create(context.TODO(), hookJob, metav1.CreateOptions{})
+		if k8serrors.IsAlreadyExists(err) {
+			return false, nil
+		}
+	}
+	if isRejected(err) {
+		return false, hookErr(err)
+	}
+	if err != nil {
+		return false, err
+	}
+	finished, succeeded := IsHookJobFinished(current)
+	if !finished {
+		return false, nil
+	}
+	if !succeeded {
+		message := hookJob.GetName() + " failed"
+		if reason := getFailedReason(current); reason != "" {
+			message += ": " + reason
+		}
+		return false, hookErr(errors.New(message))
+	}
+	return true, nil
+}
+
+// AddFailedJob adds the benchmark job of the hook error to failedJobs, return false if already added
+func AddFailedJob(benchmark *cpev1.Benchmark, hookErr *HookError) bool {
+	for _, failedJob := range benchmark.Status.FailedJobs {
+		if failedJob.JobName == hookErr.JobName && failedJob.Hook == hookErr.Hook {
+			return false
+		}
+	}
+	failedJob := cpev1.FailedJob{
+		JobName: hookErr.JobName,
+		Hook:    hookErr.Hook,
+		Message: hookErr.Err.Error(),
+	}
+	if hookErr.JobName != "" {
+		_, _, _, failedJob.Repetition, failedJob.BuildID, failedJob.IterationID, failedJob.ConfigurationID = GetDetailFromJobName(hookErr.JobName, benchmark)
+	}
+	benchmark.Status.FailedJobs = append(benchmark.Status.FailedJobs, failedJob)
+	return true
+}
+
+// IsJobFailed returns true if a hook of the benchmark job failed
+func IsJobFailed(benchmark *cpev1.Benchmark, jobName string) bool {
+	for _, failedJob := range benchmark.Status.FailedJobs {
+		if failedJob.JobName == jobName {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordHookFailure records the failed job if err is a hook error, return true if recorded
+func RecordHookFailure(c client.Client, recorder record.EventRecorder, benchmark *cpev1.Benchmark, err error) bool {
+	var hookErr *HookError
+	if !errors.As(err, &hookErr) {
+		return false
+	}
+	if !AddFailedJob(benchmark, hookErr) {
+		return true
+	}
+	if updateErr := c.Status().Update(context.Background(), benchmark); updateErr != nil {
+		return true
+	}
+	if recorder != nil {
+		recorder.Event(benchmark, corev1.EventTypeWarning, getFailureReason(hookErr.Hook), hookErr.Error())
+	}
+	return true
+}
+
+// getFailureReason returns event reason of the failed hook or preparation step
+func getFailureReason(hook string) string {
+	switch hook {
+	case HOOK_PRE_RUN, HOOK_POST_RUN, HOOK_BENCHMARK_PRE_RUN, HOOK_BENCHMARK_POST_RUN:
+		return "HookFailed"
+	}
+	return "PreparationFailed"
+}
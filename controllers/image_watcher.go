@@ -0,0 +1,208 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+
+package controllers
+
+///////////////////////////////////////////////////////////////////////////
+//
+// image_watcher.go
+//
+// - Poll image repository of trackBuildConfigs with kind ImageRepository
+//   every pollIntervalSeconds via registry v2 API (see registry.go)
+// - Put newly seen tags (or digests if trackDigest is set) to the status list
+//   of the benchmark as builds (further handled by benchmark controller)
+// - Tags found at the first poll are recorded as seen without triggering builds
+//
+////////////////////////////////////////////////////////////////////////////
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	IMAGE_REPOSITORY_KIND      = "ImageRepository"
+	IMAGE_POLL_PERIOD          = 30 * time.Second
+	DEFAULT_IMAGE_POLL_SECONDS = 300
+)
+
+func getImageWithoutTag(image string) string {
+	image = strings.Split(image, "@")[0]
+	lastSlash := strings.LastIndex(image, "/")
+	if colon := strings.LastIndex(image, ":"); colon > lastSlash {
+		image = image[:colon]
+	}
+	return image
+}
+
+func getSeenImageTags(benchmark *cpev1.Benchmark, configName string) ([]string, bool) {
+	for _, seenTags := range benchmark.Status.ImageTags {
+		if seenTags.Config == configName {
+			return seenTags.Tags, true
+		}
+	}
+	return nil, false
+}
+
+func setSeenImageTags(benchmark *cpev1.Benchmark, configName string, tags []string) {
+	for index, seenTags := range benchmark.Status.ImageTags {
+		if seenTags.Config == configName {
+			benchmark.Status.ImageTags[index].Tags = tags
+			return
+		}
+	}
+	benchmark.Status.ImageTags = append(benchmark.Status.ImageTags, cpev1.SeenImageTags{Config: configName, Tags: tags})
+}
+
+// GetNewImageBuilds returns builds of newly seen tags and the keys (tag or tag@digest) of all current tags
+func GetNewImageBuilds(benchmark *cpev1.Benchmark, configSpec cpev1.ConfigSpec, tags []string, digests map[string]string) ([]cpev1.TrackedBuild, []string) {
+	seenTags, polled := getSeenImageTags(benchmark, configSpec.Name)
+	seenMap := make(map[string]bool)
+	for _, key := range seenTags {
+		seenMap[key] = true
+	}
+	image := getImageWithoutTag(configSpec.Image)
+	var trackedBuilds []cpev1.TrackedBuild
+	var keys []string
+	for _, tag := range tags {
+		key := tag
+		name := image + ":" + tag
+		if configSpec.TrackDigest && digests[tag] != "" {
+			key = tag + "@" + digests[tag]
+			name = image + "@" + digests[tag]
+		}
+		keys = append(keys, key)
+		if !polled || seenMap[key] {
+			continue
+		}
+		trackedBuilds = append(trackedBuilds, cpev1.TrackedBuild{
+			Name:   name,
+			Kind:   IMAGE_REPOSITORY_KIND,
+			Config: configSpec.Name,
+			Image:  image + ":" + tag,
+			Digest: digests[tag],
+		})
+	}
+	return trackedBuilds, keys
+}
+
+func (r *BuildWatcher) getRegistryClient(benchmark *cpev1.Benchmark, configSpec cpev1.ConfigSpec) (*RegistryClient, string, error) {
+	registry, repository := ParseImageRepository(configSpec.Image)
+	username, password := "", ""
+	if configSpec.Secret != "" {
+		namespace := configSpec.Namespace
+		if namespace == "" {
+			namespace = benchmark.Namespace
+		}
+		// read directly not to cache all secrets of the cluster
+		secret, err := r.Clientset.CoreV1().Secrets(namespace).Get(context.Background(), configSpec.Secret, metav1.GetOptions{})
+		if err != nil {
+			return nil, "", err
+		}
+		username, password = GetRegistryCredential(secret, registry)
+	}
+	return NewRegistryClient(registry, username, password, configSpec.Insecure), repository, nil
+}
+
+func (r *BuildWatcher) pollImageRepository(benchmark *cpev1.Benchmark, configSpec cpev1.ConfigSpec) ([]cpev1.TrackedBuild, []string, error) {
+	registryClient, repository, err := r.getRegistryClient(benchmark, configSpec)
+	if err != nil {
+		return nil, nil, err
+	}
+	tags, err := registryClient.ListTags(repository)
+	if err != nil {
+		return nil, nil, err
+	}
+	tags, err = FilterImageTags(tags, configSpec.TagFilter, configSpec.Semver)
+	if err != nil {
+		return nil, nil, err
+	}
+	digests := make(map[string]string)
+	if configSpec.TrackDigest {
+		for _, tag := range tags {
+			digest, err := registryClient.GetDigest(repository, tag)
+			if err != nil {
+				r.Log.Info(fmt.Sprintf("Cannot get digest of %s:%s: %v", configSpec.Image, tag, err))
+				continue
+			}
+			digests[tag] = digest
+		}
+	}
+	trackedBuilds, keys := GetNewImageBuilds(benchmark, configSpec, tags, digests)
+	return trackedBuilds, keys, nil
+}
+
+func (r *BuildWatcher) isPollDue(key string, configSpec cpev1.ConfigSpec) bool {
+	interval := time.Duration(configSpec.PollIntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = DEFAULT_IMAGE_POLL_SECONDS * time.Second
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.lastPolled == nil {
+		r.lastPolled = make(map[string]time.Time)
+	}
+	if lastPolled, ok := r.lastPolled[key]; ok && time.Since(lastPolled) < interval {
+		return false
+	}
+	r.lastPolled[key] = time.Now()
+	return true
+}
+
+func (r *BuildWatcher) RunImagePoller() {
+	wait.Until(r.PollImageRepositories, IMAGE_POLL_PERIOD, r.Quit)
+}
+
+func (r *BuildWatcher) PollImageRepositories() {
+	benchmarkList := &cpev1.BenchmarkList{}
+	r.Client.List(context.Background(), benchmarkList)
+
+	for _, benchmark := range benchmarkList.Items {
+		updated := false
+		var newBuilds []cpev1.TrackedBuild
+		for _, configSpec := range benchmark.Spec.BuildConfigs {
+			if configSpec.Kind != IMAGE_REPOSITORY_KIND || configSpec.Image == "" {
+				continue
+			}
+			key := fmt.Sprintf("%s/%s/%s", benchmark.Namespace, benchmark.Name, configSpec.Name)
+			if !r.isPollDue(key, configSpec) {
+				continue
+			}
+			trackedBuilds, keys, err := r.pollImageRepository(&benchmark, configSpec)
+			if err != nil {
+				r.Log.Info(fmt.Sprintf("Cannot poll %s: %v", configSpec.Image, err))
+				continue
+			}
+			seenTags, _ := getSeenImageTags(&benchmark, configSpec.Name)
+			if strings.Join(seenTags, ",") != strings.Join(keys, ",") {
+				setSeenImageTags(&benchmark, configSpec.Name, keys)
+				updated = true
+			}
+			newBuilds = append(newBuilds, trackedBuilds...)
+		}
+		for _, trackedBuild := range newBuilds {
+			if isBuildTracked(&benchmark, trackedBuild.Name) {
+				continue
+			}
+			r.Log.Info(fmt.Sprintf("New image %s for %s", trackedBuild.Name, benchmark.Name))
+			benchmark.Status.TrackedBuilds = append(benchmark.Status.TrackedBuilds, trackedBuild.Name)
+			benchmark.Status.BuildDetails = append(benchmark.Status.BuildDetails, trackedBuild)
+			updated = true
+		}
+		if updated {
+			// this update will be further watched by benchmark_controller
+			err := r.Client.Status().Update(context.Background(), &benchmark)
+			if err != nil {
+				r.Log.Info(fmt.Sprintf("Cannot update #%v ", err))
+			}
+		}
+	}
+}
@@ -0,0 +1,505 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+
+package controllers
+
+///////////////////////////////////////////////////////////////////////////
+//
+// iteration_location.go
+//
+// SetValue
+// - set iterated value at the location of the item
+//   /a/b/0: JSON Pointer (RFC 6901) with JSON Patch op (add, replace, remove),
+//   parent must exist unless createParents is set
+//   $.a.b[0], $.a[*].b, $.a[?(@.name=='X')].b: JSONPath selector (all matched nodes)
+//   otherwise: dotted location (see iteration.go UpdateValue)
+// GetTypedValue
+// - convert value by type: string, int, float, bool, yaml (object, list, or scalar)
+//   (default: type of the current value at the location, otherwise string)
+// GetLocationValues
+// - get values at JSON Pointer or JSONPath location (used by generic adaptor)
+// ValidateDottedLocation
+// - reject location not in dotted notation where only dotted notation is supported
+//
+////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	LOCATION_OP_ADD     = "add"
+	LOCATION_OP_REPLACE = "replace"
+	LOCATION_OP_REMOVE  = "remove"
+	VALUE_TYPE_STRING   = "string"
+	VALUE_TYPE_INT      = "int"
+	VALUE_TYPE_FLOAT    = "float"
+	VALUE_TYPE_BOOL     = "bool"
+	VALUE_TYPE_YAML     = "yaml"
+	JSON_POINTER_PREFIX = "/"
+	JSON_PATH_PREFIX    = "$"
+	JSON_POINTER_APPEND = "-"
+)
+
+// GetTypedValue converts the value by the type, current is the value at the location (nil if not exists)
+func GetTypedValue(value string, valueType string, current interface{}) (interface{}, error) {
+	switch valueType {
+	case "":
+		if current == nil {
+			return value, nil
+		}
+		return itrHandler.getValueInterface(reflect.TypeOf(current), value), nil
+	case VALUE_TYPE_STRING:
+		return value, nil
+	case VALUE_TYPE_INT:
+		return strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	case VALUE_TYPE_FLOAT:
+		return strconv.ParseFloat(strings.TrimSpace(value), 64)
+	case VALUE_TYPE_BOOL:
+		return strconv.ParseBool(strings.TrimSpace(value))
+	case VALUE_TYPE_YAML:
+		jsonBytes, err := yaml.YAMLToJSON([]byte(value))
+		if err != nil {
+			return nil, err
+		}
+		// keep integers as int64 in unstructured object
+		wrapped := make(map[string]interface{})
+		if err = utiljson.Unmarshal([]byte(`{"value":`+string(jsonBytes)+`}`), &wrapped); err != nil {
+			return nil, err
+		}
+		return wrapped["value"], nil
+	}
+	return nil, fmt.Errorf("unknown value type %s", valueType)
+}
+
+// SetValue returns the object with the iterated value of the item set at its location
+// ValidateDottedLocation returns error if non-empty location is not in dotted notation (.a.b)
+func ValidateDottedLocation(location string) error {
+	if location == "" || (strings.HasPrefix(location, ".") && len(location) > 1) {
+		return nil
+	}
+	return fmt.Errorf("location %q must be in dotted notation starting with '.' (e.g. .template.spec)", location)
+}
+
+func (it *IterationHandler) SetValue(baseObject map[string]interface{}, item cpev1.IterationItem, value string) (map[string]interface{}, error) {
+	if item.Location == "" || value == NULL_VALUE_STR {
+		return baseObject, nil
+	}
+	op := item.Op
+	if op == "" {
+		op = LOCATION_OP_ADD
+	}
+	if op != LOCATION_OP_ADD && op != LOCATION_OP_REPLACE && op != LOCATION_OP_REMOVE {
+		return nil, fmt.Errorf("unknown op %s", op)
+	}
+	var err error
+	switch {
+	case strings.HasPrefix(item.Location, JSON_POINTER_PREFIX):
+		err = setJSONPointer(baseObject, item.Location, op, value, item.Type, item.CreateParents)
+	case strings.HasPrefix(item.Location, JSON_PATH_PREFIX):
+		err = setJSONPath(baseObject, item.Location, op, value, item.Type, item.CreateParents)
+	default:
+		if op != LOCATION_OP_ADD || item.Type != "" || item.CreateParents {
+			return nil, fmt.Errorf("op, type, and createParents require JSON Pointer or JSONPath location")
+		}
+		return it.UpdateValue(baseObject, item.Location, value), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", item.Location, err)
+	}
+	return baseObject, nil
+}
+
+// GetJSONPointerTokens returns reference tokens of the JSON Pointer
+func GetJSONPointerTokens(pointer string) ([]string, error) {
+	if !strings.HasPrefix(pointer, JSON_POINTER_PREFIX) {
+		return nil, fmt.Errorf("JSON Pointer must start with /")
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for index, token := range tokens {
+		tokens[index] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func newContainer(nextToken string) interface{} {
+	if nextToken == JSON_POINTER_APPEND {
+		return []interface{}{}
+	}
+	if _, err := strconv.Atoi(nextToken); err == nil {
+		return []interface{}{}
+	}
+	return make(map[string]interface{})
+}
+
+// setJSONPointer applies JSON Patch operation at the pointer, missing parents are created by add only if createParents is set
+func setJSONPointer(object map[string]interface{}, pointer string, op string, value string, valueType string, createParents bool) error {
+	tokens, err := GetJSONPointerTokens(pointer)
+	if err != nil {
+		return err
+	}
+	_, err = patchNode(object, tokens, op, value, valueType, createParents)
+	return err
+}
+
+// patchNode returns the node with the operation applied at the tokens
+func patchNode(node interface{}, tokens []string, op string, value string, valueType string, createParents bool) (interface{}, error) {
+	token := tokens[0]
+	last := len(tokens) == 1
+	switch typedNode := node.(type) {
+	case map[string]interface{}:
+		child, exists := typedNode[token]
+		if last {
+			if op == LOCATION_OP_REMOVE {
+				delete(typedNode, token)
+				return typedNode, nil
+			}
+			if op == LOCATION_OP_REPLACE && !exists {
+				return nil, fmt.Errorf("no value to replace at %s", token)
+			}
+			typedValue, err := GetTypedValue(value, valueType, child)
+			if err != nil {
+				return nil, err
+			}
+			typedNode[token] = typedValue
+			return typedNode, nil
+		}
+		if !exists || child == nil {
+			if op != LOCATION_OP_ADD || !createParents {
+				return nil, fmt.Errorf("%s not found", token)
+			}
+			child = newContainer(tokens[1])
+		}
+		newChild, err := patchNode(child, tokens[1:], op, value, valueType, createParents)
+		if err != nil {
+			return nil, err
+		}
+		typedNode[token] = newChild
+		return typedNode, nil
+	case []interface{}:
+		position := len(typedNode)
+		if token != JSON_POINTER_APPEND {
+			var err error
+			if position, err = strconv.Atoi(token); err != nil || position < 0 || position > len(typedNode) {
+				return nil, fmt.Errorf("invalid index %s", token)
+			}
+		}
+		exists := position < len(typedNode)
+		if last {
+			switch op {
+			case LOCATION_OP_REMOVE:
+				if !exists {
+					return typedNode, nil
+				}
+				return append(append([]interface{}{}, typedNode[:position]...), typedNode[position+1:]...), nil
+			case LOCATION_OP_REPLACE:
+				if !exists {
+					return nil, fmt.Errorf("no value to replace at %s", token)
+				}
+				typedValue, err := GetTypedValue(value, valueType, typedNode[position])
+				if err != nil {
+					return nil, err
+				}
+				typedNode[position] = typedValue
+				return typedNode, nil
+			}
+			// add inserts into the array
+			var current interface{}
+			if exists {
+				current = typedNode[position]
+			}
+			typedValue, err := GetTypedValue(value, valueType, current)
+			if err != nil {
+				return nil, err
+			}
+			inserted := append(append([]interface{}{}, typedNode[:position]...), typedValue)
+			return append(inserted, typedNode[position:]...), nil
+		}
+		if !exists {
+			if op != LOCATION_OP_ADD || !createParents {
+				return nil, fmt.Errorf("%s not found", token)
+			}
+			newChild, err := patchNode(newContainer(tokens[1]), tokens[1:], op, value, valueType, createParents)
+			if err != nil {
+				return nil, err
+			}
+			return append(typedNode, newChild), nil
+		}
+		newChild, err := patchNode(typedNode[position], tokens[1:], op, value, valueType, createParents)
+		if err != nil {
+			return nil, err
+		}
+		typedNode[position] = newChild
+		return typedNode, nil
+	}
+	return nil, fmt.Errorf("parent of %s is not an object or array", token)
+}
+
+type jsonPathStep struct {
+	name        string
+	index       int
+	wildcard    bool
+	filterKey   string
+	filterValue string
+}
+
+// GetJSONPathSteps parses JSONPath selector (child, index, wildcard, and equality filter)
+func GetJSONPathSteps(path string) ([]jsonPathStep, error) {
+	if !strings.HasPrefix(path, JSON_PATH_PREFIX) {
+		return nil, fmt.Errorf("JSONPath must start with $")
+	}
+	var steps []jsonPathStep
+	rest := path[1:]
+	for len(rest) > 0 {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end == -1 {
+				end = len(rest) - 1
+			}
+			name := rest[1 : end+1]
+			if name == "" {
+				return nil, fmt.Errorf("empty name in %s", path)
+			}
+			steps = append(steps, jsonPathStep{name: name, index: -1, wildcard: name == "*"})
+			rest = rest[end+1:]
+		case '[':
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("unclosed bracket in %s", path)
+			}
+			selector := rest[1:end]
+			if strings.HasPrefix(selector, "?(") {
+				// filter may contain ]
+				end = strings.Index(rest, ")]")
+				if end == -1 {
+					return nil, fmt.Errorf("unclosed filter in %s", path)
+				}
+				selector = rest[1 : end+1]
+				end++
+			}
+			step, err := getJSONPathBracketStep(selector)
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, step)
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("invalid JSONPath %s", path)
+		}
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("no selector in %s", path)
+	}
+	return steps, nil
+}
+
+func getJSONPathBracketStep(selector string) (jsonPathStep, error) {
+	switch {
+	case selector == "*":
+		return jsonPathStep{index: -1, wildcard: true}, nil
+	case strings.HasPrefix(selector, "?(@.") && strings.HasSuffix(selector, ")"):
+		condition := strings.SplitN(selector[4:len(selector)-1], "==", 2)
+		if len(condition) != 2 {
+			return jsonPathStep{}, fmt.Errorf("only == filter is supported: %s", selector)
+		}
+		return jsonPathStep{
+			index:       -1,
+			filterKey:   strings.TrimSpace(condition[0]),
+			filterValue: strings.Trim(strings.TrimSpace(condition[1]), `'"`),
+		}, nil
+	case strings.HasPrefix(selector, "'") || strings.HasPrefix(selector, `"`):
+		return jsonPathStep{name: strings.Trim(selector, `'"`), index: -1}, nil
+	}
+	index, err := strconv.Atoi(selector)
+	if err != nil || index < 0 {
+		return jsonPathStep{}, fmt.Errorf("invalid selector [%s]", selector)
+	}
+	return jsonPathStep{index: index}, nil
+}
+
+// setJSONPath applies the operation to all nodes matched by the selector, missing children are created by add only if createParents is set
+func setJSONPath(object map[string]interface{}, path string, op string, value string, valueType string, createParents bool) error {
+	steps, err := GetJSONPathSteps(path)
+	if err != nil {
+		return err
+	}
+	_, matched, err := patchMatchedNodes(object, steps, op, value, valueType, createParents)
+	if err == nil && matched == 0 && op != LOCATION_OP_REMOVE {
+		err = fmt.Errorf("no node matched")
+	}
+	return err
+}
+
+func (step jsonPathStep) selectIndex(index int, child interface{}) bool {
+	if step.filterKey != "" {
+		childMap, ok := child.(map[string]interface{})
+		return ok && childMap[step.filterKey] != nil && fmt.Sprintf("%v", childMap[step.filterKey]) == step.filterValue
+	}
+	return step.wildcard || (step.name == "" && step.index == index)
+}
+
+// patchMatchedNodes returns the node with the operation applied to the matched nodes and number of them
+func patchMatchedNodes(node interface{}, steps []jsonPathStep, op string, value string, valueType string, createParents bool) (interface{}, int, error) {
+	step := steps[0]
+	last := len(steps) == 1
+	matched := 0
+	switch typedNode := node.(type) {
+	case map[string]interface{}:
+		var keys []string
+		if step.wildcard {
+			for key := range typedNode {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+		} else if step.name != "" {
+			keys = []string{step.name}
+		}
+		for _, key := range keys {
+			child, exists := typedNode[key]
+			if last {
+				if op == LOCATION_OP_REMOVE {
+					if exists {
+						delete(typedNode, key)
+						matched++
+					}
+					continue
+				}
+				if op == LOCATION_OP_REPLACE && !exists {
+					continue
+				}
+				typedValue, err := GetTypedValue(value, valueType, child)
+				if err != nil {
+					return nil, matched, err
+				}
+				typedNode[key] = typedValue
+				matched++
+				continue
+			}
+			if !exists || child == nil {
+				// create only the named child of the named child
+				if op != LOCATION_OP_ADD || !createParents || step.wildcard || steps[1].name == "" || steps[1].wildcard {
+					continue
+				}
+				child = make(map[string]interface{})
+			}
+			newChild, count, err := patchMatchedNodes(child, steps[1:], op, value, valueType, createParents)
+			if err != nil {
+				return nil, matched, err
+			}
+			if count > 0 {
+				typedNode[key] = newChild
+				matched += count
+			}
+		}
+		return typedNode, matched, nil
+	case []interface{}:
+		var items []interface{}
+		for index, child := range typedNode {
+			if !step.selectIndex(index, child) {
+				items = append(items, child)
+				continue
+			}
+			if last {
+				matched++
+				if op == LOCATION_OP_REMOVE {
+					continue
+				}
+				typedValue, err := GetTypedValue(value, valueType, child)
+				if err != nil {
+					return nil, matched, err
+				}
+				items = append(items, typedValue)
+				continue
+			}
+			newChild, count, err := patchMatchedNodes(child, steps[1:], op, value, valueType, createParents)
+			if err != nil {
+				return nil, matched, err
+			}
+			matched += count
+			items = append(items, newChild)
+		}
+		if items == nil {
+			items = []interface{}{}
+		}
+		return items, matched, nil
+	}
+	return node, 0, nil
+}
+
+// GetLocationValues returns values at the JSON Pointer or all values matched by the JSONPath selector
+func GetLocationValues(object map[string]interface{}, location string) ([]interface{}, error) {
+	if strings.HasPrefix(location, JSON_POINTER_PREFIX) {
+		tokens, err := GetJSONPointerTokens(location)
+		if err != nil {
+			return nil, err
+		}
+		var node interface{} = object
+		for _, token := range tokens {
+			switch typedNode := node.(type) {
+			case map[string]interface{}:
+				node = typedNode[token]
+			case []interface{}:
+				index, err := strconv.Atoi(token)
+				if err != nil || index < 0 || index >= len(typedNode) {
+					return nil, nil
+				}
+				node = typedNode[index]
+			default:
+				return nil, nil
+			}
+		}
+		if node == nil {
+			return nil, nil
+		}
+		return []interface{}{node}, nil
+	}
+	steps, err := GetJSONPathSteps(location)
+	if err != nil {
+		return nil, err
+	}
+	return getMatchedNodes(object, steps), nil
+}
+
+func getMatchedNodes(node interface{}, steps []jsonPathStep) []interface{} {
+	if len(steps) == 0 {
+		if node == nil {
+			return nil
+		}
+		return []interface{}{node}
+	}
+	step := steps[0]
+	var matchedNodes []interface{}
+	switch typedNode := node.(type) {
+	case map[string]interface{}:
+		if step.wildcard {
+			var keys []string
+			for key := range typedNode {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				matchedNodes = append(matchedNodes, getMatchedNodes(typedNode[key], steps[1:])...)
+			}
+		} else if step.name != "" {
+			matchedNodes = getMatchedNodes(typedNode[step.name], steps[1:])
+		}
+	case []interface{}:
+		for index, child := range typedNode {
+			if step.selectIndex(index, child) {
+				matchedNodes = append(matchedNodes, getMatchedNodes(child, steps[1:])...)
+			}
+		}
+	}
+	return matchedNodes
+}
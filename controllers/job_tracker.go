@@ -11,11 +11,17 @@ package controllers
 //
 // JobTrackManager manages (add/delete) JobTracker component for each job resource
 //
-// JobTracker watch update on job resource to check completeness (refers to adaptor)
+// JobTracker watch update on job resource to check completeness (refers to adaptor of the operator each benchmark subscribed with)
+//  - mutex guards the waiting/planned maps shared by the reconciler and the tracker goroutine
 //	- putLog - put the log of completed pods to the COS
 //  - parseAndPush - call parser to parse and push the prometheus-format metric to push gateway
 //  - updateBenchmarkStatus - update results to benchmark and find best result
 //  - deployWaitingResource - deploy iterated job resource in the waiting list
+//  - ProcessProgress - advance the benchmark without blocking on the completion event of its hook Job or a re-check timer
+//    finishJobs: postRun hook, clean-up, and teardown of done jobs (the next job waits for them)
+//    pending jobs: create jobs waiting for their preparation (hooks, scenario resources, etc.) once ready
+//    (held while the node reservation cannot be renewed)
+//    finalize: benchmarkPostRun hook and release of nodes, system under test, and helm iteration once all jobs are done
 //
 ////////////////////////////////////////////////////////////////////////////
 
@@ -27,17 +33,22 @@ import (
 	"io/ioutil"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	cpev1 "github.com/IBM/cpe-operator/api/v1"
@@ -54,6 +65,8 @@ type JobTrackManager struct {
 	Log         logr.Logger
 	DC          *discovery.DiscoveryClient
 	DYN         dynamic.Interface
+	Reserver    *NodeReserver
+	Recorder    record.EventRecorder
 	*TunedHandler
 }
 
@@ -70,28 +83,37 @@ func (m *JobTrackManager) NewTracker(jobGVK schema.GroupVersionKind, benchmarkNa
 		var subscribers []string
 
 		m.JobTrackers[jobGVKString] = &JobTracker{
-			Client:         m.Client,
-			Clientset:      m.Clientset,
-			Log:            m.Log,
-			DC:             m.DC,
-			DYN:            m.DYN,
-			JobQueue:       jobQueue,
-			Quit:           quit,
-			JobGVK:         jobGVK,
-			Cos:            m.Cos,
-			WaitingJobMap:  newJobMap,
-			DRMap:          newDRMap,
-			Adaptor:        adaptor,
-			TunedHandler:   m.TunedHandler,
-			Subscribers:    subscribers,
-			JobOptMap:      make(map[string]*BaysesOptimizer),
-			BestPodNameMap: make(map[string]string),
+			Client:          m.Client,
+			Clientset:       m.Clientset,
+			Log:             m.Log,
+			DC:              m.DC,
+			DYN:             m.DYN,
+			JobQueue:        jobQueue,
+			Quit:            quit,
+			JobGVK:          jobGVK,
+			Cos:             m.Cos,
+			WaitingJobMap:   newJobMap,
+			DRMap:           newDRMap,
+			Adaptor:         adaptor,
+			AdaptorMap:      make(map[string]OperatorAdaptor),
+			TunedHandler:    m.TunedHandler,
+			Reserver:        m.Reserver,
+			Recorder:        m.Recorder,
+			Subscribers:     subscribers,
+			JobOptMap:       make(map[string]*BaysesOptimizer),
+			BestPodNameMap:  make(map[string]string),
+			BestNodeNameMap: make(map[string]string),
+			PlannedBuildMap: make(map[string][]string),
+			PendingJobMap:   make(map[string][]PendingJob),
+			FinishingJobMap: make(map[string][]FinishingJob),
+			ProgressQueue:   make(chan types.NamespacedName, JOB_MAX_QSIZE),
+			requeued:        make(map[string]bool),
 		}
 
 		m.JobTrackers[jobGVKString].Init()
 		go m.JobTrackers[jobGVKString].Run()
 	}
-	m.JobTrackers[jobGVKString].Subscribe(benchmarkName, waitingJob, dr, jobOptMap)
+	m.JobTrackers[jobGVKString].Subscribe(benchmarkName, waitingJob, dr, adaptor, jobOptMap)
 }
 
 func (m *JobTrackManager) IsExist(jobGVK schema.GroupVersionKind, benchmarkName string) bool {
@@ -102,6 +124,116 @@ func (m *JobTrackManager) IsExist(jobGVK schema.GroupVersionKind, benchmarkName
 	return m.JobTrackers[jobGVKString].IsExist(benchmarkName)
 }
 
+// GetPlannedBuilds returns builds whose jobs have been created or put in the waiting list
+func (m *JobTrackManager) GetPlannedBuilds(jobGVK schema.GroupVersionKind, benchmarkName string) []string {
+	if !m.IsExist(jobGVK, benchmarkName) {
+		return []string{}
+	}
+	tracker := m.JobTrackers[jobGVK.String()]
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	return tracker.PlannedBuildMap[benchmarkName]
+}
+
+func (m *JobTrackManager) SetPlannedBuilds(jobGVK schema.GroupVersionKind, benchmarkName string, builds []string) {
+	if m.IsExist(jobGVK, benchmarkName) {
+		tracker := m.JobTrackers[jobGVK.String()]
+		tracker.mutex.Lock()
+		defer tracker.mutex.Unlock()
+		tracker.PlannedBuildMap[benchmarkName] = builds
+	}
+}
+
+// HasWaitingJob returns true if the benchmark has a job waiting for the running one
+func (m *JobTrackManager) HasWaitingJob(jobGVK schema.GroupVersionKind, benchmarkName string) bool {
+	if !m.IsExist(jobGVK, benchmarkName) {
+		return false
+	}
+	tracker := m.JobTrackers[jobGVK.String()]
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	if len(tracker.WaitingJobMap[benchmarkName]) > 0 {
+		return true
+	}
+	for _, pending := range tracker.PendingJobMap[benchmarkName] {
+		if pending.Sequential {
+			return true
+		}
+	}
+	return false
+}
+
+// AddPendingJob adds jobs waiting for their preparation to be created by the tracker once ready
+func (m *JobTrackManager) AddPendingJob(jobGVK schema.GroupVersionKind, benchmarkName string, pendingJobs []PendingJob) {
+	if !m.IsExist(jobGVK, benchmarkName) || len(pendingJobs) == 0 {
+		return
+	}
+	tracker := m.JobTrackers[jobGVK.String()]
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	for _, pending := range pendingJobs {
+		tracker.addPendingJob(benchmarkName, pending)
+	}
+}
+
+// RequestProgress requests the tracker to advance the benchmark
+func (m *JobTrackManager) RequestProgress(jobGVK schema.GroupVersionKind, benchmark *cpev1.Benchmark) {
+	if !m.IsExist(jobGVK, benchmark.GetName()) {
+		return
+	}
+	m.JobTrackers[jobGVK.String()].requestProgress(types.NamespacedName{Name: benchmark.GetName(), Namespace: benchmark.GetNamespace()})
+}
+
+// AddWaitingJob appends jobs of newly tracked builds to the waiting list of subscribed benchmark
+func (m *JobTrackManager) AddWaitingJob(jobGVK schema.GroupVersionKind, benchmarkName string, waitingJob []*unstructured.Unstructured, dr dynamic.ResourceInterface, jobOptMap map[string]*BaysesOptimizer) {
+	if !m.IsExist(jobGVK, benchmarkName) || len(waitingJob) == 0 {
+		return
+	}
+	tracker := m.JobTrackers[jobGVK.String()]
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	tracker.WaitingJobMap[benchmarkName] = append(tracker.WaitingJobMap[benchmarkName], waitingJob...)
+	tracker.DRMap[benchmarkName] = dr
+	for k, v := range jobOptMap {
+		tracker.JobOptMap[k] = v
+	}
+	m.Log.Info(fmt.Sprintf("%s: add %d waiting jobs (%d wait)", benchmarkName, len(waitingJob), len(tracker.WaitingJobMap[benchmarkName])))
+}
+
+// PruneWaitingJob removes waiting jobs of builds that are no longer retained
+func (m *JobTrackManager) PruneWaitingJob(jobGVK schema.GroupVersionKind, benchmarkName string, builds []string) {
+	if !m.IsExist(jobGVK, benchmarkName) {
+		return
+	}
+	tracker := m.JobTrackers[jobGVK.String()]
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	buildLabels := make(map[string]bool)
+	for _, build := range builds {
+		buildLabels[getValidValue(build)] = true
+	}
+	var waitingJob []*unstructured.Unstructured
+	for _, job := range tracker.WaitingJobMap[benchmarkName] {
+		if buildLabels[job.GetLabels()[BUILD_KEY]] {
+			waitingJob = append(waitingJob, job)
+		} else {
+			delete(tracker.JobOptMap, job.GetName())
+		}
+	}
+	if len(waitingJob) == 0 {
+		delete(tracker.WaitingJobMap, benchmarkName)
+	} else {
+		tracker.WaitingJobMap[benchmarkName] = waitingJob
+	}
+	var plannedBuilds []string
+	for _, build := range tracker.PlannedBuildMap[benchmarkName] {
+		if buildLabels[getValidValue(build)] {
+			plannedBuilds = append(plannedBuilds, build)
+		}
+	}
+	tracker.PlannedBuildMap[benchmarkName] = plannedBuilds
+}
+
 func (m *JobTrackManager) DeleteTracker(jobGVK schema.GroupVersionKind, benchmarkName string) {
 	jobGVKString := jobGVK.String()
 
@@ -131,31 +263,267 @@ func (m *JobTrackManager) Run() {
 	}
 }
 
+// PendingJob is a job not created yet as waiting for its preparation
+type PendingJob struct {
+	Job        *unstructured.Unstructured
+	DR         dynamic.ResourceInterface
+	Sequential bool
+}
+
+// FinishingJob is a done job of which postRun hook and teardown are in progress
+type FinishingJob struct {
+	Job      *unstructured.Unstructured
+	TearDown bool
+	Deleting bool
+}
+
 type JobTracker struct {
 	client.Client
 	*kubernetes.Clientset
-	Log            logr.Logger
-	DC             *discovery.DiscoveryClient
-	DYN            dynamic.Interface
-	JobQueue       chan *unstructured.Unstructured
-	Quit           chan struct{}
-	JobGVK         schema.GroupVersionKind
-	Cos            COSObject
-	Subscribers    []string
-	WaitingJobMap  map[string][]*unstructured.Unstructured
-	DRMap          map[string]dynamic.ResourceInterface
-	Adaptor        OperatorAdaptor
-	JobOptMap      map[string]*BaysesOptimizer
-	BestPodNameMap map[string]string
+	Log             logr.Logger
+	DC              *discovery.DiscoveryClient
+	DYN             dynamic.Interface
+	JobQueue        chan *unstructured.Unstructured
+	Quit            chan struct{}
+	JobGVK          schema.GroupVersionKind
+	Cos             COSObject
+	Subscribers     []string
+	WaitingJobMap   map[string][]*unstructured.Unstructured
+	DRMap           map[string]dynamic.ResourceInterface
+	Adaptor         OperatorAdaptor
+	AdaptorMap      map[string]OperatorAdaptor
+	JobOptMap       map[string]*BaysesOptimizer
+	BestPodNameMap  map[string]string
+	BestNodeNameMap map[string]string
+	PlannedBuildMap map[string][]string
+	PendingJobMap   map[string][]PendingJob
+	FinishingJobMap map[string][]FinishingJob
+	ProgressQueue   chan types.NamespacedName
+	Reserver        *NodeReserver
+	Recorder        record.EventRecorder
 	*TunedHandler
+	mutex    sync.Mutex
+	requeued map[string]bool
 }
 
 func (r *JobTracker) Run() {
-	wait.Until(r.ProcessJobQueue, 0, r.Quit)
+	wait.Until(r.processQueue, 0, r.Quit)
 	close(r.JobQueue)
 }
 
+// processQueue handles either a done job or a benchmark to be advanced
+func (r *JobTracker) processQueue() {
+	select {
+	case job := <-r.JobQueue:
+		r.ProcessJobQueue(job)
+	case benchmarkKey := <-r.ProgressQueue:
+		r.ProcessProgress(benchmarkKey)
+	case <-r.Quit:
+	}
+}
+
+// requestProgress enqueues the benchmark to be advanced now without blocking the caller
+func (r *JobTracker) requestProgress(benchmarkKey types.NamespacedName) {
+	go func() {
+		select {
+		case r.ProgressQueue <- benchmarkKey:
+		case <-r.Quit:
+		}
+	}()
+}
+
+// requeue enqueues the benchmark to be advanced after READINESS_POLL_INTERVAL (once at a time)
+func (r *JobTracker) requeue(benchmarkKey types.NamespacedName) {
+	if r.requeued[benchmarkKey.Name] {
+		return
+	}
+	r.requeued[benchmarkKey.Name] = true
+	time.AfterFunc(READINESS_POLL_INTERVAL, func() {
+		select {
+		case r.ProgressQueue <- benchmarkKey:
+		case <-r.Quit:
+		}
+	})
+}
+
+func (r *JobTracker) addPendingJob(benchmarkName string, pending PendingJob) {
+	r.Log.Info(fmt.Sprintf("%s is waiting for its preparation", pending.Job.GetName()))
+	r.PendingJobMap[benchmarkName] = append(r.PendingJobMap[benchmarkName], pending)
+	r.requeue(types.NamespacedName{Name: benchmarkName, Namespace: pending.Job.GetNamespace()})
+}
+
+// isReservationLost returns true if the leases of the exclusive nodes cannot be renewed (jobs are held until renewed)
+func (r *JobTracker) isReservationLost(benchmark *cpev1.Benchmark) bool {
+	if benchmark.Spec.ExclusiveNodes == nil || r.Reserver == nil {
+		return false
+	}
+	if err := r.Reserver.GetRenewalError(benchmark); err != nil {
+		r.Log.Info(fmt.Sprintf("Hold jobs of %s: %v", benchmark.GetName(), err))
+		return true
+	}
+	return false
+}
+
+// startJob creates the job if its preparation is ready, otherwise keeps it pending,
+// return false if the preparation failed (the job is regarded as done)
+func (r *JobTracker) startJob(benchmark *cpev1.Benchmark, pending PendingJob) bool {
+	benchmarkName := benchmark.GetName()
+	// the next job waits for postRun hook and teardown of the done one
+	if pending.Sequential && len(r.FinishingJobMap[benchmarkName]) > 0 {
+		r.addPendingJob(benchmarkName, pending)
+		return true
+	}
+	if r.isReservationLost(benchmark) {
+		r.addPendingJob(benchmarkName, pending)
+		return true
+	}
+	nodeTunedOptimizer := r.JobOptMap[pending.Job.GetName()]
+	err, _ := CreateIfNotExists(r.DC, r.DYN, pending.DR, benchmark, pending.Job, r.getAdaptor(benchmarkName), r.TunedHandler, nodeTunedOptimizer)
+	if IsJobNotReady(err) {
+		if err != ErrJobNotReady {
+			r.Log.Info(fmt.Sprintf("Retry preparation of %s: %v", pending.Job.GetName(), err))
+		}
+		r.addPendingJob(benchmarkName, pending)
+		return true
+	}
+	if err != nil {
+		r.Log.Info(fmt.Sprintf("Cannot create #%v: %s", err, pending.Job.GetName()))
+	}
+	return !RecordHookFailure(r.Client, r.Recorder, benchmark, err)
+}
+
+// ProcessProgress advances the benchmark on the completion event of its hook Job or the re-check timer
+func (r *JobTracker) ProcessProgress(benchmarkKey types.NamespacedName) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.requeued[benchmarkKey.Name] = false
+	if index := r.indexOf(benchmarkKey.Name); index == -1 || index == len(r.Subscribers) {
+		return
+	}
+	benchmark := &cpev1.Benchmark{}
+	err := r.Client.Get(context.Background(), benchmarkKey, benchmark)
+	if err != nil {
+		r.Log.Info(fmt.Sprintf("Cannot get benchmark #%v ", err))
+		if !k8serrors.IsNotFound(err) {
+			r.requeue(benchmarkKey)
+		}
+		return
+	}
+	r.progress(benchmark)
+}
+
+// progress advances done jobs, creates pending jobs once ready, and finalizes the benchmark once all jobs are done
+func (r *JobTracker) progress(benchmark *cpev1.Benchmark) {
+	benchmarkName := benchmark.GetName()
+	r.finishJobs(benchmark)
+	pendingJobs := r.PendingJobMap[benchmarkName]
+	delete(r.PendingJobMap, benchmarkName)
+	for _, pending := range pendingJobs {
+		if !r.startJob(benchmark, pending) && pending.Sequential {
+			// the failed job never completes, continue to the next one
+			r.deployWaitingResource(pending.Job, benchmark)
+		}
+	}
+	if len(r.FinishingJobMap[benchmarkName]) == 0 && len(r.PendingJobMap[benchmarkName]) == 0 {
+		r.finalize(benchmark)
+	}
+}
+
+// finishJobs runs postRun hook, clean-up, and teardown of done jobs without waiting
+func (r *JobTracker) finishJobs(benchmark *cpev1.Benchmark) {
+	benchmarkName := benchmark.GetName()
+	var finishingJobs []FinishingJob
+	for _, finishing := range r.FinishingJobMap[benchmarkName] {
+		jobName := finishing.Job.GetName()
+		if !finishing.Deleting {
+			// postRun hook of the job before the next job
+			finished, err := CheckHook(r.DYN, benchmark, HOOK_POST_RUN, jobName)
+			if err != nil {
+				r.Log.Info(fmt.Sprintf("Failed %v", err))
+				if !RecordHookFailure(r.Client, r.Recorder, benchmark, err) {
+					// API error, check the hook again later
+					finishingJobs = append(finishingJobs, finishing)
+					r.requeue(types.NamespacedName{Name: benchmarkName, Namespace: benchmark.GetNamespace()})
+					continue
+				}
+			} else if !finished {
+				finishingJobs = append(finishingJobs, finishing)
+				continue
+			}
+			// delete resources created by the job resource (e.g., RayCluster of RayJob)
+			if err = r.getAdaptor(benchmarkName).CleanUp(finishing.Job.Object, r.DYN); err != nil {
+				r.Log.Info(fmt.Sprintf("Cannot clean up %s: %v", jobName, err))
+			}
+			if !finishing.TearDown {
+				continue
+			}
+			if hashItem, found := getHashItem(benchmark, jobName); found {
+				if err = DeleteScenarioResources(r.DC, r.DYN, benchmark, hashItem); err != nil {
+					r.Log.Info(fmt.Sprintf("Cannot delete resources of %s: %v", jobName, err))
+				}
+			}
+			finishing.Deleting = true
+		}
+		// the finished scenario must be torn down before the next one
+		if hashItem, found := getHashItem(benchmark, jobName); found {
+			deleted, err := IsScenarioResourcesDeleted(r.DC, r.DYN, benchmark, hashItem)
+			if err != nil {
+				r.Log.Info(fmt.Sprintf("Continue without teardown of %s: %v", jobName, err))
+			} else if !deleted {
+				finishingJobs = append(finishingJobs, finishing)
+				r.requeue(types.NamespacedName{Name: benchmarkName, Namespace: benchmark.GetNamespace()})
+			}
+		}
+	}
+	if len(finishingJobs) == 0 {
+		delete(r.FinishingJobMap, benchmarkName)
+	} else {
+		r.FinishingJobMap[benchmarkName] = finishingJobs
+	}
+}
+
+// finalize runs benchmarkPostRun hook and releases nodes, system under test, and helm iteration once all jobs are done
+func (r *JobTracker) finalize(benchmark *cpev1.Benchmark) {
+	if !IsBenchmarkCompleted(benchmark) {
+		return
+	}
+	// release reserved nodes when all jobs done
+	if benchmark.Spec.ExclusiveNodes != nil && r.Reserver != nil && len(benchmark.Status.ReservedNodes) > 0 {
+		r.releaseNodes(benchmark)
+	}
+
+	// benchmark postRun hook when all jobs done (re-checked on its completion event)
+	finished, err := CheckHook(r.DYN, benchmark, HOOK_BENCHMARK_POST_RUN, "")
+	if err != nil {
+		r.Log.Info(fmt.Sprintf("Failed %v", err))
+		if !RecordHookFailure(r.Client, r.Recorder, benchmark, err) {
+			// API error, check the hook again later
+			r.requeue(types.NamespacedName{Name: benchmark.GetName(), Namespace: benchmark.GetNamespace()})
+			return
+		}
+	} else if !finished {
+		return
+	}
+
+	// tear down system under test when all jobs done
+	if benchmark.Spec.SystemUnderTest != nil {
+		if err = DeleteSystemUnderTest(r.DC, r.DYN, benchmark, ""); err != nil {
+			r.Log.Info(fmt.Sprintf("Cannot delete system under test of %s: %v", benchmark.GetName(), err))
+		}
+	}
+
+	// restore helm deployment of the operator when all jobs done
+	if HasHelmIteration(benchmark) {
+		if err = ReleaseHelmIteration(r.DYN, benchmark); err != nil {
+			r.Log.Info(fmt.Sprintf("Cannot release helm iteration of %s: %v", benchmark.GetName(), err))
+		}
+	}
+}
+
 func (r *JobTracker) IsExist(benchmarkName string) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
 	index := r.indexOf(benchmarkName)
 	return index != -1 && index < len(r.Subscribers)
 }
@@ -189,7 +557,15 @@ func (r *JobTracker) deployWaitingResource(finishedInstance *unstructured.Unstru
 
 		if !nodeTunedOptimizer.FinalizedApplied {
 			copiedInstance := r.copyInstance(finishedInstance)
-			err, isNew := CreateIfNotExists(dr, benchmark, copiedInstance, r.Adaptor, r.TunedHandler, nodeTunedOptimizer)
+			if r.isReservationLost(benchmark) {
+				r.addPendingJob(benchmarkName, PendingJob{Job: copiedInstance, DR: dr, Sequential: true})
+				return
+			}
+			err, isNew := CreateIfNotExists(r.DC, r.DYN, dr, benchmark, copiedInstance, r.getAdaptor(benchmarkName), r.TunedHandler, nodeTunedOptimizer)
+			if IsJobNotReady(err) {
+				r.addPendingJob(benchmarkName, PendingJob{Job: copiedInstance, DR: dr, Sequential: true})
+				return
+			}
 			if err == nil && isNew {
 				r.Log.Info(fmt.Sprintf("Continue auto-tuning for %s", finishedInstance.GetName()))
 				return
@@ -209,9 +585,10 @@ func (r *JobTracker) deployWaitingResource(finishedInstance *unstructured.Unstru
 			r.Log.Info(fmt.Sprintf("Deploy resource: %s (%d waiting)", nextInstance.GetName(), len(r.WaitingJobMap[benchmarkName])))
 
 			if nodeTunedOptimizer, ok = r.JobOptMap[nextInstance.GetName()]; ok {
-				err, _ := CreateIfNotExists(dr, benchmark, nextInstance, r.Adaptor, r.TunedHandler, nodeTunedOptimizer)
-				if err != nil {
-					r.Log.Info(fmt.Sprintf("Cannot create #%v: %s", err, nextInstance.GetName()))
+				if !r.startJob(benchmark, PendingJob{Job: nextInstance, DR: dr, Sequential: true}) && len(r.WaitingJobMap[benchmarkName]) > 0 {
+					// the failed job never completes, continue to the next one
+					r.deployWaitingResource(nextInstance, benchmark)
+					return
 				}
 				if len(r.WaitingJobMap[benchmarkName]) == 0 {
 					delete(r.WaitingJobMap, benchmarkName)
@@ -233,10 +610,12 @@ func (r *JobTracker) deployWaitingResource(finishedInstance *unstructured.Unstru
 	}
 }
 
-func (r *JobTracker) ProcessJobQueue() {
-	job := <-r.JobQueue
+func (r *JobTracker) ProcessJobQueue(job *unstructured.Unstructured) {
 	jobObject := job.Object
 
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
 	jobMeta := jobObject["metadata"].(map[string]interface{})
 	jobLabels := jobMeta["labels"].(map[string]interface{})
 
@@ -264,19 +643,24 @@ func (r *JobTracker) ProcessJobQueue() {
 	jobName := jobMeta["name"].(string)
 	jobNamespace := jobMeta["namespace"].(string)
 
-	podLogOpts := corev1.PodLogOptions{}
+	failed := r.getAdaptor(benchmarkName).CheckFailed(jobObject)
+	if failed {
+		r.Log.Info(fmt.Sprintf("Job %s failed", jobName))
+		r.recordJobFailure(benchmark, jobName)
+	}
 
 	valid := false
-	podList, err := r.Adaptor.GetPodList(jobObject, r.Clientset)
+	podList, err := r.getAdaptor(benchmarkName).GetPodList(jobObject, r.Clientset)
 	if err != nil {
 		r.Log.Info(fmt.Sprintf("Cannot list pod from selector #%v ", err))
 	}
 
 	for index, pod := range podList.Items {
-		if pod.Status.Phase != corev1.PodSucceeded {
+		if failed || pod.Status.Phase != corev1.PodSucceeded {
 			continue
 		}
 		valid = true
+		podLogOpts := corev1.PodLogOptions{Container: r.getAdaptor(benchmarkName).GetLogContainer(pod)}
 		req := r.Clientset.CoreV1().Pods(jobNamespace).GetLogs(pod.Name, &podLogOpts)
 		podLogs, err := req.Stream(context.TODO())
 		if err != nil {
@@ -289,6 +673,7 @@ func (r *JobTracker) ProcessJobQueue() {
 			r.Log.Info(fmt.Sprintf("Cannot copy I/O #%v ", err))
 		} else {
 			podName := pod.Name
+			nodeName := pod.Spec.NodeName
 			keyName := fmt.Sprintf("%s/%s/%s/%s.log", benchmarkName, CLUSTER_ID, jobName, podName)
 			instance := pod.Status.HostIP
 
@@ -338,9 +723,11 @@ func (r *JobTracker) ProcessJobQueue() {
 							r.Log.Info(fmt.Sprintf("Replace with previous result %s (%.2f) -> %s (%.2f)", podName, response.PerformanceValue, bestPodName, prevResponse.PerformanceValue))
 							response = prevResponse
 							podName = bestPodName
+							nodeName = r.BestNodeNameMap[jobName]
 						} else {
 							// else record new best pod
 							r.BestPodNameMap[jobName] = pod.Name
+							r.BestNodeNameMap[jobName] = nodeName
 						}
 					}
 
@@ -349,12 +736,14 @@ func (r *JobTracker) ProcessJobQueue() {
 					} else {
 						if nodeTunedOptimizer, ok := r.JobOptMap[jobName]; ok {
 							if nodeTunedOptimizer.FinalizedApplied {
-								r.updateBenchmarkStatus(benchmark, jobName, podName, response)
+								r.updateBenchmarkStatus(benchmark, jobName, podName, nodeName, response)
 								delete(r.BestPodNameMap, jobName)
+								delete(r.BestNodeNameMap, jobName)
 							}
 						} else {
-							r.updateBenchmarkStatus(benchmark, jobName, podName, response)
+							r.updateBenchmarkStatus(benchmark, jobName, podName, nodeName, response)
 							delete(r.BestPodNameMap, jobName)
+							delete(r.BestNodeNameMap, jobName)
 						}
 					}
 				}
@@ -362,10 +751,17 @@ func (r *JobTracker) ProcessJobQueue() {
 
 		}
 	}
-	if valid {
-		// delete all pod if got result
-		for _, pod := range podList.Items {
-			r.Clientset.CoreV1().Pods(jobNamespace).Delete(context.TODO(), pod.Name, metav1.DeleteOptions{})
+	// postRun hook, clean-up, and teardown of resources of the scenario (unless the job is still auto-tuned) before the next job
+	nodeTunedOptimizer, tuning := r.JobOptMap[jobName]
+	tearDown := len(benchmark.Spec.Resources) > 0 && (!valid || !tuning || nodeTunedOptimizer.FinalizedApplied)
+	r.FinishingJobMap[benchmarkName] = append(r.FinishingJobMap[benchmarkName], FinishingJob{Job: job, TearDown: tearDown})
+
+	if valid || failed {
+		if valid {
+			// delete all pod if got result
+			for _, pod := range podList.Items {
+				r.Clientset.CoreV1().Pods(jobNamespace).Delete(context.TODO(), pod.Name, metav1.DeleteOptions{})
+			}
 		}
 
 		// handler tuned profile
@@ -375,11 +771,22 @@ func (r *JobTracker) ProcessJobQueue() {
 			r.TunedHandler.DeleteLabel(nodeSelectionSpec.TargetSelector)
 		}
 
-		r.deployWaitingResource(r.Adaptor.CopyJobResource(job), benchmark)
+		r.deployWaitingResource(r.getAdaptor(benchmarkName).CopyJobResource(job), benchmark)
 	}
+
+	r.progress(benchmark)
 }
 
-func (r *JobTracker) updateBenchmarkStatus(benchmark *cpev1.Benchmark, jobName string, podName string, response Response) {
+func (r *JobTracker) releaseNodes(benchmark *cpev1.Benchmark) {
+	r.Reserver.Release(benchmark)
+	benchmark.Status.ReservedNodes = []string{}
+	err := r.Client.Status().Update(context.Background(), benchmark)
+	if err != nil {
+		r.Log.Info(fmt.Sprintf("Cannot update reserved nodes #%v ", err))
+	}
+}
+
+func (r *JobTracker) updateBenchmarkStatus(benchmark *cpev1.Benchmark, jobName string, podName string, nodeName string, response Response) {
 	performanceKey := response.PerformanceKey
 	pushedTime := time.Now().String()
 	pvalInString := fmt.Sprintf("%f", response.PerformanceValue)
@@ -403,8 +810,15 @@ func (r *JobTracker) updateBenchmarkStatus(benchmark *cpev1.Benchmark, jobName s
 		Result:           response.Message,
 		JobName:          jobName,
 		PodName:          podName,
+		NodeName:         nodeName,
+		NodeInfo:         getNodeFingerprintByName(r.Clientset, nodeName),
 		PushedTime:       pushedTime,
 	}
+	var assertionMessages []string
+	if len(benchmark.Spec.Assertions) > 0 {
+		variables := GetAssertionVariables(resultItem, iterationMap, configurationMap)
+		resultItem.FailedAssertions, assertionMessages = EvaluateAssertions(benchmark.Spec.Assertions, variables)
+	}
 
 	results := benchmark.Status.Results
 	found := false
@@ -421,6 +835,7 @@ func (r *JobTracker) updateBenchmarkStatus(benchmark *cpev1.Benchmark, jobName s
 				benchmark.Status.Results[index].ConfigurationMap[RESERVED_AUTOTUNED_PROFILE_NAME] = fmt.Sprintf("%s\n%s", prevTunedData, configurationMap[RESERVED_AUTOTUNED_PROFILE_NAME])
 			}
 			benchmark.Status.Results[index].Items = append(existResult.Items, resultItem)
+			benchmark.Status.Results[index].Summary = GetResultSummary(benchmark.Status.Results[index].Items)
 			break
 		}
 	}
@@ -432,6 +847,7 @@ func (r *JobTracker) updateBenchmarkStatus(benchmark *cpev1.Benchmark, jobName s
 			ConfigurationID:  configurationID,
 			ConfigurationMap: configurationMap,
 			Items:            []cpev1.BenchmarkResultItem{resultItem},
+			Summary:          GetResultSummary([]cpev1.BenchmarkResultItem{resultItem}),
 		}
 		benchmark.Status.Results = append(benchmark.Status.Results, newResult)
 	}
@@ -468,13 +884,96 @@ func (r *JobTracker) updateBenchmarkStatus(benchmark *cpev1.Benchmark, jobName s
 	}
 
 	benchmark.Status.BestResults = bestResults
+
+	// export finalized auto-tuned profile
+	if nodeTunedOptimizer, ok := r.JobOptMap[jobName]; ok && nodeTunedOptimizer.AutoTuned && r.TunedHandler != nil {
+		r.exportAutoTunedProfile(benchmark, nodeTunedOptimizer, jobName, buildID, iterationID, performanceKey, pvalInString)
+	}
+
 	benchmark.Status.JobCompleted = GetJobCompletedStatus(benchmark)
+
+	if errs := UpdateDerivedValues(benchmark); len(errs) > 0 {
+		r.Log.Info(fmt.Sprintf("Cannot compute %d derived values: %v", len(errs), errs[0]))
+	}
+
+	// compare builds once all repetitions are done
+	previousRegressions := benchmark.Status.Regressions
+	benchmark.Status.Regressions = GetRegressions(benchmark)
+	newVerdicts := GetNewVerdicts(previousRegressions, benchmark.Status.Regressions)
+	completedBisections := UpdateBisections(benchmark, newVerdicts)
+
 	err := r.Client.Status().Update(context.Background(), benchmark)
 
 	if err != nil {
 		r.Log.Info(fmt.Sprintf("Cannot update #%v ", err))
+		return
+	}
+	r.recordAssertionEvents(benchmark, jobName, assertionMessages)
+	r.recordRegressionEvents(benchmark, newVerdicts)
+	r.recordBisectionEvents(benchmark, completedBisections)
+}
+
+func (r *JobTracker) recordBisectionEvents(benchmark *cpev1.Benchmark, bisections []cpev1.BisectionStatus) {
+	for _, bisection := range bisections {
+		message := fmt.Sprintf("first bad version %s (last good %s) on scenario %s config %s", bisection.FirstBad, bisection.Good, bisection.IterationID, bisection.ConfigurationID)
+		r.Log.Info(message)
+		if r.Recorder != nil {
+			r.Recorder.Event(benchmark, corev1.EventTypeWarning, "Bisected", message)
+		}
 	}
+}
 
+func (r *JobTracker) recordAssertionEvents(benchmark *cpev1.Benchmark, jobName string, messages []string) {
+	for _, message := range messages {
+		message = fmt.Sprintf("%s failed assertion %s", jobName, message)
+		r.Log.Info(message)
+		if r.Recorder != nil {
+			r.Recorder.Event(benchmark, corev1.EventTypeWarning, "AssertionFailed", message)
+		}
+	}
+}
+
+func (r *JobTracker) recordRegressionEvents(benchmark *cpev1.Benchmark, regressions []cpev1.RegressionResult) {
+	for _, regression := range regressions {
+		message := fmt.Sprintf("%s %s from %s on scenario %s config %s: %s -> %s (change %s, p-value %s)", regression.BuildID, strings.ToLower(regression.Verdict), regression.BaselineID, regression.IterationID, regression.ConfigurationID, regression.BaselineMean, regression.Mean, regression.Change, regression.PValue)
+		r.Log.Info(message)
+		if r.Recorder == nil {
+			continue
+		}
+		eventType := corev1.EventTypeNormal
+		if regression.Verdict == VERDICT_REGRESSED {
+			eventType = corev1.EventTypeWarning
+		}
+		r.Recorder.Event(benchmark, eventType, regression.Verdict, message)
+	}
+}
+
+func (r *JobTracker) exportAutoTunedProfile(benchmark *cpev1.Benchmark, nodeTunedOptimizer *BaysesOptimizer, jobName, buildID, iterationID, performanceKey, performanceValue string) {
+	exportSpec := benchmark.Spec.IterationSpec.NodeSelection.Export
+	if exportSpec != nil && exportSpec.Disabled {
+		return
+	}
+	provenance := ProfileProvenance{
+		BenchmarkName:    benchmark.GetName(),
+		JobName:          jobName,
+		BuildID:          buildID,
+		IterationID:      iterationID,
+		SamplingCount:    nodeTunedOptimizer.SamplingCount,
+		PerformanceKey:   performanceKey,
+		PerformanceValue: performanceValue,
+	}
+	exported, err := r.TunedHandler.ExportAutoTunedProfile(benchmark, nodeTunedOptimizer.FinalizedTunedProfile, provenance)
+	if err != nil {
+		r.Log.Info(fmt.Sprintf("Cannot export auto-tuned profile of %s: %v", jobName, err))
+		return
+	}
+	for index, prevExported := range benchmark.Status.ExportedProfiles {
+		if prevExported.Kind == exported.Kind && prevExported.Namespace == exported.Namespace && prevExported.Name == exported.Name {
+			benchmark.Status.ExportedProfiles[index] = exported
+			return
+		}
+	}
+	benchmark.Status.ExportedProfiles = append(benchmark.Status.ExportedProfiles, exported)
 }
 
 // is val2 better than val1
@@ -485,8 +984,38 @@ func (r *JobTracker) isBetterResult(benchmark *cpev1.Benchmark, val1 float64, va
 	return true
 }
 
-func (r *JobTracker) checkComplete(jobObject map[string]interface{}) bool {
-	return r.Adaptor.CheckComplete(jobObject)
+// getAdaptor returns the adaptor of the operator the benchmark subscribed with (caller holds the mutex)
+func (r *JobTracker) getAdaptor(benchmarkName string) OperatorAdaptor {
+	if adaptor, ok := r.AdaptorMap[benchmarkName]; ok {
+		return adaptor
+	}
+	return r.Adaptor
+}
+
+// lookupAdaptor returns the adaptor of the benchmark
+func (r *JobTracker) lookupAdaptor(benchmarkName string) OperatorAdaptor {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.getAdaptor(benchmarkName)
+}
+
+// checkDone returns true if the job is completed or failed
+func checkDone(adaptor OperatorAdaptor, jobObject map[string]interface{}) bool {
+	return adaptor.CheckComplete(jobObject) || adaptor.CheckFailed(jobObject)
+}
+
+// recordJobFailure adds the failed job to failedJobs (regarded as done) and emits JobFailed event
+func (r *JobTracker) recordJobFailure(benchmark *cpev1.Benchmark, jobName string) {
+	if !AddFailedJob(benchmark, &HookError{JobName: jobName, Err: fmt.Errorf("job resource failed")}) {
+		return
+	}
+	if err := r.Client.Status().Update(context.Background(), benchmark); err != nil {
+		r.Log.Info(fmt.Sprintf("Cannot update failed job %s: %v", jobName, err))
+		return
+	}
+	if r.Recorder != nil {
+		r.Recorder.Event(benchmark, corev1.EventTypeWarning, "JobFailed", fmt.Sprintf("%s failed", jobName))
+	}
 }
 
 func (r *JobTracker) Init() {
@@ -502,11 +1031,12 @@ func (r *JobTracker) Init() {
 
 			jobName := jobMeta["name"].(string)
 
-			if _, exist := jobLabels[BENCHMARK_LABEL]; exist {
-				r.Log.Info(fmt.Sprintf("Job on update %s - %v %v", jobName, r.checkComplete(jobObject), jobObject["status"]))
-				if r.checkComplete(jobObject) {
+			if benchmarkName, exist := jobLabels[BENCHMARK_LABEL].(string); exist {
+				adaptor := r.lookupAdaptor(benchmarkName)
+				r.Log.Info(fmt.Sprintf("Job on update %s - %v %v", jobName, adaptor.CheckComplete(jobObject), jobObject["status"]))
+				if checkDone(adaptor, jobObject) {
 					oldJobObject := oldInstance.(*unstructured.Unstructured).Object
-					if !r.checkComplete(oldJobObject) {
+					if !checkDone(adaptor, oldJobObject) {
 						r.Log.Info(fmt.Sprintf("Add %s to job queue", jobName))
 						r.JobQueue <- job
 					}
@@ -518,6 +1048,24 @@ func (r *JobTracker) Init() {
 	s.AddEventHandler(handlers)
 	factory.Start(r.Quit)
 
+	// advance the benchmark on the completion event of its hook Job
+	hookFactory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(r.DYN, 0, metav1.NamespaceAll, func(options *metav1.ListOptions) {
+		options.LabelSelector = HOOK_LABEL
+	})
+	hookHandlers := cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldInstance, instance interface{}) {
+			hookJob := instance.(*unstructured.Unstructured)
+			finished, _ := IsHookJobFinished(hookJob)
+			oldFinished, _ := IsHookJobFinished(oldInstance.(*unstructured.Unstructured))
+			benchmarkName := hookJob.GetLabels()[HOOK_LABEL]
+			if finished && !oldFinished && r.IsExist(benchmarkName) {
+				r.Log.Info(fmt.Sprintf("Hook %s finished", hookJob.GetName()))
+				r.requestProgress(types.NamespacedName{Name: benchmarkName, Namespace: hookJob.GetNamespace()})
+			}
+		},
+	}
+	hookFactory.ForResource(hookJobGVR).Informer().AddEventHandler(hookHandlers)
+	hookFactory.Start(r.Quit)
 }
 
 func (r *JobTracker) writeLogToFile(benchmarkName, CLUSTER_ID, jobName, podName string, data []byte) (err error) {
@@ -546,7 +1094,11 @@ func (r *JobTracker) indexOf(benchmarkName string) int {
 	return index
 }
 
-func (r *JobTracker) Subscribe(benchmarkName string, waitingJob []*unstructured.Unstructured, dr dynamic.ResourceInterface, jobOptMap map[string]*BaysesOptimizer) {
+func (r *JobTracker) Subscribe(benchmarkName string, waitingJob []*unstructured.Unstructured, dr dynamic.ResourceInterface, adaptor OperatorAdaptor, jobOptMap map[string]*BaysesOptimizer) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	// job resources of the same kind can be handled by different operators
+	r.AdaptorMap[benchmarkName] = adaptor
 	index := r.indexOf(benchmarkName)
 	if index == -1 || index == len(r.Subscribers) {
 		r.Subscribers = append(r.Subscribers, benchmarkName)
@@ -564,6 +1116,8 @@ func (r *JobTracker) Subscribe(benchmarkName string, waitingJob []*unstructured.
 }
 
 func (r *JobTracker) Unsubscribe(benchmarkName string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
 	index := r.indexOf(benchmarkName)
 	if index < len(r.Subscribers) {
 		if index == len(r.Subscribers)-1 {
@@ -583,6 +1137,10 @@ func (r *JobTracker) Unsubscribe(benchmarkName string) {
 			delete(r.WaitingJobMap, benchmarkName)
 			delete(r.DRMap, benchmarkName)
 		}
+		delete(r.PlannedBuildMap, benchmarkName)
+		delete(r.AdaptorMap, benchmarkName)
+		delete(r.PendingJobMap, benchmarkName)
+		delete(r.FinishingJobMap, benchmarkName)
 	} else {
 		r.Log.Info(fmt.Sprintf("%s cannot found", benchmarkName))
 	}
@@ -0,0 +1,110 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+
+package controllers
+
+///////////////////////////////////////////////////////////////////////////
+//
+// node_info.go
+//
+// GetNodeFingerprint
+// - summarize node info, well-known labels, and allocatable of the node
+//   that runs the job to attach to the result item
+//
+////////////////////////////////////////////////////////////////////////////
+
+import (
+	"context"
+	"strings"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// node feature discovery labels
+	NFD_CPU_MODEL_PREFIX = "feature.node.kubernetes.io/cpu-model"
+	NFD_CPU_MODEL_ID     = NFD_CPU_MODEL_PREFIX + ".id"
+	NFD_CPU_MODEL_FAMILY = NFD_CPU_MODEL_PREFIX + ".family"
+	NFD_CPU_MODEL_VENDOR = NFD_CPU_MODEL_PREFIX + ".vendor_id"
+)
+
+var fingerprintLabels []string = []string{
+	corev1.LabelInstanceTypeStable,
+	corev1.LabelInstanceType,
+	corev1.LabelTopologyZone,
+	corev1.LabelFailureDomainBetaZone,
+	corev1.LabelTopologyRegion,
+	corev1.LabelFailureDomainBetaRegion,
+	corev1.LabelArchStable,
+	corev1.LabelOSStable,
+}
+
+func getFirstLabel(labels map[string]string, keys ...string) string {
+	for _, key := range keys {
+		if value, ok := labels[key]; ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func getCPUModel(labels map[string]string) string {
+	var model []string
+	for _, key := range []string{NFD_CPU_MODEL_VENDOR, NFD_CPU_MODEL_FAMILY, NFD_CPU_MODEL_ID} {
+		if value, ok := labels[key]; ok {
+			model = append(model, value)
+		}
+	}
+	return strings.Join(model, "-")
+}
+
+// GetNodeFingerprint returns node metadata to be recorded along with the result
+// (read at the time of parsing the result, not at the completion of the pod)
+func GetNodeFingerprint(node *corev1.Node) *cpev1.NodeFingerprint {
+	nodeLabels := node.GetLabels()
+	labels := make(map[string]string)
+	for _, key := range fingerprintLabels {
+		if value, ok := nodeLabels[key]; ok {
+			labels[key] = value
+		}
+	}
+	for key, value := range nodeLabels {
+		if strings.HasPrefix(key, NFD_CPU_MODEL_PREFIX) {
+			labels[key] = value
+		}
+	}
+	allocatable := make(map[string]string)
+	for resourceName, quantity := range node.Status.Allocatable {
+		allocatable[string(resourceName)] = quantity.String()
+	}
+	nodeInfo := node.Status.NodeInfo
+	return &cpev1.NodeFingerprint{
+		KernelVersion:           nodeInfo.KernelVersion,
+		OSImage:                 nodeInfo.OSImage,
+		Architecture:            nodeInfo.Architecture,
+		ContainerRuntimeVersion: nodeInfo.ContainerRuntimeVersion,
+		KubeletVersion:          nodeInfo.KubeletVersion,
+		InstanceType:            getFirstLabel(nodeLabels, corev1.LabelInstanceTypeStable, corev1.LabelInstanceType),
+		Zone:                    getFirstLabel(nodeLabels, corev1.LabelTopologyZone, corev1.LabelFailureDomainBetaZone),
+		Region:                  getFirstLabel(nodeLabels, corev1.LabelTopologyRegion, corev1.LabelFailureDomainBetaRegion),
+		CPUModel:                getCPUModel(nodeLabels),
+		Labels:                  labels,
+		Allocatable:             allocatable,
+	}
+}
+
+func getNodeFingerprintByName(clientset *kubernetes.Clientset, nodeName string) *cpev1.NodeFingerprint {
+	if nodeName == "" {
+		return nil
+	}
+	node, err := clientset.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+	if err != nil {
+		return nil
+	}
+	return GetNodeFingerprint(node)
+}
@@ -0,0 +1,426 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+
+package controllers
+
+///////////////////////////////////////////////////////////////////////////
+//
+// node_reserver.go
+//
+// Reserve
+// - take a lease per node selected for exclusive access (called before start job)
+// - taint (and cordon if set) the reserved nodes for non-benchmark pods
+// StartRenewal
+// - renew the leases on a timer (a third of lease duration) for the life of the benchmark,
+//   jobs are held while the renewal fails (see GetRenewalError)
+// Release
+// - stop renewal, remove taint, uncordon, and delete lease (called after all jobs done or benchmark deleted)
+// ValidateExclusiveNodes
+// - reject toleration location not in dotted notation
+// AddTolerations
+// - inject tolerations of the reservation taint to the rendered job
+//
+////////////////////////////////////////////////////////////////////////////
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	"github.com/go-logr/logr"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	RESERVED_TAINT_KEY          = "cpe.cogadvisor.io/reserved"
+	CORDONED_BY_ANNOTATION      = "cpe.cogadvisor.io/cordoned-by"
+	NODE_LEASE_PREFIX           = "cpe-node-"
+	DEFAULT_LEASE_DURATION      = 3600
+	DEFAULT_TOLERATION_LOCATION = ".template.spec.tolerations"
+)
+
+type NodeReserver struct {
+	*kubernetes.Clientset
+	Log       logr.Logger
+	Namespace string
+
+	mutex    sync.Mutex
+	renewals map[string]*leaseRenewal
+}
+
+type leaseRenewal struct {
+	stop chan struct{}
+	err  error
+}
+
+func (n *NodeReserver) getNamespace() string {
+	if n.Namespace == "" {
+		return operatorNamespace
+	}
+	return n.Namespace
+}
+
+// holder is also used as taint value so that it must be a valid label value
+func getReservationHolder(benchmark *cpev1.Benchmark) string {
+	return getValidValue(benchmark.Namespace + "." + benchmark.Name)
+}
+
+func getNodeLeaseName(nodeName string) string {
+	return NODE_LEASE_PREFIX + nodeName
+}
+
+func getReservationSelector(benchmark *cpev1.Benchmark) *metav1.LabelSelector {
+	exclusiveSpec := benchmark.Spec.ExclusiveNodes
+	if exclusiveSpec.Selector != nil {
+		return exclusiveSpec.Selector
+	}
+	if benchmark.Spec.IterationSpec.NodeSelection != nil {
+		return benchmark.Spec.IterationSpec.NodeSelection.TargetSelector
+	}
+	return nil
+}
+
+func getLeaseDuration(benchmark *cpev1.Benchmark) int32 {
+	if benchmark.Spec.ExclusiveNodes.LeaseDurationSeconds > 0 {
+		return benchmark.Spec.ExclusiveNodes.LeaseDurationSeconds
+	}
+	return DEFAULT_LEASE_DURATION
+}
+
+func isLeaseExpired(lease *coordinationv1.Lease) bool {
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	expiredTime := lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second)
+	return time.Now().After(expiredTime)
+}
+
+func (n *NodeReserver) getNodeList(benchmark *cpev1.Benchmark) ([]corev1.Node, error) {
+	selector := getReservationSelector(benchmark)
+	if selector == nil {
+		return nil, fmt.Errorf("no node selector for exclusive nodes of %s", benchmark.Name)
+	}
+	labelSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := n.Clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{LabelSelector: labelSelector.String()})
+	if err != nil {
+		return nil, err
+	}
+	return nodes.Items, nil
+}
+
+// acquireLease returns whether the lease is newly acquired
+func (n *NodeReserver) acquireLease(nodeName string, holder string, duration int32) (bool, error) {
+	leases := n.Clientset.CoordinationV1().Leases(n.getNamespace())
+	now := metav1.NewMicroTime(time.Now())
+	lease, err := leases.Get(context.TODO(), getNodeLeaseName(nodeName), metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		lease = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      getNodeLeaseName(nodeName),
+				Namespace: n.getNamespace(),
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &holder,
+				LeaseDurationSeconds: &duration,
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}
+		_, err = leases.Create(context.TODO(), lease, metav1.CreateOptions{})
+		return err == nil, err
+	}
+	if err != nil {
+		return false, err
+	}
+	newlyAcquired := false
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != holder {
+		if lease.Spec.HolderIdentity != nil && !isLeaseExpired(lease) {
+			return false, fmt.Errorf("node %s is reserved by %s", nodeName, *lease.Spec.HolderIdentity)
+		}
+		lease.Spec.HolderIdentity = &holder
+		lease.Spec.AcquireTime = &now
+		newlyAcquired = true
+	}
+	lease.Spec.LeaseDurationSeconds = &duration
+	lease.Spec.RenewTime = &now
+	_, err = leases.Update(context.TODO(), lease, metav1.UpdateOptions{})
+	return newlyAcquired, err
+}
+
+func (n *NodeReserver) releaseLease(nodeName string, holder string) {
+	leases := n.Clientset.CoordinationV1().Leases(n.getNamespace())
+	lease, err := leases.Get(context.TODO(), getNodeLeaseName(nodeName), metav1.GetOptions{})
+	if err != nil {
+		return
+	}
+	if lease.Spec.HolderIdentity != nil && *lease.Spec.HolderIdentity == holder {
+		err = leases.Delete(context.TODO(), lease.Name, metav1.DeleteOptions{})
+		if err != nil {
+			n.Log.Info(fmt.Sprintf("Cannot delete lease %s: %v", lease.Name, err))
+		}
+	}
+}
+
+// updateNode applies the change to the latest node and retries on conflict
+func (n *NodeReserver) updateNode(nodeName string, change func(node *corev1.Node) bool) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		node, err := n.Clientset.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if !change(node) {
+			return nil
+		}
+		_, err = n.Clientset.CoreV1().Nodes().Update(context.TODO(), node, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+func (n *NodeReserver) taintNode(nodeName string, holder string, cordon bool) error {
+	return n.updateNode(nodeName, func(node *corev1.Node) bool {
+		updated := false
+		found := false
+		for index, taint := range node.Spec.Taints {
+			if taint.Key == RESERVED_TAINT_KEY {
+				found = true
+				if taint.Value != holder {
+					node.Spec.Taints[index].Value = holder
+					updated = true
+				}
+			}
+		}
+		if !found {
+			node.Spec.Taints = append(node.Spec.Taints, corev1.Taint{
+				Key:    RESERVED_TAINT_KEY,
+				Value:  holder,
+				Effect: corev1.TaintEffectNoSchedule,
+			})
+			updated = true
+		}
+		if cordon && !node.Spec.Unschedulable {
+			node.Spec.Unschedulable = true
+			if node.Annotations == nil {
+				node.Annotations = make(map[string]string)
+			}
+			node.Annotations[CORDONED_BY_ANNOTATION] = holder
+			updated = true
+		}
+		return updated
+	})
+}
+
+func (n *NodeReserver) untaintNode(nodeName string, holder string) error {
+	return n.updateNode(nodeName, func(node *corev1.Node) bool {
+		updated := false
+		var taints []corev1.Taint
+		for _, taint := range node.Spec.Taints {
+			if taint.Key == RESERVED_TAINT_KEY && taint.Value == holder {
+				updated = true
+				continue
+			}
+			taints = append(taints, taint)
+		}
+		node.Spec.Taints = taints
+		if cordonedBy, ok := node.Annotations[CORDONED_BY_ANNOTATION]; ok && cordonedBy == holder {
+			node.Spec.Unschedulable = false
+			delete(node.Annotations, CORDONED_BY_ANNOTATION)
+			updated = true
+		}
+		return updated
+	})
+}
+
+// Reserve takes (or renews) lease of every selected node and taints them
+// returns the list of reserved node names
+func (n *NodeReserver) Reserve(benchmark *cpev1.Benchmark) ([]string, error) {
+	holder := getReservationHolder(benchmark)
+	nodes, err := n.getNodeList(benchmark)
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no node matched for exclusive nodes of %s", benchmark.Name)
+	}
+	duration := getLeaseDuration(benchmark)
+
+	var reservedNodes []string
+	var newlyAcquired []string
+	for _, node := range nodes {
+		isNew, err := n.acquireLease(node.Name, holder, duration)
+		if err != nil {
+			// rollback only the leases taken by this call
+			for _, nodeName := range newlyAcquired {
+				n.releaseLease(nodeName, holder)
+			}
+			return nil, err
+		}
+		if isNew {
+			newlyAcquired = append(newlyAcquired, node.Name)
+		}
+		reservedNodes = append(reservedNodes, node.Name)
+	}
+
+	for _, node := range nodes {
+		err = n.taintNode(node.Name, holder, benchmark.Spec.ExclusiveNodes.Cordon)
+		if err != nil {
+			n.Log.Info(fmt.Sprintf("Cannot taint node %s: %v", node.Name, err))
+		}
+	}
+	n.Log.Info(fmt.Sprintf("Reserved %v for %s", reservedNodes, holder))
+	return reservedNodes, nil
+}
+
+// GetRenewalInterval returns the interval of renewing the leases (a third of lease duration)
+func GetRenewalInterval(benchmark *cpev1.Benchmark) time.Duration {
+	return time.Duration(getLeaseDuration(benchmark)) * time.Second / 3
+}
+
+// StartRenewal renews the leases of the benchmark until Release if not started yet
+func (n *NodeReserver) StartRenewal(benchmark *cpev1.Benchmark) {
+	holder := getReservationHolder(benchmark)
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	if n.renewals == nil {
+		n.renewals = make(map[string]*leaseRenewal)
+	}
+	if _, running := n.renewals[holder]; running {
+		return
+	}
+	renewal := &leaseRenewal{stop: make(chan struct{})}
+	n.renewals[holder] = renewal
+	reserved := benchmark.DeepCopy()
+	go func() {
+		ticker := time.NewTicker(GetRenewalInterval(reserved))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-renewal.stop:
+				return
+			case <-ticker.C:
+				_, err := n.Reserve(reserved)
+				if err != nil {
+					n.Log.Info(fmt.Sprintf("Cannot renew node reservation of %s: %v", holder, err))
+				}
+				n.mutex.Lock()
+				renewal.err = err
+				n.mutex.Unlock()
+			}
+		}
+	}()
+}
+
+// GetRenewalError returns the error of the last renewal, jobs must not be created while it is set
+func (n *NodeReserver) GetRenewalError(benchmark *cpev1.Benchmark) error {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	if renewal, running := n.renewals[getReservationHolder(benchmark)]; running {
+		return renewal.err
+	}
+	return nil
+}
+
+func (n *NodeReserver) stopRenewal(holder string) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	if renewal, running := n.renewals[holder]; running {
+		close(renewal.stop)
+		delete(n.renewals, holder)
+	}
+}
+
+// Release removes taint and lease of the nodes reserved by the benchmark
+func (n *NodeReserver) Release(benchmark *cpev1.Benchmark) {
+	holder := getReservationHolder(benchmark)
+	n.stopRenewal(holder)
+	nodeNames := make(map[string]bool)
+	for _, nodeName := range benchmark.Status.ReservedNodes {
+		nodeNames[nodeName] = true
+	}
+	// in case that status has not been updated
+	if nodes, err := n.getNodeList(benchmark); err == nil {
+		for _, node := range nodes {
+			nodeNames[node.Name] = true
+		}
+	}
+	for nodeName := range nodeNames {
+		err := n.untaintNode(nodeName, holder)
+		if err != nil && !errors.IsNotFound(err) {
+			n.Log.Info(fmt.Sprintf("Cannot untaint node %s: %v", nodeName, err))
+		}
+		n.releaseLease(nodeName, holder)
+	}
+	n.Log.Info(fmt.Sprintf("Released nodes of %s", holder))
+}
+
+// GetReservationTolerations returns tolerations to be injected to the benchmark job
+func GetReservationTolerations(benchmark *cpev1.Benchmark) []interface{} {
+	tolerations := []interface{}{
+		map[string]interface{}{
+			"key":      RESERVED_TAINT_KEY,
+			"operator": string(corev1.TolerationOpEqual),
+			"value":    getReservationHolder(benchmark),
+			"effect":   string(corev1.TaintEffectNoSchedule),
+		},
+	}
+	if benchmark.Spec.ExclusiveNodes.Cordon {
+		tolerations = append(tolerations, map[string]interface{}{
+			"key":      corev1.TaintNodeUnschedulable,
+			"operator": string(corev1.TolerationOpExists),
+			"effect":   string(corev1.TaintEffectNoSchedule),
+		})
+	}
+	return tolerations
+}
+
+// ValidateExclusiveNodes returns error if the toleration location is not in dotted notation
+func ValidateExclusiveNodes(benchmark *cpev1.Benchmark) error {
+	if benchmark.Spec.ExclusiveNodes == nil {
+		return nil
+	}
+	return ValidateDottedLocation(benchmark.Spec.ExclusiveNodes.TolerationLocation)
+}
+
+// AddTolerations appends reservation tolerations to the list at dotted location of the spec object (see ValidateExclusiveNodes)
+func AddTolerations(specObject map[string]interface{}, benchmark *cpev1.Benchmark) map[string]interface{} {
+	location := benchmark.Spec.ExclusiveNodes.TolerationLocation
+	if location == "" {
+		location = DEFAULT_TOLERATION_LOCATION
+	}
+	fields := itrHandler.getToken(location[1:])
+	tolerations, _, _ := unstructured.NestedSlice(specObject, fields...)
+	tolerations = append(tolerations, GetReservationTolerations(benchmark)...)
+	unstructured.SetNestedSlice(specObject, tolerations, fields...)
+	return specObject
+}
+
+// IsBenchmarkCompleted returns true if every iterated job has its result
+func IsBenchmarkCompleted(benchmark *cpev1.Benchmark) bool {
+	firstLabel, iterationLabels, builds, maxRepetition := GetIteratedValues(benchmark)
+	labels := append([]map[string]string{firstLabel}, iterationLabels...)
+	for repetition := 0; repetition < maxRepetition; repetition++ {
+		for _, build := range builds {
+			for _, iterationLabel := range labels {
+				if !IsScenarioPlanned(benchmark, build, iterationLabel) {
+					continue
+				}
+				if !CheckIfJobDone(benchmark, getJobName(benchmark, iterationLabel, build, repetition)) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
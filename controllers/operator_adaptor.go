@@ -12,7 +12,12 @@ package controllers
 // This is an abstract class for defining the function for
 // - CheckComplete - checking that the job is completed from the job resource's status
 //   (default job resource is batch/Job)
+// - CheckFailed - checking that the job is failed (not detected by default)
 // - GetPodList - to define matching rule from job to pod
+// - GetLogContainer - container of the pod with the result log (the only container by default)
+// - CleanUp - deleting resources created by the job resource after the job is done (nothing by default)
+//
+// genericAdaptor of BenchmarkOperator is used instead of the adaptors below if set (see generic_adaptor.go)
 //
 ////////////////////////////////////////////////////////////////////////////
 
@@ -21,16 +26,23 @@ import (
 	"fmt"
 	"strings"
 
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
 	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 )
 
 type OperatorAdaptor interface {
 	CheckComplete(jobObject map[string]interface{}) bool
+	CheckFailed(jobObject map[string]interface{}) bool
 	GetPodList(jobObject map[string]interface{}, clientset *kubernetes.Clientset) (*corev1.PodList, error)
+	GetLogContainer(pod corev1.Pod) string
 	CopyJobResource(originalJob *unstructured.Unstructured) *unstructured.Unstructured
+	CleanUp(jobObject map[string]interface{}, dyn dynamic.Interface) error
 }
 
 // Base Operartor Adaptor
@@ -38,6 +50,18 @@ type BaseOperatorAdaptor struct {
 	OperatorAdaptor
 }
 
+func (a *BaseOperatorAdaptor) CheckFailed(jobObject map[string]interface{}) bool {
+	return false
+}
+
+func (a *BaseOperatorAdaptor) GetLogContainer(pod corev1.Pod) string {
+	return ""
+}
+
+func (a *BaseOperatorAdaptor) CleanUp(jobObject map[string]interface{}, dyn dynamic.Interface) error {
+	return nil
+}
+
 // Default Operartor Adaptor
 type DefaultAdaptor struct {
 	*BaseOperatorAdaptor
@@ -277,14 +301,153 @@ func (a *KubeflowAdaptor) GetPodList(jobObject map[string]interface{}, clientset
 	return clientset.CoreV1().Pods(jobNamespace).List(context.TODO(), listOptions)
 }
 
+// Ray (KubeRay RayJob) Operartor Adaptor
+type RayAdaptor struct {
+	*BaseOperatorAdaptor
+}
+
+const (
+	RAY_JOB_SUCCEEDED            = "SUCCEEDED"
+	RAY_JOB_FAILED               = "FAILED"
+	RAY_JOB_STOPPED              = "STOPPED"
+	RAY_DEPLOYMENT_COMPLETE      = "Complete"
+	RAY_CLUSTER_LABEL            = "ray.io/cluster"
+	RAY_NODE_TYPE_LABEL          = "ray.io/node-type"
+	RAY_HEAD_NODE_TYPE           = "head"
+	RAY_SUBMITTER_CONTAINER_NAME = "ray-job-submitter"
+)
+
+// deployment status of RayJob that never completes
+var rayFailedDeploymentStatus = []string{"Failed", "FailedToGetOrCreateRayCluster", "FailedJobDeploy", "ValidationFailed"}
+
+func NewRayAdaptor() *RayAdaptor {
+	rayAdaptor := &RayAdaptor{}
+	abs := &BaseOperatorAdaptor{
+		OperatorAdaptor: rayAdaptor,
+	}
+	rayAdaptor.BaseOperatorAdaptor = abs
+	return rayAdaptor
+}
+
+func getRayJobStatus(jobObject map[string]interface{}) (string, string) {
+	jobStatus, _, _ := unstructured.NestedString(jobObject, "status", "jobStatus")
+	deploymentStatus, _, _ := unstructured.NestedString(jobObject, "status", "jobDeploymentStatus")
+	return jobStatus, deploymentStatus
+}
+
+func (a *RayAdaptor) CheckComplete(jobObject map[string]interface{}) bool {
+	jobStatus, deploymentStatus := getRayJobStatus(jobObject)
+	return jobStatus == RAY_JOB_SUCCEEDED && deploymentStatus == RAY_DEPLOYMENT_COMPLETE
+}
+
+func (a *RayAdaptor) CheckFailed(jobObject map[string]interface{}) bool {
+	jobStatus, deploymentStatus := getRayJobStatus(jobObject)
+	if jobStatus == RAY_JOB_FAILED || jobStatus == RAY_JOB_STOPPED {
+		return true
+	}
+	for _, failedStatus := range rayFailedDeploymentStatus {
+		if deploymentStatus == failedStatus {
+			return true
+		}
+	}
+	return false
+}
+
+// GetPodList returns the submitter pods (Kubernetes Job of the same name) or the head pod if no submitter
+func (a *RayAdaptor) GetPodList(jobObject map[string]interface{}, clientset *kubernetes.Clientset) (*corev1.PodList, error) {
+	jobMeta := jobObject["metadata"].(map[string]interface{})
+	jobNamespace := jobMeta["namespace"].(string)
+	jobName := jobMeta["name"].(string)
+
+	listOptions := metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("job-name=%s", jobName),
+		Limit:         100,
+	}
+	podList, err := clientset.CoreV1().Pods(jobNamespace).List(context.TODO(), listOptions)
+	if err != nil || len(podList.Items) > 0 {
+		return podList, err
+	}
+
+	clusterName, _, _ := unstructured.NestedString(jobObject, "status", "rayClusterName")
+	if clusterName == "" {
+		return podList, nil
+	}
+	listOptions.LabelSelector = fmt.Sprintf("%s=%s,%s=%s", RAY_CLUSTER_LABEL, clusterName, RAY_NODE_TYPE_LABEL, RAY_HEAD_NODE_TYPE)
+	return clientset.CoreV1().Pods(jobNamespace).List(context.TODO(), listOptions)
+}
+
+func (a *RayAdaptor) GetLogContainer(pod corev1.Pod) string {
+	for _, container := range pod.Spec.Containers {
+		if container.Name == RAY_SUBMITTER_CONTAINER_NAME {
+			return container.Name
+		}
+	}
+	return ""
+}
+
+// CopyJobResource keeps spec only, status and cluster name are set by KubeRay
+func (a *RayAdaptor) CopyJobResource(originalJob *unstructured.Unstructured) *unstructured.Unstructured {
+	jobObject := originalJob.DeepCopy().Object
+	metadata := jobObject["metadata"].(map[string]interface{})
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": jobObject["apiVersion"],
+			"kind":       jobObject["kind"],
+			"spec":       jobObject["spec"],
+			"metadata":   map[string]interface{}{"name": metadata["name"], "namespace": metadata["namespace"], "labels": metadata["labels"]},
+		},
+	}
+}
+
+// GetRayClusterToDelete returns the RayCluster created by the RayJob, empty if the cluster is selected from existing ones
+func GetRayClusterToDelete(jobObject map[string]interface{}) (schema.GroupVersionResource, string) {
+	clusterGVR := schema.GroupVersionResource{Resource: "rayclusters"}
+	if clusterSelector, _, _ := unstructured.NestedStringMap(jobObject, "spec", "clusterSelector"); len(clusterSelector) > 0 {
+		return clusterGVR, ""
+	}
+	apiVersion, _ := jobObject["apiVersion"].(string)
+	if gv, err := schema.ParseGroupVersion(apiVersion); err == nil {
+		clusterGVR.Group, clusterGVR.Version = gv.Group, gv.Version
+	}
+	clusterName, _, _ := unstructured.NestedString(jobObject, "status", "rayClusterName")
+	return clusterGVR, clusterName
+}
+
+// CleanUp deletes the RayCluster created by the RayJob (kept by KubeRay unless shutdownAfterJobFinishes)
+func (a *RayAdaptor) CleanUp(jobObject map[string]interface{}, dyn dynamic.Interface) error {
+	clusterGVR, clusterName := GetRayClusterToDelete(jobObject)
+	if clusterName == "" {
+		return nil
+	}
+	jobNamespace, _, _ := unstructured.NestedString(jobObject, "metadata", "namespace")
+	err := dyn.Resource(clusterGVR).Namespace(jobNamespace).Delete(context.TODO(), clusterName, metav1.DeleteOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
 var defaultAdaptor OperatorAdaptor = NewDefaultAdaptor()
 var ripsawAdaptor OperatorAdaptor = NewRipsawAdaptor()
 var mpiAdaptor OperatorAdaptor = NewMPIAdaptor()
 var kubeflowAdaptor OperatorAdaptor = NewKubeflowAdaptor()
+var rayAdaptor OperatorAdaptor = NewRayAdaptor()
+
+// GetOperatorAdaptor returns the adaptor of the BenchmarkOperator
+func GetOperatorAdaptor(operator *cpev1.BenchmarkOperator) OperatorAdaptor {
+	if operator.Spec.GenericAdaptor != nil {
+		return NewGenericAdaptor(*operator.Spec.GenericAdaptor)
+	}
+	if adaptor, adaptorExists := OperatorAdaptorMap[operator.Spec.Adaptor]; adaptorExists {
+		return adaptor
+	}
+	return OperatorAdaptorMap["default"]
+}
 
 var OperatorAdaptorMap map[string]OperatorAdaptor = map[string]OperatorAdaptor{
 	"default":  defaultAdaptor,
 	"ripsaw":   ripsawAdaptor,
 	"mpi":      mpiAdaptor,
 	"kubeflow": kubeflowAdaptor,
+	"ray":      rayAdaptor,
 }
@@ -0,0 +1,127 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+
+package controllers
+
+///////////////////////////////////////////////////////////////////////////
+//
+// placement.go
+//
+// ValidatePlacement
+// - reject unknown placement policy and affinity location not in dotted notation
+// GetSpreadNode
+// - assign repetition to one of eligible nodes in round-robin order
+// AddNodeAffinity
+// - inject required node affinity to pin the rendered job to the assigned node
+//
+////////////////////////////////////////////////////////////////////////////
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	PLACEMENT_SPREAD          = "spread"
+	DEFAULT_AFFINITY_LOCATION = ".template.spec.affinity"
+	NODE_NAME_FIELD           = "metadata.name"
+)
+
+// ValidatePlacement returns error if the placement policy is unknown
+func ValidatePlacement(benchmark *cpev1.Benchmark) error {
+	if benchmark.Spec.Placement == nil {
+		return nil
+	}
+	if benchmark.Spec.Placement.Policy != PLACEMENT_SPREAD {
+		return fmt.Errorf("unknown placement policy %q (supported: %s)", benchmark.Spec.Placement.Policy, PLACEMENT_SPREAD)
+	}
+	return ValidateDottedLocation(benchmark.Spec.Placement.AffinityLocation)
+}
+
+func getPlacementSelector(benchmark *cpev1.Benchmark) *metav1.LabelSelector {
+	if benchmark.Spec.Placement.Selector != nil {
+		return benchmark.Spec.Placement.Selector
+	}
+	if benchmark.Spec.ExclusiveNodes != nil {
+		return getReservationSelector(benchmark)
+	}
+	if benchmark.Spec.IterationSpec.NodeSelection != nil {
+		return benchmark.Spec.IterationSpec.NodeSelection.TargetSelector
+	}
+	return nil
+}
+
+// listPlacementNodes returns sorted names of nodes eligible for placement
+func listPlacementNodes(c client.Client, benchmark *cpev1.Benchmark) ([]string, error) {
+	// reserved nodes take priority when no explicit selector is set
+	if benchmark.Spec.Placement.Selector == nil && len(benchmark.Status.ReservedNodes) > 0 {
+		nodes := append([]string{}, benchmark.Status.ReservedNodes...)
+		sort.Strings(nodes)
+		return nodes, nil
+	}
+	listOptions := &client.ListOptions{}
+	if selector := getPlacementSelector(benchmark); selector != nil {
+		labelSelector, err := metav1.LabelSelectorAsSelector(selector)
+		if err != nil {
+			return nil, err
+		}
+		listOptions.LabelSelector = labelSelector
+	}
+	nodeList := &corev1.NodeList{}
+	err := c.List(context.TODO(), nodeList, listOptions)
+	if err != nil {
+		return nil, err
+	}
+	var nodes []string
+	for _, node := range nodeList.Items {
+		if node.Spec.Unschedulable {
+			continue
+		}
+		nodes = append(nodes, node.Name)
+	}
+	sort.Strings(nodes)
+	return nodes, nil
+}
+
+// GetSpreadNode returns node assigned to the repetition
+func GetSpreadNode(nodes []string, repetition int) string {
+	return nodes[repetition%len(nodes)]
+}
+
+// AddNodeAffinity requires the job to run on the node by adding node name to every node selector term at dotted location (see ValidatePlacement)
+func AddNodeAffinity(specObject map[string]interface{}, benchmark *cpev1.Benchmark, nodeName string) map[string]interface{} {
+	location := benchmark.Spec.Placement.AffinityLocation
+	if location == "" {
+		location = DEFAULT_AFFINITY_LOCATION
+	}
+	fields := append(itrHandler.getToken(location[1:]), "nodeAffinity", "requiredDuringSchedulingIgnoredDuringExecution", "nodeSelectorTerms")
+	nodeRequirement := map[string]interface{}{
+		"key":      NODE_NAME_FIELD,
+		"operator": string(corev1.NodeSelectorOpIn),
+		"values":   []interface{}{nodeName},
+	}
+	terms, _, _ := unstructured.NestedSlice(specObject, fields...)
+	if len(terms) == 0 {
+		terms = []interface{}{map[string]interface{}{}}
+	}
+	for index, term := range terms {
+		termMap, ok := term.(map[string]interface{})
+		if !ok {
+			termMap = map[string]interface{}{}
+		}
+		matchFields, _, _ := unstructured.NestedSlice(termMap, "matchFields")
+		termMap["matchFields"] = append(matchFields, nodeRequirement)
+		terms[index] = termMap
+	}
+	unstructured.SetNestedSlice(specObject, terms, fields...)
+	return specObject
+}
@@ -0,0 +1,351 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+
+package controllers
+
+///////////////////////////////////////////////////////////////////////////
+//
+// registry.go
+//
+// RegistryClient
+// - list tags and get manifest digest of image repository via registry v2 API
+// - get manifest and blob (for OCI artifact of benchmarkSpec),
+//   blob is limited in size and verified against its digest
+// - authenticate by basic auth or bearer token (WWW-Authenticate challenge)
+// FilterImageTags
+// - filter tags by regular expression and semantic version constraint
+// GetRegistryCredential
+// - read username/password from dockerconfigjson or basic-auth secret
+//
+////////////////////////////////////////////////////////////////////////////
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	DEFAULT_REGISTRY       = "registry-1.docker.io"
+	DOCKER_HUB_REGISTRY    = "docker.io"
+	REGISTRY_TIMEOUT       = 30 * time.Second
+	DIGEST_HEADER          = "Docker-Content-Digest"
+	AUTHENTICATE_HEADER    = "WWW-Authenticate"
+	MANIFEST_ACCEPT_HEADER = "application/vnd.oci.image.index.v1+json, application/vnd.docker.distribution.manifest.list.v2+json, application/vnd.oci.image.manifest.v1+json, application/vnd.docker.distribution.manifest.v2+json"
+)
+
+var linkNextRegex *regexp.Regexp = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+var challengeParamRegex *regexp.Regexp = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+type RegistryClient struct {
+	Registry string
+	Username string
+	Password string
+	Insecure bool
+	client   *http.Client
+	token    string
+}
+
+// ParseImageRepository splits image repository into registry host and repository path
+func ParseImageRepository(image string) (registry string, repository string) {
+	image = strings.Split(image, "@")[0]
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		registry, repository = parts[0], parts[1]
+	} else {
+		registry, repository = DEFAULT_REGISTRY, image
+	}
+	if registry == DOCKER_HUB_REGISTRY {
+		registry = DEFAULT_REGISTRY
+	}
+	if registry == DEFAULT_REGISTRY && !strings.Contains(repository, "/") {
+		repository = "library/" + repository
+	}
+	// remove tag
+	lastSlash := strings.LastIndex(repository, "/")
+	if colon := strings.LastIndex(repository, ":"); colon > lastSlash {
+		repository = repository[:colon]
+	}
+	return registry, repository
+}
+
+// GetImageReference returns tag or digest of the image (default: latest)
+func GetImageReference(image string) string {
+	if at := strings.LastIndex(image, "@"); at >= 0 {
+		return image[at+1:]
+	}
+	lastSlash := strings.LastIndex(image, "/")
+	if colon := strings.LastIndex(image, ":"); colon > lastSlash {
+		return image[colon+1:]
+	}
+	return "latest"
+}
+
+func NewRegistryClient(registry string, username string, password string, insecure bool) *RegistryClient {
+	return &RegistryClient{
+		Registry: registry,
+		Username: username,
+		Password: password,
+		Insecure: insecure,
+		client:   &http.Client{Timeout: REGISTRY_TIMEOUT},
+	}
+}
+
+func (c *RegistryClient) getURL(path string) string {
+	scheme := "https"
+	if c.Insecure {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, c.Registry, path)
+}
+
+// getToken requests bearer token from the realm in the challenge
+func (c *RegistryClient) getToken(challenge string) error {
+	params := make(map[string]string)
+	for _, match := range challengeParamRegex.FindAllStringSubmatch(challenge, -1) {
+		params[strings.ToLower(match[1])] = match[2]
+	}
+	realm, ok := params["realm"]
+	if !ok {
+		return fmt.Errorf("no realm in challenge: %s", challenge)
+	}
+	query := url.Values{}
+	if service, ok := params["service"]; ok {
+		query.Set("service", service)
+	}
+	if scope, ok := params["scope"]; ok {
+		query.Set("scope", scope)
+	}
+	req, err := http.NewRequest(http.MethodGet, realm+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("token request failed: %s", resp.Status)
+	}
+	var tokenResponse struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&tokenResponse); err != nil {
+		return err
+	}
+	c.token = tokenResponse.Token
+	if c.token == "" {
+		c.token = tokenResponse.AccessToken
+	}
+	return nil
+}
+
+func (c *RegistryClient) setAuth(req *http.Request) {
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	} else if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+}
+
+// do sends request and retries once with bearer token if challenged
+func (c *RegistryClient) do(method string, requestURL string, accept string) (*http.Response, error) {
+	for retry := 0; ; retry++ {
+		req, err := http.NewRequest(method, requestURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		c.setAuth(req)
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		challenge := resp.Header.Get(AUTHENTICATE_HEADER)
+		if resp.StatusCode == http.StatusUnauthorized && retry == 0 && strings.HasPrefix(strings.ToLower(challenge), "bearer") {
+			resp.Body.Close()
+			if err = c.getToken(challenge); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("%s %s: %s", method, requestURL, resp.Status)
+		}
+		return resp, nil
+	}
+}
+
+// ListTags returns all tags of the repository
+func (c *RegistryClient) ListTags(repository string) ([]string, error) {
+	var tags []string
+	requestURL := c.getURL(fmt.Sprintf("/v2/%s/tags/list", repository))
+	for requestURL != "" {
+		resp, err := c.do(http.MethodGet, requestURL, "")
+		if err != nil {
+			return nil, err
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		var tagList struct {
+			Tags []string `json:"tags"`
+		}
+		if err = json.Unmarshal(body, &tagList); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tagList.Tags...)
+
+		// paginated by Link header
+		requestURL = ""
+		if match := linkNextRegex.FindStringSubmatch(resp.Header.Get("Link")); match != nil {
+			next, err := url.Parse(match[1])
+			if err == nil {
+				requestURL = c.getURL(next.RequestURI())
+			}
+		}
+	}
+	return tags, nil
+}
+
+// GetDigest returns manifest digest of the tag
+func (c *RegistryClient) GetDigest(repository string, tag string) (string, error) {
+	resp, err := c.do(http.MethodHead, c.getURL(fmt.Sprintf("/v2/%s/manifests/%s", repository, tag)), MANIFEST_ACCEPT_HEADER)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	return resp.Header.Get(DIGEST_HEADER), nil
+}
+
+// GetManifest returns manifest of the tag or digest
+func (c *RegistryClient) GetManifest(repository string, reference string) ([]byte, error) {
+	resp, err := c.do(http.MethodGet, c.getURL(fmt.Sprintf("/v2/%s/manifests/%s", repository, reference)), MANIFEST_ACCEPT_HEADER)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return ioutil.ReadAll(resp.Body)
+}
+
+// GetBlob returns content of the blob up to maxSize bytes after verifying its sha256 digest
+func (c *RegistryClient) GetBlob(repository string, digest string, maxSize int64) ([]byte, error) {
+	if !strings.HasPrefix(digest, "sha256:") {
+		return nil, fmt.Errorf("unsupported digest %s", digest)
+	}
+	resp, err := c.do(http.MethodGet, c.getURL(fmt.Sprintf("/v2/%s/blobs/%s", repository, digest)), "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	blob, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(blob)) > maxSize {
+		return nil, fmt.Errorf("blob %s: larger than %d bytes", digest, maxSize)
+	}
+	sum := sha256.Sum256(blob)
+	if "sha256:"+hex.EncodeToString(sum[:]) != digest {
+		return nil, fmt.Errorf("blob %s: digest mismatch", digest)
+	}
+	return blob, nil
+}
+
+// FilterImageTags returns tags that match the regular expression and semantic version constraint
+// sorted by semantic version if the constraint is set
+func FilterImageTags(tags []string, tagFilter string, semverConstraint string) ([]string, error) {
+	var tagRegex *regexp.Regexp
+	var constraint *semver.Constraints
+	var err error
+	if tagFilter != "" {
+		if tagRegex, err = regexp.Compile(tagFilter); err != nil {
+			return nil, err
+		}
+	}
+	if semverConstraint != "" {
+		if constraint, err = semver.NewConstraint(semverConstraint); err != nil {
+			return nil, err
+		}
+	}
+	var filteredTags []string
+	versions := make(map[string]*semver.Version)
+	for _, tag := range tags {
+		if tagRegex != nil && !tagRegex.MatchString(tag) {
+			continue
+		}
+		if constraint != nil {
+			version, err := semver.NewVersion(tag)
+			if err != nil || !constraint.Check(version) {
+				continue
+			}
+			versions[tag] = version
+		}
+		filteredTags = append(filteredTags, tag)
+	}
+	if constraint != nil {
+		sort.SliceStable(filteredTags, func(i, j int) bool {
+			return versions[filteredTags[i]].LessThan(versions[filteredTags[j]])
+		})
+	}
+	return filteredTags, nil
+}
+
+// GetRegistryCredential returns username and password for the registry from the secret
+func GetRegistryCredential(secret *corev1.Secret, registry string) (string, string) {
+	if dockerConfig, ok := secret.Data[corev1.DockerConfigJsonKey]; ok {
+		var config struct {
+			Auths map[string]struct {
+				Username string `json:"username"`
+				Password string `json:"password"`
+				Auth     string `json:"auth"`
+			} `json:"auths"`
+		}
+		if err := json.Unmarshal(dockerConfig, &config); err != nil {
+			return "", ""
+		}
+		for server, auth := range config.Auths {
+			host := strings.TrimPrefix(strings.TrimPrefix(server, "https://"), "http://")
+			host = strings.Split(host, "/")[0]
+			if host != registry && !(registry == DEFAULT_REGISTRY && strings.HasSuffix(host, DOCKER_HUB_REGISTRY)) {
+				continue
+			}
+			if auth.Username != "" {
+				return auth.Username, auth.Password
+			}
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err == nil {
+				if credential := strings.SplitN(string(decoded), ":", 2); len(credential) == 2 {
+					return credential[0], credential[1]
+				}
+			}
+		}
+		return "", ""
+	}
+	return string(secret.Data[corev1.BasicAuthUsernameKey]), string(secret.Data[corev1.BasicAuthPasswordKey])
+}
@@ -0,0 +1,254 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+
+package controllers
+
+///////////////////////////////////////////////////////////////////////////
+//
+// regression.go
+//
+// GetRegressions
+// - compare each build to the previous build (or regression.baseline)
+//   of the same scenario and configuration once both have all repetitions
+// - verdict is Regressed/Improved if the relative change of mean exceeds the tolerance
+//   and Welch's t-test on repetitions is significant, otherwise Unchanged
+// WelchTTest
+// - two-sided p-value of Welch's t-test
+//
+////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+)
+
+const (
+	VERDICT_REGRESSED            = "Regressed"
+	VERDICT_IMPROVED             = "Improved"
+	VERDICT_UNCHANGED            = "Unchanged"
+	DEFAULT_REGRESSION_TOLERANCE = 0.05
+	DEFAULT_SIGNIFICANCE_LEVEL   = 0.05
+)
+
+func getVariance(values []float64, mean float64) float64 {
+	sumSquare := 0.0
+	for _, value := range values {
+		sumSquare += (value - mean) * (value - mean)
+	}
+	return sumSquare / float64(len(values)-1)
+}
+
+// betaContinuedFraction evaluates continued fraction of incomplete beta function (modified Lentz's method)
+func betaContinuedFraction(a, b, x float64) float64 {
+	const maxIteration = 200
+	const epsilon = 1e-12
+	const tiny = 1e-300
+	c := 1.0
+	d := 1.0 - (a+b)*x/(a+1.0)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1.0 / d
+	h := d
+	for m := 1; m <= maxIteration; m++ {
+		fm := float64(m)
+		for _, numerator := range []float64{
+			fm * (b - fm) * x / ((a + 2*fm - 1) * (a + 2*fm)),
+			-(a + fm) * (a + b + fm) * x / ((a + 2*fm) * (a + 2*fm + 1)),
+		} {
+			d = 1.0 + numerator*d
+			if math.Abs(d) < tiny {
+				d = tiny
+			}
+			c = 1.0 + numerator/c
+			if math.Abs(c) < tiny {
+				c = tiny
+			}
+			d = 1.0 / d
+			h *= d * c
+		}
+		if math.Abs(d*c-1.0) < epsilon {
+			break
+		}
+	}
+	return h
+}
+
+// regularizedIncompleteBeta returns I_x(a, b)
+func regularizedIncompleteBeta(a, b, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	lgab, _ := math.Lgamma(a + b)
+	lga, _ := math.Lgamma(a)
+	lgb, _ := math.Lgamma(b)
+	front := math.Exp(lgab - lga - lgb + a*math.Log(x) + b*math.Log(1-x))
+	if x < (a+1)/(a+b+2) {
+		return front * betaContinuedFraction(a, b, x) / a
+	}
+	return 1 - front*betaContinuedFraction(b, a, 1-x)/b
+}
+
+// WelchTTest returns t statistic and two-sided p-value of two samples with possibly unequal variances
+func WelchTTest(values []float64, baselineValues []float64) (float64, float64) {
+	n1, n2 := float64(len(values)), float64(len(baselineValues))
+	if n1 < 2 || n2 < 2 {
+		return 0, 1
+	}
+	mean1, mean2 := getMean(values), getMean(baselineValues)
+	se1, se2 := getVariance(values, mean1)/n1, getVariance(baselineValues, mean2)/n2
+	if se1+se2 == 0 {
+		if mean1 == mean2 {
+			return 0, 1
+		}
+		return math.Inf(int(math.Copysign(1, mean1-mean2))), 0
+	}
+	t := (mean1 - mean2) / math.Sqrt(se1+se2)
+	df := (se1 + se2) * (se1 + se2) / (se1*se1/(n1-1) + se2*se2/(n2-1))
+	pValue := regularizedIncompleteBeta(df/2, 0.5, df/(df+t*t))
+	return t, pValue
+}
+
+func getFloatOrDefault(value string, defaultValue float64) float64 {
+	if floatValue, err := strconv.ParseFloat(value, 64); err == nil && floatValue >= 0 {
+		return floatValue
+	}
+	return defaultValue
+}
+
+func getPerformanceValues(result cpev1.BenchmarkResult) []float64 {
+	var values []float64
+	for _, item := range result.Items {
+		if value, err := strconv.ParseFloat(item.PerformanceValue, 64); err == nil {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// CompareResult returns the comparison of result to baselineResult
+func CompareResult(benchmark *cpev1.Benchmark, result cpev1.BenchmarkResult, baselineResult cpev1.BenchmarkResult) cpev1.RegressionResult {
+	tolerance := DEFAULT_REGRESSION_TOLERANCE
+	significanceLevel := DEFAULT_SIGNIFICANCE_LEVEL
+	if regressionSpec := benchmark.Spec.Regression; regressionSpec != nil {
+		tolerance = getFloatOrDefault(regressionSpec.Tolerance, DEFAULT_REGRESSION_TOLERANCE)
+		significanceLevel = getFloatOrDefault(regressionSpec.SignificanceLevel, DEFAULT_SIGNIFICANCE_LEVEL)
+	}
+	values := getPerformanceValues(result)
+	baselineValues := getPerformanceValues(baselineResult)
+	mean, baselineMean := getMean(values), getMean(baselineValues)
+	change := 0.0
+	if baselineMean != 0 {
+		change = (mean - baselineMean) / math.Abs(baselineMean)
+	}
+	_, pValue := WelchTTest(values, baselineValues)
+
+	verdict := VERDICT_UNCHANGED
+	if math.Abs(change) > tolerance && pValue < significanceLevel {
+		worse := change < 0
+		if benchmark.Spec.IterationSpec.Minimize {
+			worse = change > 0
+		}
+		if worse {
+			verdict = VERDICT_REGRESSED
+		} else {
+			verdict = VERDICT_IMPROVED
+		}
+	}
+	performanceKey := ""
+	if len(result.Items) > 0 {
+		performanceKey = result.Items[0].PerformanceKey
+	}
+	return cpev1.RegressionResult{
+		BuildID:         result.BuildID,
+		BaselineID:      baselineResult.BuildID,
+		IterationID:     result.IterationID,
+		ConfigurationID: result.ConfigurationID,
+		PerformanceKey:  performanceKey,
+		BaselineMean:    fmt.Sprintf("%f", baselineMean),
+		Mean:            fmt.Sprintf("%f", mean),
+		Change:          fmt.Sprintf("%f", change),
+		PValue:          fmt.Sprintf("%f", pValue),
+		Verdict:         verdict,
+	}
+}
+
+// GetRegressions compares each completed result to the completed result of its baseline build
+func GetRegressions(benchmark *cpev1.Benchmark) []cpev1.RegressionResult {
+	_, _, _, maxRepetition := GetIteratedValues(benchmark)
+	baselineBuild := ""
+	if benchmark.Spec.Regression != nil {
+		baselineBuild = benchmark.Spec.Regression.Baseline
+	}
+	// bisection builds are compared by bisection.go
+	var builds []string
+	buildOrder := make(map[string]int)
+	for _, build := range benchmark.Status.TrackedBuilds {
+		if !isBisectionBuild(benchmark, build) {
+			buildOrder[build] = len(builds)
+			builds = append(builds, build)
+		}
+	}
+
+	// completed results of each scenario and configuration
+	scenarioResults := make(map[string]map[string]cpev1.BenchmarkResult)
+	for _, result := range benchmark.Status.Results {
+		if len(getPerformanceValues(result)) < maxRepetition {
+			continue
+		}
+		scenarioKey := result.IterationID + "/" + result.ConfigurationID
+		if _, exists := scenarioResults[scenarioKey]; !exists {
+			scenarioResults[scenarioKey] = make(map[string]cpev1.BenchmarkResult)
+		}
+		scenarioResults[scenarioKey][result.BuildID] = result
+	}
+
+	var regressions []cpev1.RegressionResult
+	for _, result := range benchmark.Status.Results {
+		buildResults := scenarioResults[result.IterationID+"/"+result.ConfigurationID]
+		if _, completed := buildResults[result.BuildID]; !completed || isBisectionBuild(benchmark, result.BuildID) {
+			continue
+		}
+		var baselineResult cpev1.BenchmarkResult
+		found := false
+		if baselineBuild != "" {
+			baselineResult, found = buildResults[baselineBuild]
+			found = found && baselineBuild != result.BuildID
+		} else if order, tracked := buildOrder[result.BuildID]; tracked {
+			// previous tracked build with completed result
+			for index := order - 1; index >= 0 && !found; index-- {
+				baselineResult, found = buildResults[builds[index]]
+			}
+		}
+		if found {
+			regressions = append(regressions, CompareResult(benchmark, result, baselineResult))
+		}
+	}
+	return regressions
+}
+
+// GetNewVerdicts returns Regressed/Improved comparisons that are not in the previous list
+func GetNewVerdicts(previous []cpev1.RegressionResult, current []cpev1.RegressionResult) []cpev1.RegressionResult {
+	previousVerdict := make(map[string]string)
+	for _, regression := range previous {
+		previousVerdict[regression.BuildID+"/"+regression.BaselineID+"/"+regression.IterationID+"/"+regression.ConfigurationID] = regression.Verdict
+	}
+	var newVerdicts []cpev1.RegressionResult
+	for _, regression := range current {
+		if regression.Verdict == VERDICT_UNCHANGED {
+			continue
+		}
+		if previousVerdict[regression.BuildID+"/"+regression.BaselineID+"/"+regression.IterationID+"/"+regression.ConfigurationID] != regression.Verdict {
+			newVerdicts = append(newVerdicts, regression)
+		}
+	}
+	return newVerdicts
+}
@@ -0,0 +1,315 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+
+package controllers
+
+///////////////////////////////////////////////////////////////////////////
+//
+// scenario_resource.go
+//
+// GetScenarioResources
+// - render resources (e.g., server Deployment and Service) of the scenario
+//   with the same template values as benchmarkSpec and .role,
+//   named [job name]-[role] and owned by the benchmark
+// DeployScenarioResources
+// - create resources and check their readiness without waiting (re-checked until ready or timeout)
+//   (benchmark job is the only resource tracked for completion and logs)
+// DeleteScenarioResources
+// - delete resources once the benchmark job is completed (except keep)
+// IsScenarioResourcesDeleted
+// - check whether deleted resources are gone before the next scenario is prepared
+//
+////////////////////////////////////////////////////////////////////////////
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/serializer/yaml"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+)
+
+const (
+	SCENARIO_RESOURCE_LABEL     = "cpe-benchmark-resource"
+	STEP_RESOURCES              = "resources"
+	ROLE_LABEL                  = "cpe-role"
+	TEMPLATE_ROLE_KEY           = "role"
+	TEMPLATE_RESOURCES_KEY      = "resources"
+	DEFAULT_READINESS_TIMEOUT   = 300
+	READINESS_POLL_INTERVAL     = 5 * time.Second
+	DEFAULT_READY_REPLICAS_PATH = ".status.readyReplicas"
+)
+
+// GetScenarioResourceName returns name of the resource of the job
+func GetScenarioResourceName(jobName string, role string) string {
+	return jobName + "-" + role
+}
+
+// GetScenarioResourceValue returns names of resources to be referred as {{ .resources.[role].name }}
+func GetScenarioResourceValue(benchmark *cpev1.Benchmark, jobName string) map[string]interface{} {
+	resourceValue := make(map[string]interface{})
+	for _, resourceSpec := range benchmark.Spec.Resources {
+		resourceValue[resourceSpec.Role] = map[string]interface{}{
+			"name": GetScenarioResourceName(jobName, resourceSpec.Role),
+		}
+	}
+	return resourceValue
+}
+
+func getHashItem(benchmark *cpev1.Benchmark, jobName string) (cpev1.IterationHash, bool) {
+	splited := strings.Split(jobName, HASH_DELIMIT)
+	targetHash := splited[len(splited)-1]
+	for _, hashItem := range benchmark.Status.Hash {
+		if hashItem.Hash == targetHash {
+			return hashItem, true
+		}
+	}
+	return cpev1.IterationHash{}, false
+}
+
+// renderResource returns resource rendered from the template, named and owned by the benchmark
+func renderResource(benchmark *cpev1.Benchmark, resourceSpec cpev1.ScenarioResourceSpec, templateContext map[string]interface{}, name string, labels map[string]string) (*unstructured.Unstructured, error) {
+	templateContext[TEMPLATE_ROLE_KEY] = resourceSpec.Role
+	executedSpec, err := ExecuteBenchmarkTemplate(resourceSpec.Template, templateContext)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", resourceSpec.Role, err)
+	}
+	var decUnstructured = yaml.NewDecodingSerializer(unstructured.UnstructuredJSONScheme)
+	obj := &unstructured.Unstructured{}
+	if _, _, err = decUnstructured.Decode([]byte(executedSpec), nil, obj); err != nil {
+		return nil, fmt.Errorf("%s: %v", resourceSpec.Role, err)
+	}
+	objLabels := obj.GetLabels()
+	if objLabels == nil {
+		objLabels = make(map[string]string)
+	}
+	for key, value := range labels {
+		objLabels[key] = value
+	}
+	objLabels[ROLE_LABEL] = resourceSpec.Role
+	obj.SetLabels(objLabels)
+	obj.SetName(name)
+	obj.SetNamespace(benchmark.Namespace)
+	obj.SetOwnerReferences([]metav1.OwnerReference{{
+		APIVersion: cpev1.GroupVersion.String(),
+		Kind:       "Benchmark",
+		Name:       benchmark.GetName(),
+		UID:        benchmark.GetUID(),
+	}})
+	return obj, nil
+}
+
+// GetScenarioResources returns rendered resources of the job
+func GetScenarioResources(benchmark *cpev1.Benchmark, hashItem cpev1.IterationHash) ([]*unstructured.Unstructured, error) {
+	jobName := getJobNameFromHash(benchmark.GetName(), hashItem.Hash)
+	repetition, _ := strconv.Atoi(hashItem.Repetition)
+	labels := map[string]string{SCENARIO_RESOURCE_LABEL: benchmark.GetName(), JOBHASH_KEY: hashItem.Hash}
+	var resources []*unstructured.Unstructured
+	for _, resourceSpec := range benchmark.Spec.Resources {
+		templateContext := GetTemplateContext(benchmark, hashItem.Iteration, hashItem.Build, repetition, jobName)
+		obj, err := renderResource(benchmark, resourceSpec, templateContext, GetScenarioResourceName(jobName, resourceSpec.Role), labels)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, obj)
+	}
+	return resources, nil
+}
+
+func getScenarioResourceInterface(dc *discovery.DiscoveryClient, dyn dynamic.Interface, obj *unstructured.Unstructured) (dynamic.ResourceInterface, error) {
+	gvk := obj.GroupVersionKind()
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(dc))
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, err
+	}
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return nil, fmt.Errorf("%s is not namespaced", gvk.Kind)
+	}
+	return dyn.Resource(mapping.Resource).Namespace(obj.GetNamespace()), nil
+}
+
+func getStatusValue(obj *unstructured.Unstructured, path string) (string, bool) {
+	value, found, err := unstructured.NestedFieldNoCopy(obj.Object, strings.Split(strings.TrimPrefix(path, "."), ".")...)
+	if !found || err != nil || value == nil {
+		return "", false
+	}
+	return fmt.Sprintf("%v", value), true
+}
+
+func isConditionTrue(obj *unstructured.Unstructured, conditionType string) bool {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, condition := range conditions {
+		if conditionMap, ok := condition.(map[string]interface{}); ok && conditionMap["type"] == conditionType {
+			return conditionMap["status"] == "True"
+		}
+	}
+	return false
+}
+
+// IsResourceReady checks readiness of the resource (default by kind: ready replicas, ready pod, succeeded job)
+func IsResourceReady(obj *unstructured.Unstructured, readiness *cpev1.ReadinessSpec) bool {
+	if readiness != nil && (readiness.Condition != "" || readiness.Path != "") {
+		if readiness.Condition != "" && !isConditionTrue(obj, readiness.Condition) {
+			return false
+		}
+		if readiness.Path != "" {
+			value, found := getStatusValue(obj, readiness.Path)
+			if readiness.Value != "" {
+				return found && value == readiness.Value
+			}
+			return found && value != "" && value != "0" && value != "false"
+		}
+		return true
+	}
+	switch obj.GetKind() {
+	case "Deployment", "StatefulSet", "ReplicaSet":
+		replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+		if !found {
+			replicas = 1
+		}
+		readyReplicas, _, _ := unstructured.NestedInt64(obj.Object, "status", "readyReplicas")
+		return readyReplicas >= replicas
+	case "DaemonSet":
+		desired, _, _ := unstructured.NestedInt64(obj.Object, "status", "desiredNumberScheduled")
+		ready, _, _ := unstructured.NestedInt64(obj.Object, "status", "numberReady")
+		return desired > 0 && ready >= desired
+	case "Pod":
+		return isConditionTrue(obj, "Ready")
+	case "Job":
+		succeeded, _, _ := unstructured.NestedInt64(obj.Object, "status", "succeeded")
+		return succeeded > 0
+	}
+	return true
+}
+
+// DeployScenarioResources creates resources of the job if not exist, return true if all of them are ready
+func DeployScenarioResources(dc *discovery.DiscoveryClient, dyn dynamic.Interface, benchmark *cpev1.Benchmark, jobName string) (bool, error) {
+	if len(benchmark.Spec.Resources) == 0 {
+		return true, nil
+	}
+	stepErr := func(err error) error {
+		return &HookError{Hook: STEP_RESOURCES, JobName: jobName, Err: err}
+	}
+	hashItem, found := getHashItem(benchmark, jobName)
+	if !found {
+		return false, stepErr(fmt.Errorf("no hash"))
+	}
+	resources, err := GetScenarioResources(benchmark, hashItem)
+	if err != nil {
+		return false, stepErr(err)
+	}
+	return deployResources(dc, dyn, resources, benchmark.Spec.Resources, stepErr)
+}
+
+func getReadinessTimeout(readiness *cpev1.ReadinessSpec) time.Duration {
+	timeout := int32(DEFAULT_READINESS_TIMEOUT)
+	if readiness != nil && readiness.TimeoutSeconds > 0 {
+		timeout = readiness.TimeoutSeconds
+	}
+	return time.Duration(timeout) * time.Second
+}
+
+// deployResources creates resources if not exist and checks their readiness without waiting,
+// return step error if a resource is rejected or not ready within its timeout since its creation
+// (other API errors are returned as is to retry)
+func deployResources(dc *discovery.DiscoveryClient, dyn dynamic.Interface, resources []*unstructured.Unstructured, resourceSpecs []cpev1.ScenarioResourceSpec, stepErr func(error) error) (bool, error) {
+	ready := true
+	for index, resource := range resources {
+		dr, err := getScenarioResourceInterface(dc, dyn, resource)
+		if err != nil {
+			return false, err
+		}
+		current, err := dr.Get(context.TODO(), resource.GetName(), metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			current, err = dr.Create(context.TODO(), resource, metav1.CreateOptions{})
+			if errors.IsAlreadyExists(err) {
+				ready = false
+				continue
+			}
+		}
+		if isRejected(err) {
+			return false, stepErr(err)
+		}
+		if err != nil {
+			return false, err
+		}
+		readiness := resourceSpecs[index].Readiness
+		if IsResourceReady(current, readiness) {
+			continue
+		}
+		ready = false
+		timeout := getReadinessTimeout(readiness)
+		if time.Since(current.GetCreationTimestamp().Time) > timeout {
+			return false, stepErr(fmt.Errorf("%s is not ready in %v", resource.GetName(), timeout))
+		}
+	}
+	return ready, nil
+}
+
+// DeleteScenarioResources deletes resources of the job except the ones to keep
+func DeleteScenarioResources(dc *discovery.DiscoveryClient, dyn dynamic.Interface, benchmark *cpev1.Benchmark, hashItem cpev1.IterationHash) error {
+	resources, err := GetScenarioResources(benchmark, hashItem)
+	if err != nil {
+		return err
+	}
+	propagation := metav1.DeletePropagationBackground
+	for index, resource := range resources {
+		if benchmark.Spec.Resources[index].Keep {
+			continue
+		}
+		dr, resourceErr := getScenarioResourceInterface(dc, dyn, resource)
+		if resourceErr == nil {
+			resourceErr = dr.Delete(context.TODO(), resource.GetName(), metav1.DeleteOptions{PropagationPolicy: &propagation})
+		}
+		if resourceErr != nil && !errors.IsNotFound(resourceErr) {
+			err = resourceErr
+		}
+	}
+	return err
+}
+
+// IsScenarioResourcesDeleted returns true if the deleted resources of the job are gone,
+// return error if a resource still exists after its readiness timeout since the deletion
+func IsScenarioResourcesDeleted(dc *discovery.DiscoveryClient, dyn dynamic.Interface, benchmark *cpev1.Benchmark, hashItem cpev1.IterationHash) (bool, error) {
+	resources, err := GetScenarioResources(benchmark, hashItem)
+	if err != nil {
+		return true, err
+	}
+	for index, resource := range resources {
+		if benchmark.Spec.Resources[index].Keep {
+			continue
+		}
+		dr, err := getScenarioResourceInterface(dc, dyn, resource)
+		if err != nil {
+			return true, err
+		}
+		current, err := dr.Get(context.TODO(), resource.GetName(), metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return false, nil
+		}
+		if deletionTimestamp := current.GetDeletionTimestamp(); deletionTimestamp != nil {
+			if timeout := getReadinessTimeout(benchmark.Spec.Resources[index].Readiness); time.Since(deletionTimestamp.Time) > timeout {
+				return true, fmt.Errorf("%s is not deleted in %v", resource.GetName(), timeout)
+			}
+		}
+		return false, nil
+	}
+	return true, nil
+}
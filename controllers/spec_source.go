@@ -0,0 +1,245 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+
+package controllers
+
+///////////////////////////////////////////////////////////////////////////
+//
+// spec_source.go
+//
+// ResolveBenchmarkSpec
+// - get benchmarkSpec template from benchmarkSpecFrom
+//   (ConfigMap key, Secret key, HTTP URL, or OCI artifact)
+//   and its revision (hash of the template)
+// - ConfigMap and Secret are read by API reader (not cached),
+//   URL and OCI artifact are fetched at most once per poll interval and limited to MAX_SPEC_SIZE
+//   (OCI artifact is also verified against its layer digest)
+// - reject benchmark with neither benchmarkSpec nor benchmarkSpecFrom
+// StartSpecRevision
+// - move results of the previous revision to resultHistory
+//   and reset job hash so that the new revision runs as a new result set
+//   (revision is also part of job hash, see common.go)
+//
+////////////////////////////////////////////////////////////////////////////
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	OCI_TITLE_ANNOTATION          = "org.opencontainers.image.title"
+	DEFAULT_SPEC_POLL_INTERVAL    = 300
+	SPEC_SOURCE_TIMEOUT           = 30 * time.Second
+	INLINE_SPEC_REVISION          = "inline"
+	MAX_SPEC_REVISION_HISTORY_LEN = 10
+	MAX_SPEC_SIZE                 = 1 << 20
+)
+
+type fetchedSpec struct {
+	spec      string
+	fetchedAt time.Time
+}
+
+// specURLCache keeps the template fetched from each URL (or OCI artifact image and file) until the poll interval passes
+var specURLCache map[string]fetchedSpec = make(map[string]fetchedSpec)
+var specURLCacheMutex sync.Mutex
+
+// GetSpecRevision returns revision of the template
+func GetSpecRevision(spec string) string {
+	h := fnv.New32a()
+	h.Write([]byte(spec))
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+// GetSpecPollInterval returns interval to check a new revision of benchmarkSpecFrom
+func GetSpecPollInterval(benchmark *cpev1.Benchmark) time.Duration {
+	interval := int32(DEFAULT_SPEC_POLL_INTERVAL)
+	if specFrom := benchmark.Spec.SpecFrom; specFrom != nil && specFrom.PollIntervalSeconds > 0 {
+		interval = specFrom.PollIntervalSeconds
+	}
+	return time.Duration(interval) * time.Second
+}
+
+// ResolveBenchmarkSpec returns the template and its revision (empty for inline benchmarkSpec)
+func ResolveBenchmarkSpec(c client.Reader, benchmark *cpev1.Benchmark) (string, string, error) {
+	specFrom := benchmark.Spec.SpecFrom
+	if specFrom == nil {
+		if benchmark.Spec.Spec == "" {
+			return "", "", fmt.Errorf("neither benchmarkSpec nor benchmarkSpecFrom is set")
+		}
+		return benchmark.Spec.Spec, "", nil
+	}
+	var spec string
+	var err error
+	switch {
+	case specFrom.ConfigMapKeyRef != nil:
+		spec, err = getSpecFromConfigMap(c, benchmark.Namespace, specFrom.ConfigMapKeyRef)
+	case specFrom.SecretKeyRef != nil:
+		spec, err = getSpecFromSecret(c, benchmark.Namespace, specFrom.SecretKeyRef)
+	case specFrom.URL != "":
+		spec, err = getSpecFromURL(specFrom.URL, GetSpecPollInterval(benchmark))
+	case specFrom.OCI != nil:
+		spec, err = getSpecFromOCI(c, benchmark.Namespace, specFrom.OCI, GetSpecPollInterval(benchmark))
+	default:
+		err = fmt.Errorf("no source in benchmarkSpecFrom")
+	}
+	if err != nil {
+		return "", "", err
+	}
+	return spec, GetSpecRevision(spec), nil
+}
+
+func getSpecFromConfigMap(c client.Reader, namespace string, keyRef *corev1.ConfigMapKeySelector) (string, error) {
+	configMap := &corev1.ConfigMap{}
+	err := c.Get(context.Background(), types.NamespacedName{Name: keyRef.Name, Namespace: namespace}, configMap)
+	if err != nil {
+		return "", err
+	}
+	if spec, ok := configMap.Data[keyRef.Key]; ok {
+		return spec, nil
+	}
+	if spec, ok := configMap.BinaryData[keyRef.Key]; ok {
+		return string(spec), nil
+	}
+	return "", fmt.Errorf("no key %s in configmap %s", keyRef.Key, keyRef.Name)
+}
+
+func getSpecFromSecret(c client.Reader, namespace string, keyRef *corev1.SecretKeySelector) (string, error) {
+	secret := &corev1.Secret{}
+	err := c.Get(context.Background(), types.NamespacedName{Name: keyRef.Name, Namespace: namespace}, secret)
+	if err != nil {
+		return "", err
+	}
+	if spec, ok := secret.Data[keyRef.Key]; ok {
+		return string(spec), nil
+	}
+	return "", fmt.Errorf("no key %s in secret %s", keyRef.Key, keyRef.Name)
+}
+
+func getSpecFromURL(specURL string, pollInterval time.Duration) (string, error) {
+	specURLCacheMutex.Lock()
+	defer specURLCacheMutex.Unlock()
+	if cached, ok := specURLCache[specURL]; ok && time.Since(cached.fetchedAt) < pollInterval {
+		return cached.spec, nil
+	}
+	httpClient := &http.Client{Timeout: SPEC_SOURCE_TIMEOUT}
+	resp, err := httpClient.Get(specURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GET %s: %s", specURL, resp.Status)
+	}
+	bodyBytes, err := ioutil.ReadAll(io.LimitReader(resp.Body, MAX_SPEC_SIZE+1))
+	if err != nil {
+		return "", err
+	}
+	if len(bodyBytes) > MAX_SPEC_SIZE {
+		return "", fmt.Errorf("GET %s: larger than %d bytes", specURL, MAX_SPEC_SIZE)
+	}
+	specURLCache[specURL] = fetchedSpec{spec: string(bodyBytes), fetchedAt: time.Now()}
+	return string(bodyBytes), nil
+}
+
+// GetArtifactLayerDigest returns digest of the layer titled file (default: first layer) in the OCI manifest
+func GetArtifactLayerDigest(manifest []byte, file string) (string, error) {
+	var artifactManifest struct {
+		Layers []struct {
+			Digest      string            `json:"digest"`
+			Annotations map[string]string `json:"annotations"`
+		} `json:"layers"`
+	}
+	if err := json.Unmarshal(manifest, &artifactManifest); err != nil {
+		return "", err
+	}
+	for _, layer := range artifactManifest.Layers {
+		if file == "" || layer.Annotations[OCI_TITLE_ANNOTATION] == file {
+			return layer.Digest, nil
+		}
+	}
+	return "", fmt.Errorf("no layer %s in artifact", file)
+}
+
+func getSpecFromOCI(c client.Reader, namespace string, artifact *cpev1.OCIArtifactSource, pollInterval time.Duration) (string, error) {
+	cacheKey := artifact.Image + "#" + artifact.File
+	specURLCacheMutex.Lock()
+	defer specURLCacheMutex.Unlock()
+	if cached, ok := specURLCache[cacheKey]; ok && time.Since(cached.fetchedAt) < pollInterval {
+		return cached.spec, nil
+	}
+	registry, repository := ParseImageRepository(artifact.Image)
+	username, password := "", ""
+	if artifact.Secret != "" {
+		secret := &corev1.Secret{}
+		err := c.Get(context.Background(), types.NamespacedName{Name: artifact.Secret, Namespace: namespace}, secret)
+		if err != nil {
+			return "", err
+		}
+		username, password = GetRegistryCredential(secret, registry)
+	}
+	registryClient := NewRegistryClient(registry, username, password, artifact.Insecure)
+	manifest, err := registryClient.GetManifest(repository, GetImageReference(artifact.Image))
+	if err != nil {
+		return "", err
+	}
+	digest, err := GetArtifactLayerDigest(manifest, artifact.File)
+	if err != nil {
+		return "", err
+	}
+	spec, err := registryClient.GetBlob(repository, digest, MAX_SPEC_SIZE)
+	if err != nil {
+		return "", err
+	}
+	specURLCache[cacheKey] = fetchedSpec{spec: string(spec), fetchedAt: time.Now()}
+	return string(spec), nil
+}
+
+// StartSpecRevision moves results to history if the revision is changed,
+// return job hash of the previous revision to clean up and whether the revision is changed
+func StartSpecRevision(benchmark *cpev1.Benchmark, revision string) ([]cpev1.IterationHash, bool) {
+	status := &benchmark.Status
+	if status.SpecRevision == revision {
+		return nil, false
+	}
+	previousHash := status.Hash
+	if len(status.Results) > 0 {
+		previousRevision := status.SpecRevision
+		if previousRevision == "" {
+			previousRevision = INLINE_SPEC_REVISION
+		}
+		status.ResultHistory = append(status.ResultHistory, cpev1.RevisionResults{
+			Revision:    previousRevision,
+			Results:     status.Results,
+			BestResults: status.BestResults,
+		})
+		if len(status.ResultHistory) > MAX_SPEC_REVISION_HISTORY_LEN {
+			status.ResultHistory = status.ResultHistory[len(status.ResultHistory)-MAX_SPEC_REVISION_HISTORY_LEN:]
+		}
+	}
+	status.SpecRevision = revision
+	status.Hash = nil
+	status.Results = nil
+	status.BestResults = nil
+	status.Regressions = nil
+	status.Bisections = nil
+	status.BaselineResults = nil
+	status.FailedJobs = nil
+	status.JobCompleted = GetJobCompletedStatus(benchmark)
+	return previousHash, true
+}
@@ -0,0 +1,93 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+
+package controllers
+
+///////////////////////////////////////////////////////////////////////////
+//
+// stats.go
+//
+// GetResultSummary
+// - compute mean and standard deviation of performance values over repetitions
+// - split variance into between-node and within-node components (one-way random-effects ANOVA by node)
+//   only if some node runs more than one repetition (repetitions must outnumber nodes)
+//
+////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+)
+
+func getMean(values []float64) float64 {
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+	}
+	return sum / float64(len(values))
+}
+
+// GetResultSummary returns statistics of valid performance values in items
+func GetResultSummary(items []cpev1.BenchmarkResultItem) *cpev1.ResultSummary {
+	var values []float64
+	nodeValues := make(map[string][]float64)
+	for _, item := range items {
+		value, err := strconv.ParseFloat(item.PerformanceValue, 64)
+		if err != nil {
+			continue
+		}
+		values = append(values, value)
+		nodeValues[item.NodeName] = append(nodeValues[item.NodeName], value)
+	}
+	count := len(values)
+	if count == 0 {
+		return nil
+	}
+	mean := getMean(values)
+	sumSquare := 0.0
+	for _, value := range values {
+		sumSquare += (value - mean) * (value - mean)
+	}
+	stdDev := 0.0
+	if count > 1 {
+		stdDev = math.Sqrt(sumSquare / float64(count-1))
+	}
+	summary := &cpev1.ResultSummary{
+		Count:  count,
+		Mean:   fmt.Sprintf("%f", mean),
+		StdDev: fmt.Sprintf("%f", stdDev),
+	}
+
+	// variance components need at least two nodes and one node with repeated values
+	nodeCount := len(nodeValues)
+	if _, unknown := nodeValues[""]; unknown {
+		return summary
+	}
+	summary.NodeCount = nodeCount
+	if nodeCount < 2 || count <= nodeCount {
+		return summary
+	}
+	betweenSquare := 0.0
+	withinSquare := 0.0
+	sumGroupSquare := 0.0
+	for _, groupValues := range nodeValues {
+		groupMean := getMean(groupValues)
+		betweenSquare += float64(len(groupValues)) * (groupMean - mean) * (groupMean - mean)
+		for _, value := range groupValues {
+			withinSquare += (value - groupMean) * (value - groupMean)
+		}
+		sumGroupSquare += float64(len(groupValues) * len(groupValues))
+	}
+	betweenMeanSquare := betweenSquare / float64(nodeCount-1)
+	withinMeanSquare := withinSquare / float64(count-nodeCount)
+	// expected mean squares: MSW = within, MSB = within + n0 * between (n0: adjusted repetitions per node)
+	n0 := (float64(count) - sumGroupSquare/float64(count)) / float64(nodeCount-1)
+	summary.BetweenNodeVariance = fmt.Sprintf("%f", math.Max(0, (betweenMeanSquare-withinMeanSquare)/n0))
+	summary.WithinNodeVariance = fmt.Sprintf("%f", withinMeanSquare)
+	return summary
+}
@@ -0,0 +1,195 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+
+package controllers
+
+///////////////////////////////////////////////////////////////////////////
+//
+// sut.go (system under test)
+//
+// GetSystemUnderTest
+// - render resources of the system under test (e.g., database cluster)
+//   with configuration values only (iteration values are not applied),
+//   named [benchmark]-sut-[configuration hash]-[role] and owned by the benchmark
+// DeploySystemUnderTest
+// - before creating the benchmark job, delete the system of other configurations (except keep),
+//   then, once they are gone, create the system of the job configuration if not exists
+//   and check its readiness without waiting (re-checked until ready or timeout)
+//   only the deletion or readiness timeout (and rendering error) fails the job, API errors are retried
+//   (jobs are grouped by configuration and run sequentially, see execution_order.go)
+// DeleteSystemUnderTest
+// - delete the system of all configurations (except keep) once all jobs are completed
+//
+////////////////////////////////////////////////////////////////////////////
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	SYSTEM_UNDER_TEST_LABEL        = "cpe-benchmark-sut"
+	SYSTEM_UNDER_TEST_CONFIG_LABEL = "cpe-sut-config"
+	TEMPLATE_SYSTEM_UNDER_TEST_KEY = "systemUnderTest"
+	STEP_SYSTEM_UNDER_TEST         = "systemUnderTest"
+)
+
+// GetConfigurationLabel returns configuration part of the iteration label
+func GetConfigurationLabel(benchmark *cpev1.Benchmark, iterationLabel map[string]string) map[string]string {
+	configurationLabel := make(map[string]string)
+	for _, item := range benchmark.Spec.IterationSpec.Configuration {
+		if value, ok := iterationLabel[item.Name]; ok {
+			configurationLabel[item.Name] = value
+		}
+	}
+	return configurationLabel
+}
+
+// GetSystemUnderTestHash returns hash of the configuration of the iteration label
+func GetSystemUnderTestHash(benchmark *cpev1.Benchmark, iterationLabel map[string]string) string {
+	h := fnv.New32a()
+	h.Write([]byte(getSubfixFromIterationLabel(GetConfigurationLabel(benchmark, iterationLabel))))
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+// GetSystemUnderTestName returns name of the resource of the configuration
+func GetSystemUnderTestName(benchmark *cpev1.Benchmark, configurationHash string, role string) string {
+	return benchmark.GetName() + "-sut-" + configurationHash + "-" + role
+}
+
+// GetSystemUnderTestValue returns names of resources to be referred as {{ .systemUnderTest.[role].name }}
+func GetSystemUnderTestValue(benchmark *cpev1.Benchmark, iterationLabel map[string]string) map[string]interface{} {
+	systemValue := make(map[string]interface{})
+	if benchmark.Spec.SystemUnderTest == nil {
+		return systemValue
+	}
+	configurationHash := GetSystemUnderTestHash(benchmark, iterationLabel)
+	for _, resourceSpec := range benchmark.Spec.SystemUnderTest.Resources {
+		systemValue[resourceSpec.Role] = map[string]interface{}{
+			"name": GetSystemUnderTestName(benchmark, configurationHash, resourceSpec.Role),
+		}
+	}
+	return systemValue
+}
+
+// GetSystemUnderTest returns rendered resources of the configuration of the iteration label
+func GetSystemUnderTest(benchmark *cpev1.Benchmark, iterationLabel map[string]string) ([]*unstructured.Unstructured, error) {
+	if benchmark.Spec.SystemUnderTest == nil {
+		return nil, nil
+	}
+	configurationLabel := GetConfigurationLabel(benchmark, iterationLabel)
+	configurationHash := GetSystemUnderTestHash(benchmark, iterationLabel)
+	labels := map[string]string{SYSTEM_UNDER_TEST_LABEL: benchmark.GetName(), SYSTEM_UNDER_TEST_CONFIG_LABEL: configurationHash}
+	var resources []*unstructured.Unstructured
+	for _, resourceSpec := range benchmark.Spec.SystemUnderTest.Resources {
+		templateContext := GetTemplateContext(benchmark, configurationLabel, GetEvaluatedBuild(benchmark), 0, "")
+		obj, err := renderResource(benchmark, resourceSpec, templateContext, GetSystemUnderTestName(benchmark, configurationHash, resourceSpec.Role), labels)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, obj)
+	}
+	return resources, nil
+}
+
+// DeploySystemUnderTest switches the system under test to the configuration of the job, return true if it is ready
+func DeploySystemUnderTest(dc *discovery.DiscoveryClient, dyn dynamic.Interface, benchmark *cpev1.Benchmark, jobName string) (bool, error) {
+	if benchmark.Spec.SystemUnderTest == nil {
+		return true, nil
+	}
+	stepErr := func(err error) error {
+		return &HookError{Hook: STEP_SYSTEM_UNDER_TEST, JobName: jobName, Err: err}
+	}
+	hashItem, found := getHashItem(benchmark, jobName)
+	if !found {
+		return false, stepErr(fmt.Errorf("no hash"))
+	}
+	// the next configuration must not share the system with the others
+	deleted, err := deleteSystemUnderTest(dc, dyn, benchmark, GetSystemUnderTestHash(benchmark, hashItem.Iteration), stepErr)
+	if err != nil {
+		return false, err
+	}
+	if !deleted {
+		return false, nil
+	}
+	resources, err := GetSystemUnderTest(benchmark, hashItem.Iteration)
+	if err != nil {
+		return false, stepErr(err)
+	}
+	return deployResources(dc, dyn, resources, benchmark.Spec.SystemUnderTest.Resources, stepErr)
+}
+
+// DeleteSystemUnderTest deletes resources of all configurations except the current one and the ones to keep without waiting
+func DeleteSystemUnderTest(dc *discovery.DiscoveryClient, dyn dynamic.Interface, benchmark *cpev1.Benchmark, currentHash string) error {
+	_, err := deleteSystemUnderTest(dc, dyn, benchmark, currentHash, func(err error) error { return err })
+	return err
+}
+
+// deleteSystemUnderTest deletes resources of all configurations except the current one and the ones to keep,
+// return true if all of them are gone, step error if a resource cannot be rendered or still exists after
+// the readiness timeout since its deletion (other API errors are returned as is to retry)
+func deleteSystemUnderTest(dc *discovery.DiscoveryClient, dyn dynamic.Interface, benchmark *cpev1.Benchmark, currentHash string, stepErr func(error) error) (bool, error) {
+	if benchmark.Spec.SystemUnderTest == nil {
+		return true, nil
+	}
+	var err, failure error
+	allDeleted := true
+	propagation := metav1.DeletePropagationBackground
+	deleted := map[string]bool{currentHash: true}
+	for _, hashItem := range benchmark.Status.Hash {
+		configurationHash := GetSystemUnderTestHash(benchmark, hashItem.Iteration)
+		if deleted[configurationHash] {
+			continue
+		}
+		deleted[configurationHash] = true
+		resources, renderErr := GetSystemUnderTest(benchmark, hashItem.Iteration)
+		if renderErr != nil {
+			failure = stepErr(renderErr)
+			continue
+		}
+		for index, resource := range resources {
+			resourceSpec := benchmark.Spec.SystemUnderTest.Resources[index]
+			if resourceSpec.Keep {
+				continue
+			}
+			dr, resourceErr := getScenarioResourceInterface(dc, dyn, resource)
+			if resourceErr != nil {
+				err = fmt.Errorf("cannot delete %s: %v", resource.GetName(), resourceErr)
+				continue
+			}
+			current, resourceErr := dr.Get(context.TODO(), resource.GetName(), metav1.GetOptions{})
+			if errors.IsNotFound(resourceErr) {
+				continue
+			}
+			allDeleted = false
+			if resourceErr == nil && current.GetDeletionTimestamp() == nil {
+				resourceErr = dr.Delete(context.TODO(), resource.GetName(), metav1.DeleteOptions{PropagationPolicy: &propagation})
+				if errors.IsNotFound(resourceErr) {
+					continue
+				}
+			} else if resourceErr == nil {
+				if timeout := getReadinessTimeout(resourceSpec.Readiness); time.Since(current.GetDeletionTimestamp().Time) > timeout {
+					failure = stepErr(fmt.Errorf("%s is not deleted in %v", resource.GetName(), timeout))
+				}
+			}
+			if resourceErr != nil {
+				err = fmt.Errorf("cannot delete %s: %v", resource.GetName(), resourceErr)
+			}
+		}
+	}
+	if failure != nil {
+		return allDeleted, failure
+	}
+	return allDeleted, err
+}
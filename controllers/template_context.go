@@ -0,0 +1,302 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+
+package controllers
+
+///////////////////////////////////////////////////////////////////////////
+//
+// template_context.go
+//
+// GetTemplateContext
+// - values referred in benchmarkSpec template:
+//   iteration values (split by ;), build, benchmark (name, namespace),
+//   repetition, jobName, clusterID, nodeProfile, resources (see scenario_resource.go),
+//   systemUnderTest (see sut.go)
+//   (iteration values take precedence over the other keys of the same name)
+// GetTemplateFuncMap
+// - functions without side effects: string, math, default, toYaml, toJson, indent, seq
+// ExecuteBenchmarkTemplate
+// - execute benchmarkSpec with the context and functions
+//
+////////////////////////////////////////////////////////////////////////////
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	TEMPLATE_BENCHMARK_KEY    = "benchmark"
+	TEMPLATE_REPETITION_KEY   = "repetition"
+	TEMPLATE_JOB_NAME_KEY     = "jobName"
+	TEMPLATE_CLUSTER_ID_KEY   = "clusterID"
+	TEMPLATE_NODE_PROFILE_KEY = "nodeProfile"
+	MAX_TEMPLATE_SEQ_LEN      = 10000
+)
+
+// GetTemplateContext returns values to be referred in benchmarkSpec
+func GetTemplateContext(benchmark *cpev1.Benchmark, iterationLabel map[string]string, build string, repetition int, jobName string) map[string]interface{} {
+	context := GetExpandLabel(iterationLabel)
+	nodeProfile := NODESELECT_ITR_DEFAULT
+	if profile, ok := iterationLabel[NODESELECT_ITR_NAME]; ok {
+		nodeProfile = profile
+	}
+	defaultValues := map[string]interface{}{
+		BUILD_KEY: GetBuildTemplateValue(benchmark, build),
+		TEMPLATE_BENCHMARK_KEY: map[string]interface{}{
+			"name":      benchmark.Name,
+			"namespace": benchmark.Namespace,
+		},
+		TEMPLATE_REPETITION_KEY:        repetition,
+		TEMPLATE_JOB_NAME_KEY:          jobName,
+		TEMPLATE_CLUSTER_ID_KEY:        CLUSTER_ID,
+		TEMPLATE_NODE_PROFILE_KEY:      nodeProfile,
+		TEMPLATE_RESOURCES_KEY:         GetScenarioResourceValue(benchmark, jobName),
+		TEMPLATE_SYSTEM_UNDER_TEST_KEY: GetSystemUnderTestValue(benchmark, iterationLabel),
+	}
+	for key, value := range defaultValues {
+		if _, ok := context[key]; !ok {
+			context[key] = value
+		}
+	}
+	return context
+}
+
+// ExecuteBenchmarkTemplate returns benchmarkSpec executed with the context
+func ExecuteBenchmarkTemplate(spec string, context map[string]interface{}) (string, error) {
+	tmpl, err := template.New("").Funcs(GetTemplateFuncMap()).Parse(spec)
+	if err != nil {
+		return "", err
+	}
+	var buffer bytes.Buffer
+	err = tmpl.Execute(&buffer, context)
+	if err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+// GetTemplateFuncMap returns functions available in benchmarkSpec
+func GetTemplateFuncMap() template.FuncMap {
+	return template.FuncMap{
+		// string
+		"toString":   toTemplateString,
+		"lower":      strings.ToLower,
+		"upper":      strings.ToUpper,
+		"trim":       strings.TrimSpace,
+		"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+		"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"split":      func(sep, s string) []string { return strings.Split(s, sep) },
+		"join":       joinTemplateList,
+		"quote":      func(value interface{}) string { return strconv.Quote(toTemplateString(value)) },
+		// math
+		"toInt":   toTemplateInt,
+		"toFloat": toTemplateNumber,
+		"add":     arithmetic(func(x, y float64) float64 { return x + y }),
+		"sub":     arithmetic(func(x, y float64) float64 { return x - y }),
+		"mul":     arithmetic(func(x, y float64) float64 { return x * y }),
+		"div":     divide,
+		"mod":     modulo,
+		"max":     arithmetic(math.Max),
+		"min":     arithmetic(math.Min),
+		"floor":   roundBy(math.Floor),
+		"ceil":    roundBy(math.Ceil),
+		"round":   roundBy(math.Round),
+		// others
+		"default": defaultValue,
+		"toYaml":  toYaml,
+		"toJson":  toJson,
+		"indent":  indent,
+		"nindent": func(spaces int, s string) string { return "\n" + indent(spaces, s) },
+		"seq":     seq,
+	}
+}
+
+func toTemplateString(value interface{}) string {
+	switch typedValue := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return typedValue
+	case float64:
+		return strconv.FormatFloat(typedValue, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+func joinTemplateList(sep string, list interface{}) string {
+	listValue := reflect.ValueOf(list)
+	if listValue.Kind() != reflect.Slice && listValue.Kind() != reflect.Array {
+		return toTemplateString(list)
+	}
+	var items []string
+	for index := 0; index < listValue.Len(); index++ {
+		items = append(items, toTemplateString(listValue.Index(index).Interface()))
+	}
+	return strings.Join(items, sep)
+}
+
+func toTemplateNumber(value interface{}) (float64, error) {
+	switch number := value.(type) {
+	case int:
+		return float64(number), nil
+	case int64:
+		return float64(number), nil
+	case float64:
+		return number, nil
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(number), 64)
+	}
+	return 0, fmt.Errorf("%v is not a number", value)
+}
+
+func toTemplateInt(value interface{}) (int64, error) {
+	number, err := toTemplateNumber(value)
+	return int64(number), err
+}
+
+func roundBy(rounding func(float64) float64) func(interface{}) (float64, error) {
+	return func(value interface{}) (float64, error) {
+		number, err := toTemplateNumber(value)
+		return rounding(number), err
+	}
+}
+
+func isTemplateInteger(value interface{}) bool {
+	switch number := value.(type) {
+	case int, int64:
+		return true
+	case string:
+		_, err := strconv.ParseInt(strings.TrimSpace(number), 10, 64)
+		return err == nil
+	}
+	return false
+}
+
+// calculate returns int64 if both values are integers, otherwise float64
+func calculate(a, b interface{}, operation func(float64, float64) float64) (interface{}, error) {
+	x, err := toTemplateNumber(a)
+	if err != nil {
+		return nil, err
+	}
+	y, err := toTemplateNumber(b)
+	if err != nil {
+		return nil, err
+	}
+	result := operation(x, y)
+	if isTemplateInteger(a) && isTemplateInteger(b) {
+		return int64(result), nil
+	}
+	return result, nil
+}
+
+func arithmetic(operation func(float64, float64) float64) func(interface{}, interface{}) (interface{}, error) {
+	return func(a, b interface{}) (interface{}, error) {
+		return calculate(a, b, operation)
+	}
+}
+
+// divide returns integer division if both values are integers
+func divide(a, b interface{}) (interface{}, error) {
+	y, err := toTemplateNumber(b)
+	if err != nil {
+		return nil, err
+	}
+	if y == 0 {
+		return nil, fmt.Errorf("division by zero")
+	}
+	if isTemplateInteger(a) && isTemplateInteger(b) {
+		return calculate(a, b, func(x, y float64) float64 { return float64(int64(x) / int64(y)) })
+	}
+	return calculate(a, b, func(x, y float64) float64 { return x / y })
+}
+
+func modulo(a, b interface{}) (interface{}, error) {
+	y, err := toTemplateNumber(b)
+	if err != nil {
+		return nil, err
+	}
+	if y == 0 {
+		return nil, fmt.Errorf("division by zero")
+	}
+	return calculate(a, b, math.Mod)
+}
+
+// defaultValue returns given value unless it is empty
+func defaultValue(defaultValue interface{}, given ...interface{}) interface{} {
+	if len(given) == 0 || given[0] == nil {
+		return defaultValue
+	}
+	value := reflect.ValueOf(given[0])
+	switch value.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		if value.Len() == 0 {
+			return defaultValue
+		}
+	case reflect.Bool:
+		if !value.Bool() {
+			return defaultValue
+		}
+	}
+	return given[0]
+}
+
+func toYaml(value interface{}) (string, error) {
+	yamlBytes, err := yaml.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(yamlBytes), "\n"), nil
+}
+
+func toJson(value interface{}) (string, error) {
+	jsonBytes, err := json.Marshal(value)
+	return string(jsonBytes), err
+}
+
+func indent(spaces int, s string) string {
+	padding := strings.Repeat(" ", spaces)
+	return padding + strings.ReplaceAll(s, "\n", "\n"+padding)
+}
+
+// seq returns 1..end with one argument, start..end with two arguments
+func seq(values ...interface{}) ([]int64, error) {
+	if len(values) == 0 || len(values) > 2 {
+		return nil, fmt.Errorf("seq requires one or two arguments")
+	}
+	var bounds []int64
+	for _, value := range values {
+		number, err := toTemplateNumber(value)
+		if err != nil {
+			return nil, err
+		}
+		bounds = append(bounds, int64(number))
+	}
+	start, end := int64(1), bounds[0]
+	if len(bounds) == 2 {
+		start, end = bounds[0], bounds[1]
+	}
+	if end-start+1 > MAX_TEMPLATE_SEQ_LEN {
+		return nil, fmt.Errorf("seq longer than %d", MAX_TEMPLATE_SEQ_LEN)
+	}
+	var sequence []int64
+	for number := start; number <= end; number++ {
+		sequence = append(sequence, number)
+	}
+	return sequence, nil
+}
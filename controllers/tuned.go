@@ -13,6 +13,8 @@ package controllers
 // - add profile label to the node selected by selector (called before start job)
 // DeleteLabel
 // - delete profile label from the node (called after job done)
+// ExportAutoTunedProfile
+// - materialise the finalized auto-tuned profile as a named Tuned (or ConfigMap)
 //
 ////////////////////////////////////////////////////////////////////////////
 
@@ -21,10 +23,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
@@ -44,6 +49,13 @@ const (
 	RESERVED_PRIORITY_NUMBER        = 0
 	RESERVED_AUTOTUNED_PROFILE_NAME = "auto-tuned"
 	BASE_PROFILE                    = "openshift-default"
+
+	EXPORTED_PRIORITY_NUMBER   = 20
+	EXPORT_KIND_TUNED          = TUNED_KIND
+	EXPORT_KIND_CONFIGMAP      = "ConfigMap"
+	EXPORTED_PROFILE_LABEL     = "cpe.cogadvisor.io/exported-profile"
+	EXPORTED_PROFILE_DATA_KEY  = "tuned.conf"
+	PROVENANCE_ANNOTATION_BASE = "cpe.cogadvisor.io/"
 )
 
 type TunedHandler struct {
@@ -234,3 +246,169 @@ func (t *TunedHandler) DeleteAutoTunedProfile() error {
 func (t *TunedHandler) IsSameProfile(tunedProfile map[TuneType]map[string]string, cmpTunedProfile map[TuneType]map[string]string) bool {
 	return reflect.DeepEqual(tunedProfile, cmpTunedProfile)
 }
+
+// Exported Profile
+
+// ProfileProvenance describes where an exported profile comes from
+type ProfileProvenance struct {
+	BenchmarkName    string
+	JobName          string
+	BuildID          string
+	IterationID      string
+	SamplingCount    int
+	PerformanceKey   string
+	PerformanceValue string
+}
+
+func (p ProfileProvenance) annotations() map[string]interface{} {
+	return map[string]interface{}{
+		PROVENANCE_ANNOTATION_BASE + "benchmark":        p.BenchmarkName,
+		PROVENANCE_ANNOTATION_BASE + "job":              p.JobName,
+		PROVENANCE_ANNOTATION_BASE + "build":            p.BuildID,
+		PROVENANCE_ANNOTATION_BASE + "scenario":         p.IterationID,
+		PROVENANCE_ANNOTATION_BASE + "samples":          fmt.Sprintf("%d", p.SamplingCount),
+		PROVENANCE_ANNOTATION_BASE + "performanceKey":   p.PerformanceKey,
+		PROVENANCE_ANNOTATION_BASE + "performanceValue": p.PerformanceValue,
+		PROVENANCE_ANNOTATION_BASE + "exportedTime":     time.Now().Format(time.RFC3339),
+	}
+}
+
+func (p ProfileProvenance) labels(extraLabels map[string]string) map[string]interface{} {
+	labels := map[string]interface{}{
+		BENCHMARK_LABEL:        p.BenchmarkName,
+		EXPORTED_PROFILE_LABEL: "true",
+	}
+	for key, value := range extraLabels {
+		labels[key] = value
+	}
+	return labels
+}
+
+// GetExportedProfileName returns valid profile name for the job
+func GetExportedProfileName(spec *cpev1.ProfileExportSpec, jobName string) string {
+	name := strings.ToLower(getValidValue(jobName))
+	if spec != nil && spec.Name != "" {
+		name = spec.Name + "-" + name[strings.LastIndex(name, "-")+1:]
+	}
+	return name
+}
+
+// GetExportedTunedProfile returns Tuned object of the finalized profile that matches node label profile=<name>
+func GetExportedTunedProfile(name string, tunedProfile map[TuneType]map[string]string, provenance ProfileProvenance, extraLabels map[string]string) *unstructured.Unstructured {
+	profile := GetAutoTunedProfile(tunedProfile)
+	object := profile.Object
+	object["metadata"] = map[string]interface{}{
+		"name":        name,
+		"namespace":   TUNED_NAMESPACE,
+		"labels":      provenance.labels(extraLabels),
+		"annotations": provenance.annotations(),
+	}
+	spec := object["spec"].(map[string]interface{})
+	spec["profile"].([]interface{})[0].(map[string]interface{})["name"] = name
+	recommend := spec["recommend"].([]interface{})[0].(map[string]interface{})
+	recommend["match"].([]interface{})[0].(map[string]interface{})["value"] = name
+	recommend["priority"] = EXPORTED_PRIORITY_NUMBER
+	recommend["profile"] = name
+	return profile
+}
+
+// GetExportedProfileConfigMap returns ConfigMap keeping tuned data of the finalized profile (for non-Openshift cluster)
+func GetExportedProfileConfigMap(name string, namespace string, tunedProfile map[TuneType]map[string]string, provenance ProfileProvenance, extraLabels map[string]string) *corev1.ConfigMap {
+	labels := make(map[string]string)
+	for key, value := range provenance.labels(extraLabels) {
+		labels[key] = value.(string)
+	}
+	annotations := make(map[string]string)
+	for key, value := range provenance.annotations() {
+		annotations[key] = value.(string)
+	}
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Data: map[string]string{
+			EXPORTED_PROFILE_DATA_KEY: GetDataProfile(tunedProfile),
+		},
+	}
+}
+
+func (t *TunedHandler) isTunedAvailable() bool {
+	gvr, _ := schema.ParseResourceArg(TUNED_RESOURCE)
+	_, err := t.DYN.Resource(*gvr).Namespace(TUNED_NAMESPACE).List(context.TODO(), metav1.ListOptions{Limit: 1})
+	return err == nil
+}
+
+func (t *TunedHandler) exportTuned(profile *unstructured.Unstructured) error {
+	gvr, _ := schema.ParseResourceArg(TUNED_RESOURCE)
+	dr := t.DYN.Resource(*gvr).Namespace(TUNED_NAMESPACE)
+	existProfile, err := dr.Get(context.TODO(), profile.GetName(), metav1.GetOptions{})
+	if err != nil {
+		_, err = dr.Create(context.TODO(), profile, metav1.CreateOptions{})
+		return err
+	}
+	profile.SetResourceVersion(existProfile.GetResourceVersion())
+	_, err = dr.Update(context.TODO(), profile, metav1.UpdateOptions{})
+	return err
+}
+
+func (t *TunedHandler) exportConfigMap(configMap *corev1.ConfigMap) error {
+	cmInterface := t.Clientset.CoreV1().ConfigMaps(configMap.Namespace)
+	_, err := cmInterface.Create(context.TODO(), configMap, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		_, err = cmInterface.Update(context.TODO(), configMap, metav1.UpdateOptions{})
+	}
+	return err
+}
+
+// ExportAutoTunedProfile creates (or updates) the finalized profile as a named and labelled resource
+// the exported resource is not owned by the benchmark so that it can be applied after the benchmark is deleted
+func (t *TunedHandler) ExportAutoTunedProfile(benchmark *cpev1.Benchmark, tunedProfile map[TuneType]map[string]string, provenance ProfileProvenance) (cpev1.ExportedProfile, error) {
+	exportSpec := benchmark.Spec.IterationSpec.NodeSelection.Export
+	var extraLabels map[string]string
+	kind := ""
+	namespace := benchmark.Namespace
+	if exportSpec != nil {
+		kind = exportSpec.Kind
+		extraLabels = exportSpec.Labels
+		if exportSpec.Namespace != "" {
+			namespace = exportSpec.Namespace
+		}
+	}
+	if kind == "" {
+		if t.isTunedAvailable() {
+			kind = EXPORT_KIND_TUNED
+		} else {
+			kind = EXPORT_KIND_CONFIGMAP
+		}
+	}
+	name := GetExportedProfileName(exportSpec, provenance.JobName)
+	exported := cpev1.ExportedProfile{
+		Kind:             kind,
+		Name:             name,
+		JobName:          provenance.JobName,
+		BuildID:          provenance.BuildID,
+		IterationID:      provenance.IterationID,
+		SamplingCount:    provenance.SamplingCount,
+		PerformanceKey:   provenance.PerformanceKey,
+		PerformanceValue: provenance.PerformanceValue,
+	}
+
+	var err error
+	switch kind {
+	case EXPORT_KIND_TUNED:
+		exported.Namespace = TUNED_NAMESPACE
+		err = t.exportTuned(GetExportedTunedProfile(name, tunedProfile, provenance, extraLabels))
+	case EXPORT_KIND_CONFIGMAP:
+		exported.Namespace = namespace
+		err = t.exportConfigMap(GetExportedProfileConfigMap(name, namespace, tunedProfile, provenance, extraLabels))
+	default:
+		err = fmt.Errorf("unknown export kind %s", kind)
+	}
+	if err == nil {
+		t.Log.Info(fmt.Sprintf("Export %s %s/%s from %s", kind, exported.Namespace, name, provenance.JobName))
+	}
+	return exported, err
+}
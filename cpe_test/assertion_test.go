@@ -0,0 +1,43 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+// go test -v cpe_test/assertion_test.go
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	"github.com/IBM/cpe-operator/controllers"
+)
+
+func TestEvaluateAssertions(t *testing.T) {
+	item := cpev1.BenchmarkResultItem{
+		PerformanceValue: "1200.000000",
+		Result:           `{"tpmC": 1200, "p99_ms": 42.5, "Latency (ms)": [1, 2, 3]}`,
+	}
+	variables := controllers.GetAssertionVariables(item, map[string]string{"threads": "4"}, map[string]string{"profile": "default"})
+	assert.Equal(t, "Latency_ms_", controllers.GetVariableName("Latency (ms)"))
+
+	assertions := []cpev1.AssertionSpec{
+		{Name: "slo", Expression: "p99_ms < 50 && tpmC > 1000"},
+		{Name: "labels", Expression: "labels.threads == '4' && labels.profile == 'default'"},
+		{Name: "perThread", Expression: "performanceValue / double(labels.threads) >= 300.0"},
+		{Name: "rawKey", Expression: "size(values['Latency (ms)']) == 3 && Latency_ms_[2] == 3"},
+	}
+	failed, messages := controllers.EvaluateAssertions(assertions, variables)
+	assert.Equal(t, 0, len(failed), messages)
+
+	assertions = []cpev1.AssertionSpec{
+		{Name: "strict", Expression: "p99_ms < 40"},
+		{Name: "missing", Expression: "p999_ms < 100"},
+		{Name: "notBool", Expression: "tpmC"},
+	}
+	failed, messages = controllers.EvaluateAssertions(assertions, variables)
+	assert.Equal(t, []string{"strict", "missing", "notBool"}, failed)
+	assert.Equal(t, 3, len(messages))
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+// go test -v cpe_test/baseline_test.go
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	"github.com/IBM/cpe-operator/controllers"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetBaselineValues(t *testing.T) {
+	benchmark := &cpev1.Benchmark{}
+	benchmark.Status.TrackedBuilds = []string{"build-1", "build-2"}
+	benchmark.Status.Results = []cpev1.BenchmarkResult{
+		newResult("build-1", 100, 102),
+		newResult("build-2", 90, 92),
+	}
+	values, build := controllers.GetBaselineValues(benchmark, "")
+	assert.Equal(t, "build-2", build)
+	assert.Equal(t, 1, len(values))
+	assert.Equal(t, "91.000000", values[0].Value)
+	assert.Equal(t, "score", values[0].PerformanceKey)
+
+	values, _ = controllers.GetBaselineValues(benchmark, "build-1")
+	assert.Equal(t, "101.000000", values[0].Value)
+
+	merged := controllers.MergeBaselineValues(values, []cpev1.BaselineValue{{IterationID: "s1", ConfigurationID: "c1", Value: "95"}})
+	assert.Equal(t, 1, len(merged))
+	assert.Equal(t, "95", merged[0].Value)
+}
+
+func TestEvaluateBaseline(t *testing.T) {
+	benchmark := &cpev1.Benchmark{}
+	benchmark.Status.TrackedBuilds = []string{"build-1"}
+	benchmark.Status.Results = []cpev1.BenchmarkResult{newResult("build-1", 90, 92)}
+	baseline := &cpev1.BenchmarkBaseline{}
+	baseline.Name = "coremark-baseline"
+	baseline.Status.Values = []cpev1.BaselineValue{{IterationID: "s1", ConfigurationID: "c1", Value: "100"}}
+
+	comparisons, _ := controllers.EvaluateBaseline(benchmark, baseline)
+	assert.Equal(t, 1, len(comparisons))
+	assert.False(t, comparisons[0].Passed)
+	controllers.SetBaselineConditions(benchmark, baseline.Name, comparisons, true)
+	assert.True(t, meta.IsStatusConditionTrue(benchmark.Status.Conditions, controllers.CONDITION_FAILED))
+	assert.True(t, meta.IsStatusConditionFalse(benchmark.Status.Conditions, controllers.CONDITION_PASSED))
+
+	// within tolerance
+	baseline.Spec.Tolerance = "0.1"
+	comparisons, _ = controllers.EvaluateBaseline(benchmark, baseline)
+	assert.True(t, comparisons[0].Passed)
+	controllers.SetBaselineConditions(benchmark, baseline.Name, comparisons, true)
+	assert.True(t, meta.IsStatusConditionTrue(benchmark.Status.Conditions, controllers.CONDITION_PASSED))
+
+	// not completed yet
+	controllers.SetBaselineConditions(benchmark, baseline.Name, comparisons, false)
+	assert.False(t, meta.IsStatusConditionTrue(benchmark.Status.Conditions, controllers.CONDITION_PASSED))
+	assert.False(t, meta.IsStatusConditionTrue(benchmark.Status.Conditions, controllers.CONDITION_FAILED))
+}
+
+func assertMissingResults(t *testing.T, benchmark *cpev1.Benchmark) {
+	assert.True(t, meta.IsStatusConditionTrue(benchmark.Status.Conditions, controllers.CONDITION_FAILED))
+	condition := meta.FindStatusCondition(benchmark.Status.Conditions, controllers.CONDITION_PASSED)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, controllers.REASON_MISSING_RESULTS, condition.Reason)
+}
+
+func TestEvaluateBaselineMissingResults(t *testing.T) {
+	benchmark := &cpev1.Benchmark{}
+	benchmark.Status.TrackedBuilds = []string{"build-1"}
+	benchmark.Status.Results = []cpev1.BenchmarkResult{newResult("build-1", 100, 102)}
+	baseline := &cpev1.BenchmarkBaseline{}
+	baseline.Name = "coremark-baseline"
+	baseline.Status.Values = []cpev1.BaselineValue{
+		{IterationID: "s1", ConfigurationID: "c1", Value: "100"},
+		// wrong scenario ID
+		{IterationID: "s2", ConfigurationID: "c1", Value: "100"},
+	}
+	comparisons, _ := controllers.EvaluateBaseline(benchmark, baseline)
+	assert.Equal(t, 2, len(comparisons))
+	assert.True(t, comparisons[0].Passed)
+	assert.True(t, comparisons[1].Missing)
+	controllers.SetBaselineConditions(benchmark, baseline.Name, comparisons, true)
+	assertMissingResults(t, benchmark)
+
+	// mismatched performance key
+	baseline.Status.Values = []cpev1.BaselineValue{{IterationID: "s1", ConfigurationID: "c1", Value: "100", PerformanceKey: "latency"}}
+	comparisons, _ = controllers.EvaluateBaseline(benchmark, baseline)
+	assert.True(t, comparisons[0].Missing)
+	controllers.SetBaselineConditions(benchmark, baseline.Name, comparisons, true)
+	assertMissingResults(t, benchmark)
+
+	// no comparison
+	controllers.SetBaselineConditions(benchmark, baseline.Name, nil, true)
+	assertMissingResults(t, benchmark)
+}
+
+func TestEvaluateBaselineFailedJobs(t *testing.T) {
+	benchmark := &cpev1.Benchmark{}
+	benchmark.Status.TrackedBuilds = []string{"build-1"}
+	benchmark.Status.Results = []cpev1.BenchmarkResult{newResult("build-1", 100, 102)}
+	baseline := &cpev1.BenchmarkBaseline{}
+	baseline.Name = "coremark-baseline"
+	baseline.Status.Values = []cpev1.BaselineValue{{IterationID: "s1", ConfigurationID: "c1", Value: "100"}}
+	comparisons, _ := controllers.EvaluateBaseline(benchmark, baseline)
+	controllers.SetBaselineConditions(benchmark, baseline.Name, comparisons, true)
+	assert.True(t, meta.IsStatusConditionTrue(benchmark.Status.Conditions, controllers.CONDITION_PASSED))
+
+	// a job of the evaluated build failed in its hook
+	benchmark.Status.FailedJobs = []cpev1.FailedJob{{JobName: "coremark-x", BuildID: "build-1", Hook: controllers.STEP_SYSTEM_UNDER_TEST}}
+	controllers.SetBaselineConditions(benchmark, baseline.Name, comparisons, true)
+	assertMissingResults(t, benchmark)
+
+	// failed job of another build is not counted
+	benchmark.Status.FailedJobs[0].BuildID = "build-0"
+	controllers.SetBaselineConditions(benchmark, baseline.Name, comparisons, true)
+	assert.True(t, meta.IsStatusConditionTrue(benchmark.Status.Conditions, controllers.CONDITION_PASSED))
+}
+
+func TestGetBaselineRefKeys(t *testing.T) {
+	benchmark := &cpev1.Benchmark{}
+	benchmark.Namespace = "bench"
+	assert.Empty(t, controllers.GetBaselineRefKeys(benchmark))
+	// default to benchmark namespace
+	benchmark.Spec.BaselineRef = &cpev1.BaselineReference{Name: "release"}
+	assert.Equal(t, []string{"bench/release"}, controllers.GetBaselineRefKeys(benchmark))
+	benchmark.Spec.BaselineRef.Namespace = "baselines"
+	assert.Equal(t, []string{"baselines/release"}, controllers.GetBaselineRefKeys(benchmark))
+}
@@ -63,3 +63,29 @@ func TestGetAutoTunedProfile(t *testing.T) {
 	fmt.Println("Total Run: ", nodeTunedOptimizer.SamplingCount)
 	fmt.Println("Final: ", nodeTunedOptimizer.FinalizedTunedProfile)
 }
+
+func TestGetExportedTunedProfile(t *testing.T) {
+	tunedProfile := map[controllers.TuneType]map[string]string{
+		"sysctl": map[string]string{"kernel.sched_min_granularity_ns": "10000000"},
+	}
+	provenance := controllers.ProfileProvenance{
+		BenchmarkName:    "coremark",
+		JobName:          "coremark-cpeh-1234",
+		SamplingCount:    10,
+		PerformanceKey:   "score",
+		PerformanceValue: "100.000000",
+	}
+	name := controllers.GetExportedProfileName(nil, provenance.JobName)
+	assert.Equal(t, "coremark-cpeh-1234", name)
+	profile := controllers.GetExportedTunedProfile(name, tunedProfile, provenance, map[string]string{"pool": "prod"})
+	printUnstructure(profile)
+	assert.Equal(t, name, profile.GetName())
+	assert.Equal(t, "prod", profile.GetLabels()["pool"])
+	assert.Equal(t, "10", profile.GetAnnotations()["cpe.cogadvisor.io/samples"])
+	recommend := profile.Object["spec"].(map[string]interface{})["recommend"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, name, recommend["profile"])
+
+	configMap := controllers.GetExportedProfileConfigMap(name, "default", tunedProfile, provenance, nil)
+	assert.Contains(t, configMap.Data["tuned.conf"], "kernel.sched_min_granularity_ns=10000000")
+	assert.Equal(t, "coremark", configMap.Labels["cpe-benchmark"])
+}
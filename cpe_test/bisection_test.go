@@ -0,0 +1,79 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+// go test -v cpe_test/bisection_test.go
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	"github.com/IBM/cpe-operator/controllers"
+)
+
+func TestUpdateBisections(t *testing.T) {
+	benchmark := &cpev1.Benchmark{}
+	benchmark.Spec.Repetition = 3
+	benchmark.Spec.Regression = &cpev1.RegressionSpec{Bisect: &cpev1.BisectSpec{
+		Candidates: []string{"app:v1", "app:v2", "app:v3", "app:v4", "app:v5", "app:v6"},
+	}}
+	benchmark.Status.TrackedBuilds = []string{"build-1", "build-6"}
+	benchmark.Status.BuildDetails = []cpev1.TrackedBuild{
+		{Name: "build-1", Image: "app:v1"},
+		{Name: "build-6", Image: "app:v6"},
+	}
+	benchmark.Status.Results = []cpev1.BenchmarkResult{
+		newResult("build-1", 100, 101, 99),
+		newResult("build-6", 80, 81, 79),
+	}
+	assert.Equal(t, []string{"app:v2", "app:v3", "app:v4", "app:v5"}, controllers.GetBisectionCandidates(benchmark, "build-1", "build-6"))
+
+	regressions := controllers.GetRegressions(benchmark)
+	completed := controllers.UpdateBisections(benchmark, regressions)
+	assert.Equal(t, 0, len(completed))
+	assert.Equal(t, 1, len(benchmark.Status.Bisections))
+	assert.Equal(t, "app:v4", benchmark.Status.Bisections[0].Testing)
+	assert.Equal(t, "app:v4", benchmark.Status.TrackedBuilds[2])
+	// bisection build runs only the regressed scenario and is not compared to the previous build
+	assert.True(t, controllers.IsScenarioPlanned(benchmark, "app:v4", map[string]string{}))
+	assert.False(t, controllers.IsScenarioPlanned(benchmark, "app:v4", map[string]string{"threads": "2"}))
+
+	// v4 is bad
+	benchmark.Status.Results = append(benchmark.Status.Results, newResult("app:v4", 80, 80.5, 79))
+	completed = controllers.UpdateBisections(benchmark, nil)
+	assert.Equal(t, 0, len(completed))
+	assert.Equal(t, "app:v3", benchmark.Status.Bisections[0].Testing)
+	assert.Equal(t, 0, len(controllers.GetNewVerdicts(regressions, controllers.GetRegressions(benchmark))))
+
+	// v3 is good
+	benchmark.Status.Results = append(benchmark.Status.Results, newResult("app:v3", 100, 100.5, 99))
+	completed = controllers.UpdateBisections(benchmark, nil)
+	assert.Equal(t, 1, len(completed))
+	assert.Equal(t, "app:v4", completed[0].FirstBad)
+	assert.Equal(t, "app:v3", completed[0].Good)
+	assert.Equal(t, controllers.BISECTION_COMPLETED, benchmark.Status.Bisections[0].Phase)
+
+	// same regression is not bisected again
+	controllers.UpdateBisections(benchmark, regressions)
+	assert.Equal(t, 1, len(benchmark.Status.Bisections))
+}
+
+func TestGetBisectionCandidatesFromImageTags(t *testing.T) {
+	benchmark := &cpev1.Benchmark{}
+	benchmark.Spec.Regression = &cpev1.RegressionSpec{Bisect: &cpev1.BisectSpec{}}
+	benchmark.Status.BuildDetails = []cpev1.TrackedBuild{
+		{Name: "quay.io/cpe/app:v1", Kind: controllers.IMAGE_REPOSITORY_KIND, Config: "app", Image: "quay.io/cpe/app:v1"},
+		{Name: "quay.io/cpe/app:v4", Kind: controllers.IMAGE_REPOSITORY_KIND, Config: "app", Image: "quay.io/cpe/app:v4"},
+	}
+	benchmark.Status.ImageTags = []cpev1.SeenImageTags{{Config: "app", Tags: []string{"v1", "v2", "v3", "v4"}}}
+	// tags are not ordered without semver
+	benchmark.Spec.BuildConfigs = []cpev1.ConfigSpec{{Name: "app", Kind: controllers.IMAGE_REPOSITORY_KIND, Image: "quay.io/cpe/app"}}
+	assert.Nil(t, controllers.GetBisectionCandidates(benchmark, "quay.io/cpe/app:v1", "quay.io/cpe/app:v4"))
+
+	benchmark.Spec.BuildConfigs[0].Semver = ">=1.0.0"
+	assert.Equal(t, []string{"quay.io/cpe/app:v2", "quay.io/cpe/app:v3"}, controllers.GetBisectionCandidates(benchmark, "quay.io/cpe/app:v1", "quay.io/cpe/app:v4"))
+}
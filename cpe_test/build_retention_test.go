@@ -0,0 +1,100 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+// go test -v cpe_test/build_retention_test.go
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	"github.com/IBM/cpe-operator/controllers"
+)
+
+func newRetentionBenchmark() *cpev1.Benchmark {
+	benchmark := &cpev1.Benchmark{}
+	benchmark.Name = "coremark"
+	benchmark.Status.TrackedBuilds = []string{"build-1", "build-2", "build-3", "build-4"}
+	for _, build := range benchmark.Status.TrackedBuilds {
+		benchmark.Status.BuildDetails = append(benchmark.Status.BuildDetails, cpev1.TrackedBuild{Name: build})
+		benchmark.Status.Hash = append(benchmark.Status.Hash, cpev1.IterationHash{Build: build})
+	}
+	benchmark.Status.Results = []cpev1.BenchmarkResult{{BuildID: "build-1", Items: []cpev1.BenchmarkResultItem{{JobName: "coremark-1"}}}}
+	return benchmark
+}
+
+func TestGetRetainedBuilds(t *testing.T) {
+	benchmark := newRetentionBenchmark()
+	assert.Equal(t, benchmark.Status.TrackedBuilds, controllers.GetRetainedBuilds(benchmark))
+
+	benchmark.Spec.BuildRetention = &cpev1.BuildRetentionSpec{KeepLast: 1}
+	assert.Equal(t, []string{"build-4"}, controllers.GetRetainedBuilds(benchmark))
+
+	benchmark.Spec.BuildRetention = &cpev1.BuildRetentionSpec{KeepLast: 2, Pinned: []string{"build-1"}}
+	assert.Equal(t, []string{"build-1", "build-3", "build-4"}, controllers.GetRetainedBuilds(benchmark))
+
+	// pinned only keeps all
+	benchmark.Spec.BuildRetention = &cpev1.BuildRetentionSpec{Pinned: []string{"build-1"}}
+	assert.Equal(t, 4, len(controllers.GetRetainedBuilds(benchmark)))
+}
+
+func TestPruneBuildStatus(t *testing.T) {
+	benchmark := newRetentionBenchmark()
+	assert.False(t, controllers.PruneBuildStatus(benchmark))
+
+	benchmark.Spec.BuildRetention = &cpev1.BuildRetentionSpec{KeepLast: 1, Pinned: []string{"build-2"}}
+	assert.True(t, controllers.PruneBuildStatus(benchmark))
+	assert.Equal(t, []string{"build-2", "build-4"}, benchmark.Status.TrackedBuilds)
+	assert.Equal(t, 2, len(benchmark.Status.BuildDetails))
+	assert.Equal(t, 2, len(benchmark.Status.Hash))
+	assert.Equal(t, "build-2", benchmark.Status.Hash[0].Build)
+	// results are kept as history
+	assert.Equal(t, 1, len(benchmark.Status.Results))
+	assert.False(t, controllers.PruneBuildStatus(benchmark))
+}
+
+func TestIsBuildCompleted(t *testing.T) {
+	benchmark := newRetentionBenchmark()
+	assert.False(t, controllers.IsBuildCompleted(benchmark, "build-1"))
+
+	jobNames := controllers.GetBuildJobNames(benchmark, "build-2")
+	assert.Equal(t, 1, len(jobNames))
+	benchmark.Status.Results = append(benchmark.Status.Results, cpev1.BenchmarkResult{BuildID: "build-2", Items: []cpev1.BenchmarkResultItem{{JobName: jobNames[0]}}})
+	assert.True(t, controllers.IsBuildCompleted(benchmark, "build-2"))
+	assert.False(t, controllers.IsBuildCompleted(benchmark, "build-3"))
+}
+
+func TestIsNewBuild(t *testing.T) {
+	benchmark := &cpev1.Benchmark{}
+	// init build before any build is tracked
+	assert.True(t, controllers.IsNewBuild(benchmark, "init"))
+
+	benchmark = newRetentionBenchmark()
+	assert.True(t, controllers.IsNewBuild(benchmark, "build-4"))
+	assert.False(t, controllers.IsNewBuild(benchmark, "build-3"))
+}
+
+func TestIsBenchmarkCompletedWithNewBuildsOnly(t *testing.T) {
+	benchmark := &cpev1.Benchmark{}
+	benchmark.Name = "coremark"
+	benchmark.Spec.BuildRetention = &cpev1.BuildRetentionSpec{NewBuildsOnly: true}
+	// two builds tracked in one update
+	benchmark.Status.TrackedBuilds = []string{"build-1", "build-2"}
+	assert.True(t, controllers.IsSkippedBuild(benchmark, "build-1"))
+	assert.False(t, controllers.IsSkippedBuild(benchmark, "build-2"))
+	assert.Empty(t, controllers.GetBuildJobNames(benchmark, "build-1"))
+	assert.False(t, controllers.IsBenchmarkCompleted(benchmark))
+
+	jobNames := controllers.GetBuildJobNames(benchmark, "build-2")
+	assert.Equal(t, 1, len(jobNames))
+	benchmark.Status.Results = []cpev1.BenchmarkResult{{BuildID: "build-2", Items: []cpev1.BenchmarkResultItem{{JobName: jobNames[0]}}}}
+	assert.True(t, controllers.IsBenchmarkCompleted(benchmark))
+
+	// all builds are waited for without newBuildsOnly
+	benchmark.Spec.BuildRetention.NewBuildsOnly = false
+	assert.False(t, controllers.IsBenchmarkCompleted(benchmark))
+}
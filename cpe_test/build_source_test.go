@@ -0,0 +1,168 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+// go test -v cpe_test/build_source_test.go
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	"github.com/IBM/cpe-operator/controllers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestPipelineRunSource(t *testing.T) {
+	build := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "tekton.dev/v1beta1",
+		"kind":       "PipelineRun",
+		"metadata": map[string]interface{}{
+			"name":      "build-coremark-x7k2p",
+			"namespace": "ci",
+			"labels":    map[string]interface{}{"tekton.dev/pipeline": "build-coremark"},
+		},
+		"status": map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Succeeded", "status": "Unknown"},
+			},
+			"pipelineResults": []interface{}{
+				map[string]interface{}{"name": "IMAGE_URL", "value": "quay.io/cpe/coremark:abc\n"},
+				map[string]interface{}{"name": "IMAGE_DIGEST", "value": "sha256:1234"},
+			},
+		},
+	}}
+	source := controllers.GetBuildSource(build)
+	assert.NotNil(t, source)
+	assert.False(t, source.IsCompleted(build))
+	build.Object["status"].(map[string]interface{})["conditions"] = []interface{}{
+		map[string]interface{}{"type": "Succeeded", "status": "True"},
+	}
+	assert.True(t, source.IsCompleted(build))
+
+	kind, name, namespace := source.GetConfig(build)
+	assert.Equal(t, "PipelineRun", kind)
+	assert.Equal(t, "build-coremark", name)
+	assert.Equal(t, "ci", namespace)
+
+	trackedBuild := controllers.GetTrackedBuild(source, build)
+	assert.Equal(t, "ci-build-coremark-x7k2p", trackedBuild.Name)
+	assert.Equal(t, "quay.io/cpe/coremark:abc", trackedBuild.Image)
+	assert.Equal(t, "sha256:1234", trackedBuild.Digest)
+}
+
+func TestWorkflowSource(t *testing.T) {
+	build := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       "Workflow",
+		"metadata":   map[string]interface{}{"name": "build-coremark-abcde", "namespace": "argo"},
+		"spec": map[string]interface{}{
+			"workflowTemplateRef": map[string]interface{}{"name": "build-coremark"},
+		},
+		"status": map[string]interface{}{
+			"phase": "Succeeded",
+			"nodes": map[string]interface{}{
+				"build-coremark-abcde-1": map[string]interface{}{
+					"outputs": map[string]interface{}{
+						"parameters": []interface{}{
+							map[string]interface{}{"name": "image-url", "value": "quay.io/cpe/coremark:def"},
+						},
+					},
+				},
+			},
+		},
+	}}
+	source := controllers.GetBuildSource(build)
+	assert.True(t, source.IsCompleted(build))
+	_, name, _ := source.GetConfig(build)
+	assert.Equal(t, "build-coremark", name)
+	image, digest := source.GetImage(build)
+	assert.Equal(t, "quay.io/cpe/coremark:def", image)
+	assert.Equal(t, "", digest)
+}
+
+func TestMatchGenericBuildConfig(t *testing.T) {
+	configSpec := cpev1.ConfigSpec{
+		Name:          "nightly",
+		APIVersion:    "example.com/v1",
+		Kind:          "Release",
+		Selector:      &metav1.LabelSelector{MatchLabels: map[string]string{"app": "coremark"}},
+		FieldSelector: "spec.channel=nightly",
+		SuccessPath:   ".status.conditions[type=Ready].status",
+		ArtifactPath:  ".status.artifact.image",
+		DigestPath:    ".status.artifact.digest",
+	}
+	build := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Release",
+		"metadata": map[string]interface{}{
+			"name":      "coremark-1",
+			"namespace": "ci",
+			"labels":    map[string]interface{}{"app": "coremark"},
+		},
+		"spec": map[string]interface{}{"channel": "nightly"},
+		"status": map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Ready", "status": "True"},
+			},
+			"artifact": map[string]interface{}{"image": "quay.io/cpe/coremark:1", "digest": "sha256:5678"},
+		},
+	}}
+	trackedBuild, matched := controllers.MatchBuildConfig(configSpec, build)
+	assert.True(t, matched)
+	assert.Equal(t, "ci-coremark-1", trackedBuild.Name)
+	assert.Equal(t, "nightly", trackedBuild.Config)
+	assert.Equal(t, "quay.io/cpe/coremark:1", trackedBuild.Image)
+	assert.Equal(t, "sha256:5678", trackedBuild.Digest)
+
+	// not selected by field selector
+	configSpec.FieldSelector = "spec.channel!=nightly"
+	_, matched = controllers.MatchBuildConfig(configSpec, build)
+	assert.False(t, matched)
+
+	// not yet completed
+	configSpec.FieldSelector = ""
+	build.Object["status"].(map[string]interface{})["conditions"] = []interface{}{}
+	_, matched = controllers.MatchBuildConfig(configSpec, build)
+	assert.False(t, matched)
+}
+
+func TestGetBuildConfigGVKs(t *testing.T) {
+	benchmark := &cpev1.Benchmark{Spec: cpev1.BenchmarkSpec{BuildConfigs: []cpev1.ConfigSpec{
+		{Name: "coremark"},
+		{Name: "nightly", APIVersion: "example.com/v1", Kind: "Release"},
+		{Name: "pipeline", APIVersion: "tekton.dev/v1beta1", Kind: "PipelineRun"},
+	}}}
+	// built-in build sources are watched by InitInformer
+	assert.Equal(t, []string{"example.com/v1, Kind=Release", "tekton.dev/v1beta1, Kind=PipelineRun"}, controllers.GetBuildConfigGVKs(benchmark))
+	assert.Empty(t, controllers.GetBuildConfigGVKs(&cpev1.Benchmark{}))
+}
+
+func TestGetBuildTemplateValue(t *testing.T) {
+	benchmark := &cpev1.Benchmark{}
+	benchmark.Spec.Spec = "image: {{ or .build.image \"quay.io/cpe/coremark:latest\" }}@{{ .build.digest }}"
+	benchmark.Status.BuildDetails = []cpev1.TrackedBuild{
+		{Name: "ci-coremark-1", Kind: "PipelineRun", Config: "build-coremark", Image: "quay.io/cpe/coremark:1", Digest: "sha256:5678"},
+	}
+	// rendered as in GetBenchmarkWithIteration
+	context := controllers.GetTemplateContext(benchmark, map[string]string{}, "ci-coremark-1", 0, "")
+	executedSpec, err := controllers.ExecuteBenchmarkTemplate(benchmark.Spec.Spec, context)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, "image: quay.io/cpe/coremark:1@sha256:5678", executedSpec)
+
+	// untracked build (init)
+	context = controllers.GetTemplateContext(benchmark, map[string]string{}, controllers.INIT_BUILD_NAME, 0, "")
+	executedSpec, err = controllers.ExecuteBenchmarkTemplate(benchmark.Spec.Spec, context)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, "image: quay.io/cpe/coremark:latest@", executedSpec)
+
+	// iteration item named build takes precedence
+	context = controllers.GetTemplateContext(benchmark, map[string]string{"build": "custom"}, "ci-coremark-1", 0, "")
+	executedSpec, err = controllers.ExecuteBenchmarkTemplate("build: {{ .build }}", context)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, "build: custom", executedSpec)
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+// go test -v cpe_test/derived_iteration_test.go
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	"github.com/IBM/cpe-operator/controllers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetIterationLabels(t *testing.T) {
+	items := []cpev1.IterationItem{
+		{Name: "threads", Values: []string{"1", "4"}},
+		{Name: "memory", Expression: `string(threads * 2) + "Gi"`},
+		{Name: "nodes", Values: []string{"2"}},
+		{Name: "gpus-per-node", Values: []string{"8"}},
+		{Name: "ranks", Expression: "nodes * gpus_per_node"},
+		{Name: "ratio", Expression: "double(ranks) / double(threads)"},
+	}
+	labels, errs := controllers.GetIterationLabels(items)
+	assert.Equal(t, 0, len(errs))
+	assert.Equal(t, 2, len(labels))
+	assert.Equal(t, map[string]string{"threads": "1", "memory": "2Gi", "nodes": "2", "gpus-per-node": "8", "ranks": "16", "ratio": "16"}, labels[0])
+	assert.Equal(t, map[string]string{"threads": "4", "memory": "8Gi", "nodes": "2", "gpus-per-node": "8", "ranks": "16", "ratio": "4"}, labels[1])
+
+	// combination that cannot be evaluated is skipped
+	items = []cpev1.IterationItem{
+		{Name: "threads", Values: []string{"0", "2"}},
+		{Name: "share", Expression: "100 / threads"},
+	}
+	labels, errs = controllers.GetIterationLabels(items)
+	assert.Equal(t, 1, len(errs))
+	assert.Equal(t, []map[string]string{{"threads": "2", "share": "50"}}, labels)
+
+	// string type keeps numeric-looking values as they are
+	items = []cpev1.IterationItem{
+		{Name: "version", Values: []string{"2.10"}, Type: controllers.VALUE_TYPE_STRING},
+		{Name: "month", Values: []string{"08"}, Type: controllers.VALUE_TYPE_STRING},
+		{Name: "tag", Expression: `version + "-" + month`},
+	}
+	labels, errs = controllers.GetIterationLabels(items)
+	assert.Equal(t, 0, len(errs))
+	assert.Equal(t, []map[string]string{{"version": "2.10", "month": "08", "tag": "2.10-08"}}, labels)
+
+	// no iteration
+	labels, errs = controllers.GetIterationLabels(nil)
+	assert.Equal(t, 0, len(errs))
+	assert.Equal(t, []map[string]string{{}}, labels)
+}
+
+func TestGetIteratedValuesWithDerivedIteration(t *testing.T) {
+	benchmark := &cpev1.Benchmark{
+		ObjectMeta: metav1.ObjectMeta{Name: "stream", Namespace: "default"},
+		Spec: cpev1.BenchmarkSpec{
+			IterationSpec: cpev1.IterationSpec{
+				Iteration:     []cpev1.IterationItem{{Name: "threads", Values: []string{"1", "2"}}},
+				Configuration: []cpev1.IterationItem{{Name: "memory", Expression: `string(threads * 2) + "Gi"`}},
+			},
+		},
+	}
+	firstLabel, iterationLabels, _, _ := controllers.GetIteratedValues(benchmark)
+	assert.Equal(t, map[string]string{"threads": "1", "memory": "2Gi"}, firstLabel)
+	assert.Equal(t, []map[string]string{{"threads": "2", "memory": "4Gi"}}, iterationLabels)
+	assert.Equal(t, 0, len(controllers.ValidateDerivedIterations(benchmark)))
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+// go test -v cpe_test/derived_metric_test.go
+
+package controllers
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	"github.com/IBM/cpe-operator/controllers"
+)
+
+func newThreadResult(threads string, configuration string, throughputs ...string) cpev1.BenchmarkResult {
+	result := cpev1.BenchmarkResult{
+		BuildID:          "build-1",
+		IterationID:      "threads=" + threads,
+		IterationMap:     map[string]string{"threads": threads},
+		ConfigurationMap: map[string]string{"profile": configuration},
+	}
+	for index, throughput := range throughputs {
+		result.Items = append(result.Items, cpev1.BenchmarkResultItem{
+			Repetition:       fmt.Sprintf("%d", index),
+			PerformanceValue: throughput,
+			Result:           `{"ops/sec": ` + throughput + `}`,
+		})
+	}
+	return result
+}
+
+func TestUpdateDerivedValues(t *testing.T) {
+	benchmark := &cpev1.Benchmark{}
+	benchmark.Spec.DerivedMetrics = []cpev1.DerivedMetricSpec{
+		{Name: "ops_per_thread", Expression: "ops_sec / double(labels.threads)"},
+		{Name: "speedup", Expression: "ops_sec / ref.ops_sec", Reference: map[string]string{"threads": "1"}},
+		{Name: "invalid", Expression: "labels.threads"},
+	}
+	benchmark.Status.Results = []cpev1.BenchmarkResult{
+		newThreadResult("4", "default", "300", "360"),
+		newThreadResult("1", "tuned", "200"),
+	}
+	errs := controllers.UpdateDerivedValues(benchmark)
+	assert.Equal(t, 3, len(errs))
+	item := benchmark.Status.Results[0].Items[0]
+	assert.Equal(t, "75.000000", item.DerivedValues["ops_per_thread"])
+	// reference of another configuration is not used
+	_, exists := item.DerivedValues["speedup"]
+	assert.False(t, exists)
+
+	benchmark.Status.Results = append(benchmark.Status.Results, newThreadResult("1", "default", "100", "120"))
+	controllers.UpdateDerivedValues(benchmark)
+	// same repetition of the reference
+	assert.Equal(t, "3.000000", benchmark.Status.Results[0].Items[0].DerivedValues["speedup"])
+	assert.Equal(t, "3.000000", benchmark.Status.Results[0].Items[1].DerivedValues["speedup"])
+	assert.Equal(t, "1.000000", benchmark.Status.Results[2].Items[0].DerivedValues["speedup"])
+
+	// name collided with parsed key
+	benchmark.Spec.DerivedMetrics = []cpev1.DerivedMetricSpec{{Name: "ops/sec", Expression: "ops_sec * 2.0"}}
+	errs = controllers.UpdateDerivedValues(benchmark)
+	assert.Equal(t, 5, len(errs))
+	assert.Nil(t, benchmark.Status.Results[0].Items[0].DerivedValues)
+}
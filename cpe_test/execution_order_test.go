@@ -0,0 +1,112 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+// go test -v cpe_test/execution_order_test.go
+
+package controllers
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	"github.com/IBM/cpe-operator/controllers"
+)
+
+// 2 repetitions x 2 builds x 2 configurations in the nested loop order
+func getOrderKeys() []controllers.JobOrderKey {
+	var keys []controllers.JobOrderKey
+	for repetition := 0; repetition < 2; repetition++ {
+		for build := 0; build < 2; build++ {
+			for configuration := 0; configuration < 2; configuration++ {
+				keys = append(keys, controllers.JobOrderKey{Repetition: repetition, Build: build, Configuration: configuration})
+			}
+		}
+	}
+	return keys
+}
+
+func TestGetExecutionOrder(t *testing.T) {
+	keys := getOrderKeys()
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7}, controllers.GetExecutionOrder(keys, nil, 0))
+
+	interleaveBuild := &cpev1.ExecutionOrderSpec{Strategy: controllers.ORDER_INTERLEAVE}
+	assert.Equal(t, []int{0, 2, 1, 3, 4, 6, 5, 7}, controllers.GetExecutionOrder(keys, interleaveBuild, 0))
+
+	// configurations in the outer loop of builds: c0b0, c0b1, c1b0, c1b1 per repetition
+	var configurationKeys []controllers.JobOrderKey
+	for repetition := 0; repetition < 2; repetition++ {
+		for configuration := 0; configuration < 2; configuration++ {
+			for build := 0; build < 2; build++ {
+				configurationKeys = append(configurationKeys, controllers.JobOrderKey{Repetition: repetition, Build: build, Configuration: configuration})
+			}
+		}
+	}
+	interleaveConfiguration := &cpev1.ExecutionOrderSpec{Strategy: controllers.ORDER_INTERLEAVE, InterleaveBy: controllers.INTERLEAVE_CONFIGURATION}
+	order := controllers.GetExecutionOrder(configurationKeys, interleaveConfiguration, 0)
+	assert.Equal(t, []int{0, 2, 1, 3, 4, 6, 5, 7}, order)
+	for index := range order {
+		assert.Equal(t, index%2, configurationKeys[order[index]].Configuration)
+	}
+
+	shuffle := &cpev1.ExecutionOrderSpec{Strategy: controllers.ORDER_SHUFFLE}
+	order = controllers.GetExecutionOrder(keys, shuffle, 42)
+	fmt.Println("Shuffled: ", order)
+	assert.Equal(t, order, controllers.GetExecutionOrder(keys, shuffle, 42))
+	assert.ElementsMatch(t, []int{0, 1, 2, 3, 4, 5, 6, 7}, order)
+}
+
+func TestGetOrderSeed(t *testing.T) {
+	benchmark := &cpev1.Benchmark{}
+	_, updated := controllers.GetOrderSeed(benchmark)
+	assert.False(t, updated)
+
+	benchmark.Spec.IterationSpec.Order = &cpev1.ExecutionOrderSpec{Strategy: controllers.ORDER_SHUFFLE}
+	seed, updated := controllers.GetOrderSeed(benchmark)
+	assert.True(t, updated)
+
+	// reuse recorded seed
+	benchmark.Status.OrderSeed = &seed
+	recordedSeed, updated := controllers.GetOrderSeed(benchmark)
+	assert.False(t, updated)
+	assert.Equal(t, seed, recordedSeed)
+
+	// seed in spec takes priority
+	specSeed := seed + 1
+	benchmark.Spec.IterationSpec.Order.Seed = &specSeed
+	recordedSeed, updated = controllers.GetOrderSeed(benchmark)
+	assert.True(t, updated)
+	assert.Equal(t, specSeed, recordedSeed)
+}
+
+func TestGroupByConfiguration(t *testing.T) {
+	// repetition > configuration: a, b, a, b
+	groups := []string{"a", "b", "a", "b"}
+	assert.Equal(t, []int{0, 2, 1, 3}, controllers.GroupByConfiguration([]int{0, 1, 2, 3}, groups))
+	// first appearance in the given order
+	assert.Equal(t, []int{3, 1, 2, 0}, controllers.GroupByConfiguration([]int{3, 2, 1, 0}, groups))
+}
+
+func TestValidateExecutionOrder(t *testing.T) {
+	benchmark := &cpev1.Benchmark{}
+	benchmark.Spec.IterationSpec.Order = &cpev1.ExecutionOrderSpec{Strategy: controllers.ORDER_INTERLEAVE, InterleaveBy: controllers.INTERLEAVE_CONFIGURATION}
+	assert.Nil(t, controllers.ValidateExecutionOrder(benchmark))
+
+	// grouped by configuration
+	benchmark.Spec.SystemUnderTest = &cpev1.SystemUnderTestSpec{}
+	assert.NotNil(t, controllers.ValidateExecutionOrder(benchmark))
+	benchmark.Spec.IterationSpec.Order = &cpev1.ExecutionOrderSpec{Strategy: controllers.ORDER_SHUFFLE}
+	assert.Nil(t, controllers.ValidateExecutionOrder(benchmark))
+
+	// unknown strategy or interleaveBy
+	benchmark.Spec.SystemUnderTest = nil
+	benchmark.Spec.IterationSpec.Order = &cpev1.ExecutionOrderSpec{Strategy: "shufle"}
+	assert.NotNil(t, controllers.ValidateExecutionOrder(benchmark))
+	benchmark.Spec.IterationSpec.Order = &cpev1.ExecutionOrderSpec{Strategy: controllers.ORDER_INTERLEAVE, InterleaveBy: "configurations"}
+	assert.NotNil(t, controllers.ValidateExecutionOrder(benchmark))
+	benchmark.Spec.IterationSpec.Order = &cpev1.ExecutionOrderSpec{}
+	assert.Nil(t, controllers.ValidateExecutionOrder(benchmark))
+}
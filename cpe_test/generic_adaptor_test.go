@@ -0,0 +1,95 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+// go test -v cpe_test/generic_adaptor_test.go
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	"github.com/IBM/cpe-operator/controllers"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func getGenericJobObject(phase string, conditionType string) map[string]interface{} {
+	return map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "stream-cpeh-1234", "namespace": "default"},
+		"status": map[string]interface{}{
+			"state":      map[string]interface{}{"phase": phase},
+			"conditions": []interface{}{map[string]interface{}{"type": conditionType, "status": "True"}},
+		},
+	}
+}
+
+func TestGenericAdaptorCheckComplete(t *testing.T) {
+	adaptor := controllers.NewGenericAdaptor(cpev1.GenericAdaptorSpec{
+		CompleteStatus: &cpev1.StatusMatchSpec{Path: "/status/state/phase", Values: []string{"Completed"}},
+		FailedStatus:   &cpev1.StatusMatchSpec{Path: "$.status.state.phase", Values: []string{"Failed", "Aborted"}},
+	})
+	assert.False(t, adaptor.CheckComplete(getGenericJobObject("Running", "Running")))
+	assert.False(t, adaptor.CheckFailed(getGenericJobObject("Running", "Running")))
+	assert.True(t, adaptor.CheckComplete(getGenericJobObject("Completed", "Running")))
+	assert.True(t, adaptor.CheckFailed(getGenericJobObject("Aborted", "Running")))
+	assert.False(t, adaptor.CheckComplete(map[string]interface{}{"metadata": map[string]interface{}{}}))
+
+	adaptor = controllers.NewGenericAdaptor(cpev1.GenericAdaptorSpec{
+		CompleteConditions: []string{"Succeeded", "Complete"},
+		FailedConditions:   []string{"Failed"},
+	})
+	assert.True(t, adaptor.CheckComplete(getGenericJobObject("", "Complete")))
+	assert.False(t, adaptor.CheckFailed(getGenericJobObject("", "Complete")))
+	assert.True(t, adaptor.CheckFailed(getGenericJobObject("", "Failed")))
+
+	// built-in adaptors do not detect failure
+	assert.False(t, controllers.OperatorAdaptorMap["default"].CheckFailed(getGenericJobObject("", "Failed")))
+}
+
+func TestGenericAdaptorPods(t *testing.T) {
+	jobObject := getGenericJobObject("Completed", "Complete")
+	adaptor := controllers.NewGenericAdaptor(cpev1.GenericAdaptorSpec{})
+	selector, err := adaptor.GetPodSelector(jobObject)
+	assert.Nil(t, err)
+	assert.Equal(t, "job-name=stream-cpeh-1234", selector)
+	assert.Equal(t, "", adaptor.GetLogContainer(corev1.Pod{}))
+
+	adaptor = controllers.NewGenericAdaptor(cpev1.GenericAdaptorSpec{
+		PodSelector:     "volcano.sh/job-name={{ .metadata.name }},volcano.sh/job-namespace={{ .metadata.namespace }}",
+		ResultPod:       "{{ .metadata.name }}-client-",
+		ResultContainer: "client",
+	})
+	selector, err = adaptor.GetPodSelector(jobObject)
+	assert.Nil(t, err)
+	assert.Equal(t, "volcano.sh/job-name=stream-cpeh-1234,volcano.sh/job-namespace=default", selector)
+	podList := &corev1.PodList{Items: []corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "stream-cpeh-1234-server-0"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "stream-cpeh-1234-client-0"}},
+	}}
+	resultPods, err := adaptor.FilterResultPods(jobObject, podList)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(resultPods.Items))
+	assert.Equal(t, "stream-cpeh-1234-client-0", resultPods.Items[0].Name)
+	assert.Equal(t, "client", adaptor.GetLogContainer(resultPods.Items[0]))
+
+	// missing key
+	adaptor = controllers.NewGenericAdaptor(cpev1.GenericAdaptorSpec{PodSelector: "app={{ .spec.app }}"})
+	_, err = adaptor.GetPodSelector(jobObject)
+	assert.NotNil(t, err)
+}
+
+func TestGetOperatorAdaptor(t *testing.T) {
+	operator := &cpev1.BenchmarkOperator{Spec: cpev1.BenchmarkOperatorSpec{Adaptor: "mpi"}}
+	_, ok := controllers.GetOperatorAdaptor(operator).(*controllers.MPIAdaptor)
+	assert.True(t, ok)
+	operator.Spec.Adaptor = "unknown"
+	_, ok = controllers.GetOperatorAdaptor(operator).(*controllers.DefaultAdaptor)
+	assert.True(t, ok)
+	operator.Spec.GenericAdaptor = &cpev1.GenericAdaptorSpec{CompleteConditions: []string{"Succeeded"}}
+	_, ok = controllers.GetOperatorAdaptor(operator).(*controllers.GenericAdaptor)
+	assert.True(t, ok)
+}
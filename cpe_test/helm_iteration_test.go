@@ -0,0 +1,110 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+// go test -v cpe_test/helm_iteration_test.go
+
+package controllers
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	"github.com/IBM/cpe-operator/controllers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestGetHelmIteration(t *testing.T) {
+	benchmark := &cpev1.Benchmark{
+		ObjectMeta: metav1.ObjectMeta{Name: "cockroach", Namespace: "default"},
+		Spec: cpev1.BenchmarkSpec{
+			IterationSpec: cpev1.IterationSpec{
+				Iteration: []cpev1.IterationItem{{Name: "workload", Values: []string{"kv"}}},
+				Configuration: []cpev1.IterationItem{
+					{Name: "chart", Values: []string{"2.7.0", "2.8.0"}, Helm: "version"},
+					{Name: "replicas", Values: []string{"1", "2"}, Helm: ".operator.replicas"},
+					{Name: "nodes", Values: []string{"3"}},
+				},
+			},
+		},
+	}
+	assert.True(t, controllers.HasHelmIteration(benchmark))
+	iteration := controllers.GetHelmIteration(benchmark, map[string]string{"workload": "kv", "chart": "2.8.0", "replicas": "2", "nodes": "3"})
+	assert.Equal(t, &cpev1.HelmIteration{
+		Benchmark: "default/cockroach",
+		Version:   "2.8.0",
+		Values:    map[string]string{".operator.replicas": "2"},
+	}, iteration)
+
+	benchmark.Spec.IterationSpec.Configuration = benchmark.Spec.IterationSpec.Configuration[2:]
+	assert.False(t, controllers.HasHelmIteration(benchmark))
+	assert.Nil(t, controllers.GetHelmIteration(benchmark, map[string]string{"nodes": "3"}))
+}
+
+func TestGetHelmValuesYaml(t *testing.T) {
+	valuesYaml := `image:
+  repository: cockroachdb/cockroach-operator
+  tag: v2.7.0
+`
+	executed, err := controllers.GetHelmValuesYaml(valuesYaml, map[string]string{".image.tag": "v2.8.0", ".operator.replicas": "2", ".operator.debug": "true"})
+	assert.Nil(t, err)
+	values := make(map[string]interface{})
+	assert.Nil(t, yaml.Unmarshal([]byte(executed), &values))
+	image := values["image"].(map[string]interface{})
+	assert.Equal(t, "cockroachdb/cockroach-operator", image["repository"])
+	assert.Equal(t, "v2.8.0", image["tag"])
+	operator := values["operator"].(map[string]interface{})
+	assert.Equal(t, float64(2), operator["replicas"])
+	assert.Equal(t, true, operator["debug"])
+
+	// no values
+	executed, err = controllers.GetHelmValuesYaml(valuesYaml, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, valuesYaml, executed)
+}
+
+func TestIsHelmIterationApplied(t *testing.T) {
+	iteration := &cpev1.HelmIteration{Benchmark: "default/cockroach", Version: "2.8.0"}
+	requested, _ := json.Marshal(iteration)
+	benchmarkOperator := &cpev1.BenchmarkOperator{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "cockroach",
+			Annotations: map[string]string{controllers.HELM_ITERATION_ANNOTATION: string(requested)},
+		},
+	}
+	// not installed yet
+	applied, err := controllers.IsHelmIterationApplied(benchmarkOperator, iteration)
+	assert.False(t, applied)
+	assert.Nil(t, err)
+
+	benchmarkOperator.Status.HelmIteration = &cpev1.HelmIteration{Benchmark: "default/cockroach", Version: "2.8.0"}
+	applied, err = controllers.IsHelmIterationApplied(benchmarkOperator, iteration)
+	assert.True(t, applied)
+	assert.Nil(t, err)
+
+	benchmarkOperator.Status.HelmIterationError = "chart not found"
+	applied, err = controllers.IsHelmIterationApplied(benchmarkOperator, iteration)
+	assert.False(t, applied)
+	assert.NotNil(t, err)
+
+	// error of the previous request
+	next := &cpev1.HelmIteration{Benchmark: "default/cockroach", Version: "2.9.0"}
+	applied, err = controllers.IsHelmIterationApplied(benchmarkOperator, next)
+	assert.False(t, applied)
+	assert.Nil(t, err)
+}
+
+func TestGetHelmIterationTimeout(t *testing.T) {
+	assert.Equal(t, controllers.DEFAULT_HELM_ITERATION_TIMEOUT*time.Second, controllers.GetHelmIterationTimeout(cpev1.HelmSpec{}))
+	assert.Equal(t, 20*time.Minute, controllers.GetHelmIterationTimeout(cpev1.HelmSpec{TimeoutSeconds: 1200}))
+
+	benchmarkOperator := &cpev1.BenchmarkOperator{}
+	assert.True(t, controllers.GetHelmIterationRequestTime(benchmarkOperator).IsZero())
+	benchmarkOperator.Annotations = map[string]string{controllers.HELM_ITERATION_TIME_ANNOTATION: "2022-06-01T10:00:00Z"}
+	assert.Equal(t, time.Date(2022, 6, 1, 10, 0, 0, 0, time.UTC), controllers.GetHelmIterationRequestTime(benchmarkOperator))
+}
@@ -0,0 +1,92 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+// go test -v cpe_test/hook_test.go
+
+package controllers
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	"github.com/IBM/cpe-operator/controllers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestGetHookJob(t *testing.T) {
+	benchmark := &cpev1.Benchmark{
+		ObjectMeta: metav1.ObjectMeta{Name: "tpcc", Namespace: "default"},
+		Spec: cpev1.BenchmarkSpec{
+			Hooks: &cpev1.HooksSpec{
+				PreRun: `template:
+  spec:
+    containers:
+    - name: load
+      image: tpcc-loader
+      args: ["--warehouses={{ .warehouses }}", "--{{ .hook }}"]
+    restartPolicy: Never`,
+				BenchmarkPostRun: `template:
+  spec:
+    containers:
+    - name: report
+      image: reporter
+      args: ["{{ .benchmark.name }}"]
+    restartPolicy: Never`,
+			},
+		},
+	}
+	hashItem := cpev1.IterationHash{Hash: "123", Build: "init", Iteration: map[string]string{"warehouses": "10"}, Repetition: "0"}
+	hookJob, err := controllers.GetHookJob(benchmark, controllers.HOOK_PRE_RUN, &hashItem)
+	assert.Nil(t, err)
+	assert.Equal(t, "tpcc-cpeh-123-prerun", hookJob.GetName())
+	assert.Equal(t, "Job", hookJob.GetKind())
+	assert.Equal(t, "tpcc", hookJob.GetLabels()[controllers.HOOK_LABEL])
+	containers, _, _ := unstructured.NestedSlice(hookJob.Object, "spec", "template", "spec", "containers")
+	assert.Equal(t, []interface{}{"--warehouses=10", "--preRun"}, containers[0].(map[string]interface{})["args"])
+	// timeout is set to activeDeadlineSeconds
+	deadline, _, _ := unstructured.NestedInt64(hookJob.Object, "spec", "activeDeadlineSeconds")
+	assert.Equal(t, int64(controllers.DEFAULT_HOOK_TIMEOUT), deadline)
+
+	hookJob, err = controllers.GetHookJob(benchmark, controllers.HOOK_POST_RUN, &hashItem)
+	assert.Nil(t, err)
+	assert.Nil(t, hookJob)
+
+	hookJob, err = controllers.GetHookJob(benchmark, controllers.HOOK_BENCHMARK_POST_RUN, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "tpcc-cpe-benchmarkpostrun", hookJob.GetName())
+
+	finished, succeeded := controllers.IsHookJobFinished(&unstructured.Unstructured{Object: map[string]interface{}{"status": map[string]interface{}{"succeeded": int64(1)}}})
+	assert.True(t, finished && succeeded)
+	finished, succeeded = controllers.IsHookJobFinished(&unstructured.Unstructured{Object: map[string]interface{}{"status": map[string]interface{}{
+		"conditions": []interface{}{map[string]interface{}{"type": "Failed", "status": "True"}},
+	}}})
+	assert.True(t, finished)
+	assert.False(t, succeeded)
+	finished, _ = controllers.IsHookJobFinished(&unstructured.Unstructured{Object: map[string]interface{}{"status": map[string]interface{}{"active": int64(1)}}})
+	assert.False(t, finished)
+}
+
+func TestAddFailedJob(t *testing.T) {
+	benchmark := &cpev1.Benchmark{
+		ObjectMeta: metav1.ObjectMeta{Name: "tpcc", Namespace: "default"},
+		Spec: cpev1.BenchmarkSpec{
+			IterationSpec: cpev1.IterationSpec{
+				Iteration: []cpev1.IterationItem{{Name: "warehouses", Values: []string{"10"}}},
+			},
+		},
+	}
+	benchmark.Status.Hash = []cpev1.IterationHash{{Hash: "123", Build: "init", Iteration: map[string]string{"warehouses": "10"}, Repetition: "0"}}
+	hookErr := &controllers.HookError{Hook: controllers.HOOK_PRE_RUN, JobName: "tpcc-cpeh-123", Err: fmt.Errorf("failed")}
+	assert.False(t, controllers.CheckIfJobDone(benchmark, "tpcc-cpeh-123"))
+	assert.True(t, controllers.AddFailedJob(benchmark, hookErr))
+	assert.False(t, controllers.AddFailedJob(benchmark, hookErr))
+	assert.Equal(t, 1, len(benchmark.Status.FailedJobs))
+	assert.Equal(t, "init", benchmark.Status.FailedJobs[0].BuildID)
+	assert.Equal(t, "warehouses=10", benchmark.Status.FailedJobs[0].IterationID)
+	assert.True(t, controllers.CheckIfJobDone(benchmark, "tpcc-cpeh-123"))
+}
@@ -0,0 +1,162 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+// go test -v cpe_test/iteration_location_test.go
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	"github.com/IBM/cpe-operator/controllers"
+	"sigs.k8s.io/yaml"
+)
+
+var locationTestSpec = `
+template:
+  spec:
+    nodeSelector:
+      disktype: ssd
+    containers:
+    - name: server
+      image: server:v1
+      args: ["--threads", "1"]
+      resources:
+        limits:
+          cpu: 1
+    - name: client
+      image: client:v1
+`
+
+func getLocationTestObject(t *testing.T) map[string]interface{} {
+	object := make(map[string]interface{})
+	assert.Nil(t, yaml.Unmarshal([]byte(locationTestSpec), &object))
+	return object
+}
+
+func getContainers(object map[string]interface{}) []interface{} {
+	return object["template"].(map[string]interface{})["spec"].(map[string]interface{})["containers"].([]interface{})
+}
+
+func TestSetValueJSONPointer(t *testing.T) {
+	handler := &controllers.IterationHandler{}
+	object := getLocationTestObject(t)
+
+	// replace keeps type of the current value
+	object, err := handler.SetValue(object, cpev1.IterationItem{Location: "/template/spec/containers/0/resources/limits/cpu", Op: "replace"}, "4")
+	assert.Nil(t, err)
+	limits := getContainers(object)[0].(map[string]interface{})["resources"].(map[string]interface{})["limits"].(map[string]interface{})
+	assert.Equal(t, float64(4), limits["cpu"])
+
+	// add requires parent unless createParents is set, ~1 is /
+	_, err = handler.SetValue(object, cpev1.IterationItem{Location: "/template/metadata/annotations/cpe.cogadvisor.io~1profile"}, "tuned")
+	assert.NotNil(t, err)
+	_, err = handler.SetValue(object, cpev1.IterationItem{Location: "/spec/template/spec/containers/0/args/-"}, "--debug")
+	assert.NotNil(t, err)
+	assert.NotContains(t, object, "spec")
+	object, err = handler.SetValue(object, cpev1.IterationItem{Location: "/template/metadata/annotations/cpe.cogadvisor.io~1profile", CreateParents: true}, "tuned")
+	assert.Nil(t, err)
+	annotations := object["template"].(map[string]interface{})["metadata"].(map[string]interface{})["annotations"].(map[string]interface{})
+	assert.Equal(t, "tuned", annotations["cpe.cogadvisor.io/profile"])
+
+	// add inserts into array and - appends
+	object, err = handler.SetValue(object, cpev1.IterationItem{Location: "/template/spec/containers/0/args/0"}, "--verbose")
+	assert.Nil(t, err)
+	object, err = handler.SetValue(object, cpev1.IterationItem{Location: "/template/spec/containers/0/args/-"}, "--debug")
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"--verbose", "--threads", "1", "--debug"}, getContainers(object)[0].(map[string]interface{})["args"])
+
+	// yaml value adds whole block
+	object, err = handler.SetValue(object, cpev1.IterationItem{Location: "/template/spec/containers/-", Type: "yaml"}, "name: sidecar\nimage: sidecar:v1\nports: [8080]")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "sidecar", "image": "sidecar:v1", "ports": []interface{}{int64(8080)}}, getContainers(object)[2])
+
+	// remove
+	object, err = handler.SetValue(object, cpev1.IterationItem{Location: "/template/spec/nodeSelector", Op: "remove"}, "true")
+	assert.Nil(t, err)
+	assert.NotContains(t, object["template"].(map[string]interface{})["spec"], "nodeSelector")
+	object, err = handler.SetValue(object, cpev1.IterationItem{Location: "/template/spec/containers/1", Op: "remove"}, "true")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(getContainers(object)))
+
+	// replace requires existing value
+	_, err = handler.SetValue(object, cpev1.IterationItem{Location: "/template/spec/hostNetwork", Op: "replace"}, "true")
+	assert.NotNil(t, err)
+	_, err = handler.SetValue(object, cpev1.IterationItem{Location: "/template/spec/containers/5/image"}, "server:v2")
+	assert.NotNil(t, err)
+}
+
+func TestSetValueJSONPath(t *testing.T) {
+	handler := &controllers.IterationHandler{}
+	object := getLocationTestObject(t)
+
+	// filter
+	object, err := handler.SetValue(object, cpev1.IterationItem{Location: "$.template.spec.containers[?(@.name=='client')].image"}, "client:v2")
+	assert.Nil(t, err)
+	containers := getContainers(object)
+	assert.Equal(t, "server:v1", containers[0].(map[string]interface{})["image"])
+	assert.Equal(t, "client:v2", containers[1].(map[string]interface{})["image"])
+
+	// wildcard with typed value
+	object, err = handler.SetValue(object, cpev1.IterationItem{Location: "$.template.spec.containers[*].tty", Type: "bool"}, "true")
+	assert.Nil(t, err)
+	for _, container := range getContainers(object) {
+		assert.Equal(t, true, container.(map[string]interface{})["tty"])
+	}
+
+	// index and quoted name
+	object, err = handler.SetValue(object, cpev1.IterationItem{Location: "$.template.spec.nodeSelector['kubernetes.io/arch']"}, "arm64")
+	assert.Nil(t, err)
+	object, err = handler.SetValue(object, cpev1.IterationItem{Location: "$.template.spec.containers[0].args[1]", Type: "int"}, "8")
+	assert.Nil(t, err)
+	assert.Equal(t, "arm64", object["template"].(map[string]interface{})["spec"].(map[string]interface{})["nodeSelector"].(map[string]interface{})["kubernetes.io/arch"])
+	assert.Equal(t, []interface{}{"--threads", int64(8)}, getContainers(object)[0].(map[string]interface{})["args"])
+
+	// remove matched
+	object, err = handler.SetValue(object, cpev1.IterationItem{Location: "$.template.spec.containers[?(@.name=='client')]", Op: "remove"}, "true")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(getContainers(object)))
+
+	// no match
+	_, err = handler.SetValue(object, cpev1.IterationItem{Location: "$.template.spec.containers[?(@.name=='none')].image"}, "none:v1")
+	assert.NotNil(t, err)
+
+	// missing parents are created only with createParents
+	_, err = handler.SetValue(object, cpev1.IterationItem{Location: "$.spec.template.spec.hostNetwork", Type: "bool"}, "true")
+	assert.NotNil(t, err)
+	assert.NotContains(t, object, "spec")
+	object, err = handler.SetValue(object, cpev1.IterationItem{Location: "$.template.spec.securityContext.runAsUser", Type: "int", CreateParents: true}, "1000")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1000), object["template"].(map[string]interface{})["spec"].(map[string]interface{})["securityContext"].(map[string]interface{})["runAsUser"])
+
+	// createParents requires JSON Pointer or JSONPath
+	_, err = handler.SetValue(object, cpev1.IterationItem{Location: ".template.spec.hostNetwork", CreateParents: true}, "true")
+	assert.NotNil(t, err)
+}
+
+func TestGetTypedValue(t *testing.T) {
+	value, err := controllers.GetTypedValue("3", "", int64(1))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), value)
+	value, err = controllers.GetTypedValue("3", "", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "3", value)
+	value, err = controllers.GetTypedValue("0.5", "float", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 0.5, value)
+	value, err = controllers.GetTypedValue("[a, b]", "yaml", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"a", "b"}, value)
+	_, err = controllers.GetTypedValue("many", "int", nil)
+	assert.NotNil(t, err)
+	_, err = controllers.GetTypedValue("1", "list", nil)
+	assert.NotNil(t, err)
+
+	// op and type require JSON Pointer or JSONPath
+	_, err = (&controllers.IterationHandler{}).SetValue(map[string]interface{}{}, cpev1.IterationItem{Location: ".spec.replicas", Type: "int"}, "1")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+// go test -v cpe_test/node_info_test.go
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/IBM/cpe-operator/controllers"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetNodeFingerprint(t *testing.T) {
+	testCases := []struct {
+		name         string
+		labels       map[string]string
+		instanceType string
+		zone         string
+		cpuModel     string
+		labelCount   int
+	}{
+		{
+			name: "with labels",
+			labels: map[string]string{
+				corev1.LabelInstanceTypeStable:       "bx2.16x64",
+				corev1.LabelTopologyZone:             "us-south-1",
+				controllers.NFD_CPU_MODEL_VENDOR:     "Intel",
+				controllers.NFD_CPU_MODEL_FAMILY:     "6",
+				controllers.NFD_CPU_MODEL_ID:         "85",
+				"feature.node.kubernetes.io/cpu-sse": "true",
+				"app":                                "ignored",
+			},
+			instanceType: "bx2.16x64",
+			zone:         "us-south-1",
+			cpuModel:     "Intel-6-85",
+			labelCount:   5,
+		},
+		{
+			name:   "without labels",
+			labels: nil,
+		},
+		{
+			name: "partial labels",
+			labels: map[string]string{
+				corev1.LabelInstanceType:          "m5.xlarge",
+				corev1.LabelFailureDomainBetaZone: "us-east-1a",
+				controllers.NFD_CPU_MODEL_VENDOR:  "AMD",
+				controllers.NFD_CPU_MODEL_ID:      "49",
+			},
+			instanceType: "m5.xlarge",
+			zone:         "us-east-1a",
+			cpuModel:     "AMD-49",
+			labelCount:   4,
+		},
+	}
+	for _, testCase := range testCases {
+		node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker", Labels: testCase.labels}}
+		node.Status.NodeInfo.KernelVersion = "5.4.0"
+		node.Status.Allocatable = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("16")}
+		fingerprint := controllers.GetNodeFingerprint(node)
+		assert.Equal(t, testCase.instanceType, fingerprint.InstanceType, testCase.name)
+		assert.Equal(t, testCase.zone, fingerprint.Zone, testCase.name)
+		assert.Equal(t, testCase.cpuModel, fingerprint.CPUModel, testCase.name)
+		assert.Equal(t, testCase.labelCount, len(fingerprint.Labels), testCase.name)
+		assert.Equal(t, "5.4.0", fingerprint.KernelVersion, testCase.name)
+		assert.Equal(t, "16", fingerprint.Allocatable["cpu"], testCase.name)
+	}
+}
@@ -0,0 +1,83 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+// go test -v cpe_test/node_reserver_test.go
+
+package controllers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	"github.com/IBM/cpe-operator/controllers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func getExclusiveBenchmark() *cpev1.Benchmark {
+	return &cpev1.Benchmark{
+		ObjectMeta: metav1.ObjectMeta{Name: "coremark", Namespace: "default"},
+		Spec: cpev1.BenchmarkSpec{
+			ExclusiveNodes: &cpev1.ExclusiveNodesSpec{
+				Cordon: true,
+			},
+		},
+	}
+}
+
+func TestAddTolerations(t *testing.T) {
+	benchmark := getExclusiveBenchmark()
+	object := GetInitObject()
+	modifiedObject := controllers.AddTolerations(object, benchmark)
+	fmt.Printf("Modify: %v\n", modifiedObject)
+	tolerations := modifiedObject["template"].(map[string]interface{})["spec"].(map[string]interface{})["tolerations"].([]interface{})
+	assert.Equal(t, 2, len(tolerations))
+	assert.Equal(t, "default-coremark", tolerations[0].(map[string]interface{})["value"])
+	assert.Equal(t, "node.kubernetes.io/unschedulable", tolerations[1].(map[string]interface{})["key"])
+
+	// append to existing list
+	modifiedObject = controllers.AddTolerations(modifiedObject, benchmark)
+	tolerations = modifiedObject["template"].(map[string]interface{})["spec"].(map[string]interface{})["tolerations"].([]interface{})
+	assert.Equal(t, 4, len(tolerations))
+}
+
+func TestValidateExclusiveNodes(t *testing.T) {
+	benchmark := getExclusiveBenchmark()
+	assert.Nil(t, controllers.ValidateExclusiveNodes(benchmark))
+	benchmark.Spec.ExclusiveNodes.TolerationLocation = ".spec.template.spec.tolerations"
+	assert.Nil(t, controllers.ValidateExclusiveNodes(benchmark))
+	// missing leading dot, JSON Pointer, and JSONPath are rejected
+	for _, location := range []string{"template.spec.tolerations", "/template/spec/tolerations", "$.template.spec.tolerations", "."} {
+		benchmark.Spec.ExclusiveNodes.TolerationLocation = location
+		assert.NotNil(t, controllers.ValidateExclusiveNodes(benchmark), location)
+	}
+}
+
+func TestIsBenchmarkCompleted(t *testing.T) {
+	benchmark := getExclusiveBenchmark()
+	benchmark.Spec.Repetition = 2
+	assert.False(t, controllers.IsBenchmarkCompleted(benchmark))
+	benchmark.Status.Results = []cpev1.BenchmarkResult{{
+		Items: []cpev1.BenchmarkResultItem{{JobName: "coremark-cpeh-unknown"}},
+	}}
+	assert.False(t, controllers.IsBenchmarkCompleted(benchmark))
+
+	// all repetitions have results
+	jobNames := controllers.GetBuildJobNames(benchmark, controllers.INIT_BUILD_NAME)
+	assert.Equal(t, 2, len(jobNames))
+	benchmark.Status.Results[0].Items = []cpev1.BenchmarkResultItem{{JobName: jobNames[0]}}
+	assert.False(t, controllers.IsBenchmarkCompleted(benchmark))
+	benchmark.Status.Results[0].Items = append(benchmark.Status.Results[0].Items, cpev1.BenchmarkResultItem{JobName: jobNames[1]})
+	assert.True(t, controllers.IsBenchmarkCompleted(benchmark))
+}
+
+func TestGetRenewalInterval(t *testing.T) {
+	benchmark := getExclusiveBenchmark()
+	assert.Equal(t, time.Duration(controllers.DEFAULT_LEASE_DURATION/3)*time.Second, controllers.GetRenewalInterval(benchmark))
+	benchmark.Spec.ExclusiveNodes.LeaseDurationSeconds = 90
+	assert.Equal(t, 30*time.Second, controllers.GetRenewalInterval(benchmark))
+}
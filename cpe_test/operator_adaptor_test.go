@@ -0,0 +1,67 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+// go test -v cpe_test/operator_adaptor_test.go
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/IBM/cpe-operator/controllers"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func getRayJobObject(jobStatus string, deploymentStatus string) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "ray.io/v1",
+		"kind":       "RayJob",
+		"metadata":   map[string]interface{}{"name": "rayjob-cpeh-1234", "namespace": "default", "resourceVersion": "10", "labels": map[string]interface{}{"cpe-benchmark": "rayjob"}},
+		"spec":       map[string]interface{}{"entrypoint": "python job_example.py"},
+		"status":     map[string]interface{}{"jobStatus": jobStatus, "jobDeploymentStatus": deploymentStatus, "rayClusterName": "rayjob-cpeh-1234-raycluster-abcde"},
+	}
+}
+
+func TestRayAdaptorStatus(t *testing.T) {
+	adaptor := controllers.OperatorAdaptorMap["ray"]
+	running := getRayJobObject("RUNNING", "Running")
+	assert.False(t, adaptor.CheckComplete(running))
+	assert.False(t, adaptor.CheckFailed(running))
+
+	// job status is set before the submitter completes
+	assert.False(t, adaptor.CheckComplete(getRayJobObject("SUCCEEDED", "Running")))
+	assert.True(t, adaptor.CheckComplete(getRayJobObject("SUCCEEDED", "Complete")))
+
+	assert.True(t, adaptor.CheckFailed(getRayJobObject("FAILED", "Complete")))
+	assert.True(t, adaptor.CheckFailed(getRayJobObject("STOPPED", "Running")))
+	assert.True(t, adaptor.CheckFailed(getRayJobObject("", "FailedToGetOrCreateRayCluster")))
+	assert.False(t, adaptor.CheckComplete(getRayJobObject("FAILED", "Complete")))
+}
+
+func TestRayAdaptorResources(t *testing.T) {
+	adaptor := controllers.OperatorAdaptorMap["ray"]
+	submitter := corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "istio-proxy"}, {Name: "ray-job-submitter"}}}}
+	assert.Equal(t, "ray-job-submitter", adaptor.GetLogContainer(submitter))
+	assert.Equal(t, "", adaptor.GetLogContainer(corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "ray-head"}}}}))
+
+	jobObject := getRayJobObject("SUCCEEDED", "Complete")
+	copied := adaptor.CopyJobResource(&unstructured.Unstructured{Object: jobObject})
+	assert.NotContains(t, copied.Object, "status")
+	assert.Equal(t, "", copied.GetResourceVersion())
+	assert.Equal(t, "rayjob-cpeh-1234", copied.GetName())
+	assert.Equal(t, map[string]string{"cpe-benchmark": "rayjob"}, copied.GetLabels())
+
+	clusterGVR, clusterName := controllers.GetRayClusterToDelete(jobObject)
+	assert.Equal(t, schema.GroupVersionResource{Group: "ray.io", Version: "v1", Resource: "rayclusters"}, clusterGVR)
+	assert.Equal(t, "rayjob-cpeh-1234-raycluster-abcde", clusterName)
+
+	// existing cluster is not deleted
+	jobObject["spec"].(map[string]interface{})["clusterSelector"] = map[string]interface{}{"ray.io/cluster": "shared"}
+	_, clusterName = controllers.GetRayClusterToDelete(jobObject)
+	assert.Equal(t, "", clusterName)
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+// go test -v cpe_test/placement_test.go
+
+package controllers
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	"github.com/IBM/cpe-operator/controllers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAddNodeAffinity(t *testing.T) {
+	benchmark := &cpev1.Benchmark{
+		ObjectMeta: metav1.ObjectMeta{Name: "coremark", Namespace: "default"},
+		Spec: cpev1.BenchmarkSpec{
+			Placement: &cpev1.PlacementSpec{Policy: controllers.PLACEMENT_SPREAD},
+		},
+	}
+	nodes := []string{"node-a", "node-b"}
+	assert.Equal(t, "node-a", controllers.GetSpreadNode(nodes, 0))
+	assert.Equal(t, "node-b", controllers.GetSpreadNode(nodes, 1))
+	assert.Equal(t, "node-a", controllers.GetSpreadNode(nodes, 2))
+
+	object := GetInitObject()
+	modifiedObject := controllers.AddNodeAffinity(object, benchmark, "node-b")
+	fmt.Printf("Modify: %v\n", modifiedObject)
+	affinity := modifiedObject["template"].(map[string]interface{})["spec"].(map[string]interface{})["affinity"].(map[string]interface{})
+	required := affinity["nodeAffinity"].(map[string]interface{})["requiredDuringSchedulingIgnoredDuringExecution"].(map[string]interface{})
+	terms := required["nodeSelectorTerms"].([]interface{})
+	assert.Equal(t, 1, len(terms))
+	matchFields := terms[0].(map[string]interface{})["matchFields"].([]interface{})
+	assert.Equal(t, []interface{}{"node-b"}, matchFields[0].(map[string]interface{})["values"])
+}
+
+func TestGetResultSummary(t *testing.T) {
+	items := []cpev1.BenchmarkResultItem{
+		{NodeName: "node-a", PerformanceValue: "1.0"},
+		{NodeName: "node-a", PerformanceValue: "3.0"},
+		{NodeName: "node-b", PerformanceValue: "5.0"},
+		{NodeName: "node-b", PerformanceValue: "7.0"},
+	}
+	summary := controllers.GetResultSummary(items)
+	assert.Equal(t, 4, summary.Count)
+	assert.Equal(t, "4.000000", summary.Mean)
+	assert.Equal(t, 2, summary.NodeCount)
+	// (MSB 16 - MSW 2) / 2 repetitions per node
+	assert.Equal(t, "7.000000", summary.BetweenNodeVariance)
+	assert.Equal(t, "2.000000", summary.WithinNodeVariance)
+
+	// between-node variance truncated at 0
+	summary = controllers.GetResultSummary([]cpev1.BenchmarkResultItem{
+		{NodeName: "node-a", PerformanceValue: "1.0"},
+		{NodeName: "node-a", PerformanceValue: "7.0"},
+		{NodeName: "node-b", PerformanceValue: "2.0"},
+		{NodeName: "node-b", PerformanceValue: "6.0"},
+	})
+	assert.Equal(t, "0.000000", summary.BetweenNodeVariance)
+	assert.Equal(t, "13.000000", summary.WithinNodeVariance)
+
+	// no variance split with one repetition per node (spread with repetitions not outnumbering nodes)
+	summary = controllers.GetResultSummary([]cpev1.BenchmarkResultItem{items[0], items[2]})
+	assert.Equal(t, 2, summary.NodeCount)
+	assert.Equal(t, "", summary.BetweenNodeVariance)
+
+	// no variance split for single node
+	summary = controllers.GetResultSummary(items[:2])
+	assert.Equal(t, 1, summary.NodeCount)
+	assert.Equal(t, "", summary.BetweenNodeVariance)
+	assert.Nil(t, controllers.GetResultSummary([]cpev1.BenchmarkResultItem{}))
+}
+
+func TestValidatePlacement(t *testing.T) {
+	benchmark := &cpev1.Benchmark{}
+	assert.Nil(t, controllers.ValidatePlacement(benchmark))
+	benchmark.Spec.Placement = &cpev1.PlacementSpec{Policy: controllers.PLACEMENT_SPREAD}
+	assert.Nil(t, controllers.ValidatePlacement(benchmark))
+	benchmark.Spec.Placement.Policy = "pack"
+	assert.NotNil(t, controllers.ValidatePlacement(benchmark))
+	// affinity location must be dotted
+	benchmark.Spec.Placement = &cpev1.PlacementSpec{Policy: controllers.PLACEMENT_SPREAD, AffinityLocation: ".spec.affinity"}
+	assert.Nil(t, controllers.ValidatePlacement(benchmark))
+	benchmark.Spec.Placement.AffinityLocation = "template.spec.affinity"
+	assert.NotNil(t, controllers.ValidatePlacement(benchmark))
+	benchmark.Spec.Placement.AffinityLocation = "/template/spec/affinity"
+	assert.NotNil(t, controllers.ValidatePlacement(benchmark))
+}
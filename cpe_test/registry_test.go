@@ -0,0 +1,132 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+// go test -v cpe_test/registry_test.go
+
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	"github.com/IBM/cpe-operator/controllers"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func newFakeRegistry() *httptest.Server {
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		username, password, _ := r.BasicAuth()
+		if username != "user" || password != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"token": "secret-token"}`)
+	})
+	mux.HandleFunc("/v2/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret-token" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry",scope="repository:cpe/coremark:pull"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.URL.Path == "/v2/cpe/coremark/tags/list" && r.URL.Query().Get("last") == "":
+			w.Header().Set("Link", `</v2/cpe/coremark/tags/list?n=2&last=v1.1.0>; rel="next"`)
+			fmt.Fprint(w, `{"name": "cpe/coremark", "tags": ["latest", "v1.1.0"]}`)
+		case r.URL.Path == "/v2/cpe/coremark/tags/list":
+			fmt.Fprint(w, `{"name": "cpe/coremark", "tags": ["v1.0.0", "v1.2.0-rc1", "v1.2.0"]}`)
+		case strings.HasPrefix(r.URL.Path, "/v2/cpe/coremark/manifests/"):
+			w.Header().Set("Docker-Content-Digest", "sha256:"+strings.TrimPrefix(r.URL.Path, "/v2/cpe/coremark/manifests/"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	server = httptest.NewServer(mux)
+	return server
+}
+
+func TestRegistryClient(t *testing.T) {
+	server := newFakeRegistry()
+	defer server.Close()
+
+	registry := strings.TrimPrefix(server.URL, "http://")
+	registryClient := controllers.NewRegistryClient(registry, "user", "pass", true)
+	tags, err := registryClient.ListTags("cpe/coremark")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, []string{"latest", "v1.1.0", "v1.0.0", "v1.2.0-rc1", "v1.2.0"}, tags)
+
+	digest, err := registryClient.GetDigest("cpe/coremark", "v1.2.0")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, "sha256:v1.2.0", digest)
+
+	// wrong credential
+	_, err = controllers.NewRegistryClient(registry, "user", "wrong", true).ListTags("cpe/coremark")
+	assert.NotEqual(t, err, nil)
+}
+
+func TestFilterImageTags(t *testing.T) {
+	tags := []string{"latest", "v1.1.0", "v1.0.0", "v1.2.0-rc1", "v1.2.0"}
+	filteredTags, err := controllers.FilterImageTags(tags, "", ">=1.1.0")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, []string{"v1.1.0", "v1.2.0"}, filteredTags)
+
+	filteredTags, err = controllers.FilterImageTags(tags, "-rc", "")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, []string{"v1.2.0-rc1"}, filteredTags)
+}
+
+func TestParseImageRepository(t *testing.T) {
+	registry, repository := controllers.ParseImageRepository("quay.io/cpe/coremark:latest")
+	assert.Equal(t, "quay.io", registry)
+	assert.Equal(t, "cpe/coremark", repository)
+	registry, repository = controllers.ParseImageRepository("ubuntu")
+	assert.Equal(t, "registry-1.docker.io", registry)
+	assert.Equal(t, "library/ubuntu", repository)
+	registry, repository = controllers.ParseImageRepository("localhost:5000/coremark")
+	assert.Equal(t, "localhost:5000", registry)
+	assert.Equal(t, "coremark", repository)
+}
+
+func TestGetNewImageBuilds(t *testing.T) {
+	benchmark := &cpev1.Benchmark{}
+	configSpec := cpev1.ConfigSpec{Name: "coremark", Kind: controllers.IMAGE_REPOSITORY_KIND, Image: "quay.io/cpe/coremark"}
+
+	// first poll only records tags
+	trackedBuilds, keys := controllers.GetNewImageBuilds(benchmark, configSpec, []string{"v1.0.0"}, nil)
+	assert.Equal(t, 0, len(trackedBuilds))
+	benchmark.Status.ImageTags = []cpev1.SeenImageTags{{Config: "coremark", Tags: keys}}
+
+	trackedBuilds, _ = controllers.GetNewImageBuilds(benchmark, configSpec, []string{"v1.0.0", "v1.1.0"}, nil)
+	assert.Equal(t, 1, len(trackedBuilds))
+	assert.Equal(t, "quay.io/cpe/coremark:v1.1.0", trackedBuilds[0].Name)
+	assert.Equal(t, "quay.io/cpe/coremark:v1.1.0", trackedBuilds[0].Image)
+
+	// new digest of the same tag
+	configSpec.TrackDigest = true
+	benchmark.Status.ImageTags = []cpev1.SeenImageTags{{Config: "coremark", Tags: []string{"latest@sha256:1"}}}
+	trackedBuilds, _ = controllers.GetNewImageBuilds(benchmark, configSpec, []string{"latest"}, map[string]string{"latest": "sha256:2"})
+	assert.Equal(t, 1, len(trackedBuilds))
+	assert.Equal(t, "quay.io/cpe/coremark@sha256:2", trackedBuilds[0].Name)
+}
+
+func TestGetRegistryCredential(t *testing.T) {
+	secret := &corev1.Secret{Data: map[string][]byte{
+		corev1.DockerConfigJsonKey: []byte(`{"auths": {"https://quay.io": {"auth": "dXNlcjpwYXNz"}}}`),
+	}}
+	username, password := controllers.GetRegistryCredential(secret, "quay.io")
+	assert.Equal(t, "user", username)
+	assert.Equal(t, "pass", password)
+
+	secret = &corev1.Secret{Data: map[string][]byte{"username": []byte("user"), "password": []byte("pass")}}
+	username, password = controllers.GetRegistryCredential(secret, "quay.io")
+	assert.Equal(t, "user", username)
+	assert.Equal(t, "pass", password)
+}
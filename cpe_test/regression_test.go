@@ -0,0 +1,68 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+// go test -v cpe_test/regression_test.go
+
+package controllers
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	"github.com/IBM/cpe-operator/controllers"
+)
+
+func newResult(build string, values ...float64) cpev1.BenchmarkResult {
+	result := cpev1.BenchmarkResult{BuildID: build, IterationID: "s1", ConfigurationID: "c1"}
+	for _, value := range values {
+		result.Items = append(result.Items, cpev1.BenchmarkResultItem{PerformanceKey: "score", PerformanceValue: fmt.Sprintf("%f", value)})
+	}
+	return result
+}
+
+func TestWelchTTest(t *testing.T) {
+	// reference values from scipy.stats.ttest_ind(equal_var=False)
+	tValue, pValue := controllers.WelchTTest([]float64{27.5, 21.0, 19.0, 23.6, 17.0, 17.9, 16.9, 20.1, 21.9, 22.6, 23.1, 19.6, 19.0, 21.7, 21.4},
+		[]float64{27.1, 22.0, 20.8, 23.4, 23.4, 23.5, 25.8, 22.0, 24.8, 20.2, 21.9, 22.1, 22.9, 20.5, 24.4})
+	assert.InDelta(t, -2.46, tValue, 0.01)
+	assert.InDelta(t, 0.021, pValue, 0.001)
+
+	_, pValue = controllers.WelchTTest([]float64{1}, []float64{2, 3})
+	assert.Equal(t, 1.0, pValue)
+}
+
+func TestGetRegressions(t *testing.T) {
+	benchmark := &cpev1.Benchmark{}
+	benchmark.Spec.Repetition = 3
+	benchmark.Status.TrackedBuilds = []string{"build-1", "build-2", "build-3"}
+	benchmark.Status.Results = []cpev1.BenchmarkResult{
+		newResult("build-1", 100, 101, 99),
+		newResult("build-2", 90, 91, 89),
+		newResult("build-3", 90, 92, 89.5),
+	}
+	regressions := controllers.GetRegressions(benchmark)
+	assert.Equal(t, 2, len(regressions))
+	assert.Equal(t, "build-1", regressions[0].BaselineID)
+	assert.Equal(t, controllers.VERDICT_REGRESSED, regressions[0].Verdict)
+	assert.Equal(t, "build-2", regressions[1].BaselineID)
+	assert.Equal(t, controllers.VERDICT_UNCHANGED, regressions[1].Verdict)
+
+	// lower is better
+	benchmark.Spec.IterationSpec.Minimize = true
+	regressions = controllers.GetRegressions(benchmark)
+	assert.Equal(t, controllers.VERDICT_IMPROVED, regressions[0].Verdict)
+	assert.Equal(t, 1, len(controllers.GetNewVerdicts(nil, regressions)))
+	assert.Equal(t, 0, len(controllers.GetNewVerdicts(regressions, regressions)))
+
+	// pinned baseline and incomplete result
+	benchmark.Spec.Regression = &cpev1.RegressionSpec{Baseline: "build-1", Tolerance: "0.2"}
+	benchmark.Status.Results[2] = newResult("build-3", 90)
+	regressions = controllers.GetRegressions(benchmark)
+	assert.Equal(t, 1, len(regressions))
+	assert.Equal(t, "build-2", regressions[0].BuildID)
+	assert.Equal(t, controllers.VERDICT_UNCHANGED, regressions[0].Verdict)
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+// go test -v cpe_test/scenario_resource_test.go
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	"github.com/IBM/cpe-operator/controllers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestGetScenarioResources(t *testing.T) {
+	benchmark := &cpev1.Benchmark{
+		ObjectMeta: metav1.ObjectMeta{Name: "iperf", Namespace: "default"},
+		Spec: cpev1.BenchmarkSpec{
+			Resources: []cpev1.ScenarioResourceSpec{
+				{
+					Role: "server",
+					Template: `apiVersion: apps/v1
+kind: Deployment
+spec:
+  replicas: {{ .replicas }}
+  template:
+    metadata:
+      labels:
+        app: {{ .benchmark.name }}-{{ .role }}`,
+				},
+				{
+					Role: "service",
+					Template: `apiVersion: v1
+kind: Service
+spec:
+  selector:
+    app: {{ .benchmark.name }}-server`,
+					Keep: true,
+				},
+			},
+		},
+	}
+	hashItem := cpev1.IterationHash{Hash: "123", Build: "init", Iteration: map[string]string{"replicas": "2"}, Repetition: "0"}
+	resources, err := controllers.GetScenarioResources(benchmark, hashItem)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(resources))
+	assert.Equal(t, "iperf-cpeh-123-server", resources[0].GetName())
+	assert.Equal(t, "default", resources[0].GetNamespace())
+	assert.Equal(t, "server", resources[0].GetLabels()[controllers.ROLE_LABEL])
+	replicas, _, _ := unstructured.NestedInt64(resources[0].Object, "spec", "replicas")
+	assert.Equal(t, int64(2), replicas)
+	app, _, _ := unstructured.NestedString(resources[0].Object, "spec", "template", "metadata", "labels", "app")
+	assert.Equal(t, "iperf-server", app)
+	assert.Equal(t, "Service", resources[1].GetKind())
+
+	// client refers resource names
+	context := controllers.GetTemplateContext(benchmark, hashItem.Iteration, "init", 0, "iperf-cpeh-123")
+	executedSpec, err := controllers.ExecuteBenchmarkTemplate("{{ .resources.service.name }}", context)
+	assert.Nil(t, err)
+	assert.Equal(t, "iperf-cpeh-123-service", executedSpec)
+}
+
+func TestIsResourceReady(t *testing.T) {
+	deployment := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind":   "Deployment",
+		"spec":   map[string]interface{}{"replicas": int64(2)},
+		"status": map[string]interface{}{"readyReplicas": int64(1)},
+	}}
+	assert.False(t, controllers.IsResourceReady(deployment, nil))
+	deployment.Object["status"].(map[string]interface{})["readyReplicas"] = int64(2)
+	assert.True(t, controllers.IsResourceReady(deployment, nil))
+
+	pod := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind": "Pod",
+		"status": map[string]interface{}{
+			"phase":      "Running",
+			"conditions": []interface{}{map[string]interface{}{"type": "Ready", "status": "False"}},
+		},
+	}}
+	assert.False(t, controllers.IsResourceReady(pod, nil))
+	assert.True(t, controllers.IsResourceReady(pod, &cpev1.ReadinessSpec{Path: ".status.phase", Value: "Running"}))
+	assert.False(t, controllers.IsResourceReady(pod, &cpev1.ReadinessSpec{Condition: "Ready"}))
+
+	service := &unstructured.Unstructured{Object: map[string]interface{}{"kind": "Service"}}
+	assert.True(t, controllers.IsResourceReady(service, nil))
+}
@@ -0,0 +1,169 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+// go test -v cpe_test/spec_source_test.go
+
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	"github.com/IBM/cpe-operator/controllers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestResolveBenchmarkSpec(t *testing.T) {
+	template := "template:\n  spec: {}\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, template)
+	}))
+	defer server.Close()
+
+	benchmark := &cpev1.Benchmark{
+		ObjectMeta: metav1.ObjectMeta{Name: "coremark", Namespace: "default"},
+		Spec:       cpev1.BenchmarkSpec{Spec: "inline"},
+	}
+	spec, revision, err := controllers.ResolveBenchmarkSpec(nil, benchmark)
+	assert.Nil(t, err)
+	assert.Equal(t, "inline", spec)
+	assert.Equal(t, "", revision)
+
+	benchmark.Spec.SpecFrom = &cpev1.BenchmarkSpecSource{URL: server.URL}
+	spec, revision, err = controllers.ResolveBenchmarkSpec(nil, benchmark)
+	assert.Nil(t, err)
+	assert.Equal(t, template, spec)
+	assert.Equal(t, controllers.GetSpecRevision(template), revision)
+
+	// fetched again once the poll interval passes
+	template = "template:\n  spec:\n    restartPolicy: Never\n"
+	_, cachedRevision, err := controllers.ResolveBenchmarkSpec(nil, benchmark)
+	assert.Nil(t, err)
+	assert.Equal(t, revision, cachedRevision)
+	benchmark.Spec.SpecFrom.PollIntervalSeconds = 1
+	time.Sleep(time.Second)
+	_, newRevision, err := controllers.ResolveBenchmarkSpec(nil, benchmark)
+	assert.Nil(t, err)
+	assert.NotEqual(t, revision, newRevision)
+
+	// too large template
+	largeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, strings.Repeat("#", controllers.MAX_SPEC_SIZE+1))
+	}))
+	defer largeServer.Close()
+	benchmark.Spec.SpecFrom = &cpev1.BenchmarkSpecSource{URL: largeServer.URL}
+	_, _, err = controllers.ResolveBenchmarkSpec(nil, benchmark)
+	assert.NotNil(t, err)
+
+	// neither benchmarkSpec nor benchmarkSpecFrom
+	benchmark.Spec = cpev1.BenchmarkSpec{}
+	_, _, err = controllers.ResolveBenchmarkSpec(nil, benchmark)
+	assert.NotNil(t, err)
+}
+
+func TestStartSpecRevision(t *testing.T) {
+	benchmark := &cpev1.Benchmark{
+		ObjectMeta: metav1.ObjectMeta{Name: "coremark", Namespace: "default"},
+	}
+	benchmark.Status.Hash = []cpev1.IterationHash{{Hash: "1", Build: "init", Repetition: "0"}}
+
+	// first revision without results
+	previousHash, changed := controllers.StartSpecRevision(benchmark, "rev1")
+	assert.True(t, changed)
+	assert.Equal(t, 1, len(previousHash))
+	assert.Equal(t, 0, len(benchmark.Status.Hash))
+	assert.Equal(t, 0, len(benchmark.Status.ResultHistory))
+
+	_, changed = controllers.StartSpecRevision(benchmark, "rev1")
+	assert.False(t, changed)
+
+	benchmark.Status.Hash = []cpev1.IterationHash{{Hash: "2", Build: "init", Repetition: "0"}}
+	benchmark.Status.Results = []cpev1.BenchmarkResult{newResult("init", 1, 2)}
+	benchmark.Status.Regressions = []cpev1.RegressionResult{{BuildID: "init"}}
+	previousHash, changed = controllers.StartSpecRevision(benchmark, "rev2")
+	assert.True(t, changed)
+	assert.Equal(t, "2", previousHash[0].Hash)
+	assert.Equal(t, "rev2", benchmark.Status.SpecRevision)
+	assert.Equal(t, 0, len(benchmark.Status.Results))
+	assert.Equal(t, 0, len(benchmark.Status.Regressions))
+	assert.Equal(t, 1, len(benchmark.Status.ResultHistory))
+	assert.Equal(t, "rev1", benchmark.Status.ResultHistory[0].Revision)
+	assert.Equal(t, 2, len(benchmark.Status.ResultHistory[0].Results[0].Items))
+}
+
+func newFakeArtifactRegistry(template string, fetched *int) *httptest.Server {
+	sum := sha256.Sum256([]byte(template))
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/cpe/templates/manifests/v1":
+			*fetched++
+			fmt.Fprintf(w, `{"schemaVersion": 2, "layers": [{"digest": "%s", "annotations": {"org.opencontainers.image.title": "job.yaml"}}]}`, digest)
+		case "/v2/cpe/templates/manifests/corrupt":
+			fmt.Fprint(w, `{"schemaVersion": 2, "layers": [{"digest": "sha256:0000", "annotations": {"org.opencontainers.image.title": "job.yaml"}}]}`)
+		case "/v2/cpe/templates/blobs/" + digest, "/v2/cpe/templates/blobs/sha256:0000":
+			fmt.Fprint(w, template)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestResolveBenchmarkSpecFromOCI(t *testing.T) {
+	template := "template:\n  spec: {}\n"
+	fetched := 0
+	server := newFakeArtifactRegistry(template, &fetched)
+	defer server.Close()
+	registry := strings.TrimPrefix(server.URL, "http://")
+
+	benchmark := &cpev1.Benchmark{ObjectMeta: metav1.ObjectMeta{Name: "coremark", Namespace: "default"}}
+	benchmark.Spec.SpecFrom = &cpev1.BenchmarkSpecSource{OCI: &cpev1.OCIArtifactSource{Image: registry + "/cpe/templates:v1", File: "job.yaml", Insecure: true}}
+	spec, _, err := controllers.ResolveBenchmarkSpec(nil, benchmark)
+	assert.Nil(t, err)
+	assert.Equal(t, template, spec)
+
+	// cached within the poll interval
+	_, _, err = controllers.ResolveBenchmarkSpec(nil, benchmark)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, fetched)
+
+	// content does not match the digest
+	benchmark.Spec.SpecFrom.OCI.Image = registry + "/cpe/templates:corrupt"
+	_, _, err = controllers.ResolveBenchmarkSpec(nil, benchmark)
+	assert.NotNil(t, err)
+
+	// too large template
+	largeServer := newFakeArtifactRegistry(strings.Repeat("#", controllers.MAX_SPEC_SIZE+1), &fetched)
+	defer largeServer.Close()
+	benchmark.Spec.SpecFrom.OCI.Image = strings.TrimPrefix(largeServer.URL, "http://") + "/cpe/templates:v1"
+	_, _, err = controllers.ResolveBenchmarkSpec(nil, benchmark)
+	assert.NotNil(t, err)
+}
+
+func TestGetArtifactLayerDigest(t *testing.T) {
+	manifest := []byte(`{"schemaVersion": 2, "layers": [
+		{"digest": "sha256:aaa", "annotations": {"org.opencontainers.image.title": "job.yaml"}},
+		{"digest": "sha256:bbb", "annotations": {"org.opencontainers.image.title": "workflow.yaml"}}]}`)
+	digest, err := controllers.GetArtifactLayerDigest(manifest, "")
+	assert.Nil(t, err)
+	assert.Equal(t, "sha256:aaa", digest)
+	digest, err = controllers.GetArtifactLayerDigest(manifest, "workflow.yaml")
+	assert.Nil(t, err)
+	assert.Equal(t, "sha256:bbb", digest)
+	_, err = controllers.GetArtifactLayerDigest(manifest, "none.yaml")
+	assert.NotNil(t, err)
+
+	assert.Equal(t, "v1", controllers.GetImageReference("quay.io/cpe/templates:v1"))
+	assert.Equal(t, "latest", controllers.GetImageReference("localhost:5000/cpe/templates"))
+	assert.Equal(t, "sha256:aaa", controllers.GetImageReference("quay.io/cpe/templates@sha256:aaa"))
+}
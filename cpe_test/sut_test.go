@@ -0,0 +1,64 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+// go test -v cpe_test/sut_test.go
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	"github.com/IBM/cpe-operator/controllers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestGetSystemUnderTest(t *testing.T) {
+	benchmark := &cpev1.Benchmark{
+		ObjectMeta: metav1.ObjectMeta{Name: "cockroach", Namespace: "default"},
+		Spec: cpev1.BenchmarkSpec{
+			IterationSpec: cpev1.IterationSpec{
+				Iteration:     []cpev1.IterationItem{{Name: "workload", Values: []string{"kv", "bank"}}},
+				Configuration: []cpev1.IterationItem{{Name: "nodes", Values: []string{"3", "5"}}},
+			},
+			SystemUnderTest: &cpev1.SystemUnderTestSpec{
+				Resources: []cpev1.ScenarioResourceSpec{
+					{
+						Role: "db",
+						Template: `apiVersion: crdb.cockroachlabs.com/v1alpha1
+kind: CrdbCluster
+spec:
+  nodes: {{ .nodes }}`,
+					},
+				},
+			},
+		},
+	}
+	kv := map[string]string{"workload": "kv", "nodes": "3"}
+	bank := map[string]string{"workload": "bank", "nodes": "3"}
+	scaled := map[string]string{"workload": "kv", "nodes": "5"}
+
+	// shared by iterations of the same configuration
+	assert.Equal(t, map[string]string{"nodes": "3"}, controllers.GetConfigurationLabel(benchmark, kv))
+	assert.Equal(t, controllers.GetSystemUnderTestHash(benchmark, kv), controllers.GetSystemUnderTestHash(benchmark, bank))
+	assert.NotEqual(t, controllers.GetSystemUnderTestHash(benchmark, kv), controllers.GetSystemUnderTestHash(benchmark, scaled))
+
+	resources, err := controllers.GetSystemUnderTest(benchmark, kv)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(resources))
+	configurationHash := controllers.GetSystemUnderTestHash(benchmark, kv)
+	assert.Equal(t, "cockroach-sut-"+configurationHash+"-db", resources[0].GetName())
+	assert.Equal(t, configurationHash, resources[0].GetLabels()[controllers.SYSTEM_UNDER_TEST_CONFIG_LABEL])
+	nodes, _, _ := unstructured.NestedInt64(resources[0].Object, "spec", "nodes")
+	assert.Equal(t, int64(3), nodes)
+
+	// client refers the system of its configuration
+	context := controllers.GetTemplateContext(benchmark, bank, "", 0, "cockroach-cpeh-123")
+	executedSpec, err := controllers.ExecuteBenchmarkTemplate("{{ .systemUnderTest.db.name }}", context)
+	assert.Nil(t, err)
+	assert.Equal(t, "cockroach-sut-"+configurationHash+"-db", executedSpec)
+}
@@ -0,0 +1,62 @@
+/*
+ * Copyright 2022- IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ */
+// go test -v cpe_test/template_context_test.go
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	cpev1 "github.com/IBM/cpe-operator/api/v1"
+	"github.com/IBM/cpe-operator/controllers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestExecuteBenchmarkTemplate(t *testing.T) {
+	benchmark := &cpev1.Benchmark{
+		ObjectMeta: metav1.ObjectMeta{Name: "coremark", Namespace: "default"},
+	}
+	iterationLabel := map[string]string{"threads": "4", "args": "-a;-b"}
+	context := controllers.GetTemplateContext(benchmark, iterationLabel, "init", 2, "coremark-job")
+	assert.Equal(t, controllers.NODESELECT_ITR_DEFAULT, context[controllers.TEMPLATE_NODE_PROFILE_KEY])
+
+	spec := `name: {{ .benchmark.name }}-{{ .benchmark.namespace }}-{{ .repetition }}-{{ .jobName }}
+threads: {{ mul .threads 2 }}
+half: {{ div .threads 3 }}
+ratio: {{ div .threads 8.0 }}
+args: {{ join "," .args | upper }}
+image: {{ default "busybox" .build.image }}
+{{- if gt (toInt .threads) 2 }}
+large: true
+{{- end }}
+workers:{{ range seq .threads }} w{{ . }}{{ end }}
+labels:{{ toYaml .benchmark | nindent 2 }}`
+	executedSpec, err := controllers.ExecuteBenchmarkTemplate(spec, context)
+	assert.Nil(t, err)
+	assert.Equal(t, `name: coremark-default-2-coremark-job
+threads: 8
+half: 1
+ratio: 0.5
+args: -A,-B
+image: busybox
+large: true
+workers: w1 w2 w3 w4
+labels:
+  name: coremark
+  namespace: default`, executedSpec)
+
+	// iteration values take precedence
+	context = controllers.GetTemplateContext(benchmark, map[string]string{"repetition": "x"}, "init", 0, "")
+	executedSpec, err = controllers.ExecuteBenchmarkTemplate("{{ .repetition }}", context)
+	assert.Nil(t, err)
+	assert.Equal(t, "x", executedSpec)
+
+	_, err = controllers.ExecuteBenchmarkTemplate("{{ div 1 0 }}", context)
+	assert.NotNil(t, err)
+	_, err = controllers.ExecuteBenchmarkTemplate("{{ seq 100000 }}", context)
+	assert.NotNil(t, err)
+}
@@ -5,8 +5,10 @@ go 1.15
 require (
 	github.com/Azure/go-autorest/autorest v0.11.12 // indirect
 	github.com/IBM/ibm-cos-sdk-go v1.7.0
+	github.com/Masterminds/semver/v3 v3.1.1
 	github.com/d4l3k/go-bayesopt v0.0.0-20191110222447-8506d3040732
 	github.com/go-logr/logr v0.4.0
+	github.com/google/cel-go v0.12.4
 	github.com/google/gofuzz v1.2.0 // indirect
 	github.com/googleapis/gnostic v0.5.5 // indirect
 	github.com/json-iterator/go v1.1.11 // indirect
@@ -23,6 +25,7 @@ require (
 	golang.org/x/term v0.0.0-20210503060354-a79de5458b56 // indirect
 	golang.org/x/time v0.0.0-20210220033141-f8bda1e9f3ba // indirect
 	google.golang.org/appengine v1.6.7 // indirect
+	google.golang.org/genproto v0.0.0-20220502173005-c8bf987b8c21
 	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b // indirect
 	helm.sh/helm/v3 v3.5.1
 	k8s.io/api v0.20.1
@@ -31,4 +34,5 @@ require (
 	k8s.io/utils v0.0.0-20210305010621-2afb4311ab10 // indirect
 	sigs.k8s.io/controller-runtime v0.7.2
 	sigs.k8s.io/structured-merge-diff/v4 v4.1.1 // indirect
+	sigs.k8s.io/yaml v1.2.0
 )
@@ -110,6 +110,11 @@ func main() {
 		DYN:       dyn,
 	}
 
+	nodeReserver := &controllers.NodeReserver{
+		Clientset: clientset,
+		Log:       ctrl.Log.WithName("controllers").WithName("NodeReserver"),
+	}
+
 	jobTrackers := make(map[string]*controllers.JobTracker)
 	cos := controllers.COSObject{}
 	cos.InitValue()
@@ -126,24 +131,51 @@ func main() {
 		Log:          ctrl.Log.WithName("controllers").WithName("JobTracker"),
 		DC:           dc,
 		DYN:          dyn,
+		Reserver:     nodeReserver,
+		Recorder:     mgr.GetEventRecorderFor("cpe-operator"),
 		TunedHandler: tunedHandler,
 	}
 
 	go jobTrackManager.Run()
 
+	buildQueue := make(chan *unstructured.Unstructured, BUILD_MAX_QSIZE)
+	defer close(buildQueue)
+
+	buildWatcher := &controllers.BuildWatcher{
+		Client:     mgr.GetClient(),
+		Clientset:  clientset,
+		Log:        ctrl.Log.WithName("trackers").WithName("BuildWatcher"),
+		Scheme:     mgr.GetScheme(),
+		DC:         dc,
+		DYN:        dyn,
+		BuildQueue: buildQueue,
+		Quit:       quit,
+	}
+
 	if err = (&controllers.BenchmarkReconciler{
 		Client:       mgr.GetClient(),
+		APIReader:    mgr.GetAPIReader(),
 		Log:          ctrl.Log.WithName("controllers").WithName("Benchmark"),
 		Scheme:       mgr.GetScheme(),
 		DC:           dc,
 		DYN:          dyn,
 		JTM:          jobTrackManager,
 		TunedHandler: tunedHandler,
+		BuildWatcher: buildWatcher,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Benchmark")
 		os.Exit(1)
 	}
 
+	if err = (&controllers.BenchmarkBaselineReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("BenchmarkBaseline"),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "BenchmarkBaseline")
+		os.Exit(1)
+	}
+
 	helmOpt := &helmclient.RestConfClientOptions{
 		Options: &helmclient.Options{
 			RepositoryCache:  "/tmp/.helmcache",
@@ -178,23 +210,13 @@ func main() {
 	}
 	benchmarkOperator.DeployNoneOperator()
 
-	buildQueue := make(chan *unstructured.Unstructured, BUILD_MAX_QSIZE)
-	defer close(buildQueue)
-
-	buildWatcher := &controllers.BuildWatcher{
-		Client:     mgr.GetClient(),
-		Log:        ctrl.Log.WithName("trackers").WithName("BuildWatcher"),
-		Scheme:     mgr.GetScheme(),
-		DC:         dc,
-		DYN:        dyn,
-		BuildQueue: buildQueue,
-		Quit:       quit,
-	}
-
+	// generic build sources are watched when referred by benchmarks
 	err = buildWatcher.InitInformer()
-	if err == nil {
-		go buildWatcher.Run()
+	if err != nil {
+		setupLog.Info(fmt.Sprintf("No built-in build resource: %v", err))
 	}
+	go buildWatcher.Run()
+	go buildWatcher.RunImagePoller()
 
 	controllers.NewCollector(mgr.GetClient(), ctrl.Log.WithName("controllers").WithName("ResultCollector"))
 